@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	_ "time/tzdata" // Digest time zones on hosts without a zoneinfo database
 
 	"github.com/gin-gonic/gin"
 	"github.com/tom-fitz/trailmemo-api/config"
 	"github.com/tom-fitz/trailmemo-api/internal/database"
 	"github.com/tom-fitz/trailmemo-api/internal/handlers"
+	"github.com/tom-fitz/trailmemo-api/internal/jobs"
 	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/realtime"
 	"github.com/tom-fitz/trailmemo-api/internal/repository"
 	"github.com/tom-fitz/trailmemo-api/internal/services"
 )
@@ -42,14 +47,130 @@ func main() {
 		log.Fatalf("Failed to initialize Firebase: %v", err)
 	}
 
+	// Initialize audio processing
+	audioValidator := services.NewAudioValidator(cfg.FFprobePath)
+	transcoder := services.NewTranscoder(cfg.FFmpegPath, cfg.TranscodeOpusBitrateKbps, cfg.TranscodeMP3BitrateKbps)
+	waveformGenerator := services.NewWaveformGenerator(cfg.FFmpegPath)
+	transcriber, err := services.NewTranscriber(cfg.TranscriberEngine, cfg.WhisperPath, cfg.WhisperModelPath, cfg.FFmpegPath, cfg.TranscriptionMaxDuration)
+	if err != nil {
+		log.Fatalf("Failed to initialize transcriber: %v", err)
+	}
+	tagger, err := services.NewTagger(cfg.TagVocabularyPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize tagger: %v", err)
+	}
+
+	// Initialize email
+	mailer, err := services.NewMailer(cfg.Mailer, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	emailTemplates, err := services.NewEmailTemplates()
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+
+	// Initialize push notifications
+	pushSender, err := services.NewPushSender(cfg.PushSender, firebaseService, services.APNsConfig{
+		KeyPath:     cfg.APNsKeyPath,
+		KeyID:       cfg.APNsKeyID,
+		TeamID:      cfg.APNsTeamID,
+		Topic:       cfg.APNsTopic,
+		Environment: cfg.APNsEnvironment,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize push sender: %v", err)
+	}
+
+	// Initialize signed audio links for exports
+	audioLinks := services.NewAudioLinkSigner(cfg.ExportLinkSecret, cfg.ExportLinkExpiry)
+	if cfg.PublicBaseURL == "" {
+		log.Println("PUBLIC_BASE_URL is not set; exports will not include audio links")
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	memoRepo := repository.NewMemoRepository(db)
+	revisionRepo := repository.NewRevisionRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	audioVariantRepo := repository.NewAudioVariantRepository(db)
+	waveformRepo := repository.NewWaveformRepository(db)
+	transcriptRepo := repository.NewTranscriptRepository(db)
+	tagSuggestionRepo := repository.NewTagSuggestionRepository(db)
+	savedSearchRepo := repository.NewSavedSearchRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationPrefRepo := repository.NewNotificationPreferenceRepository(db)
+	memoEventRepo := repository.NewMemoEventRepository(db)
+	crewPositionRepo := repository.NewCrewPositionRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	pushDeviceRepo := repository.NewPushDeviceRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	// Start background job worker
+	worker := jobs.NewWorker(jobRepo, cfg.WorkerConcurrency)
+	worker.Register(models.JobTypeTranscodeAudio, jobs.NewTranscodeJob(memoRepo, audioVariantRepo, firebaseService, transcoder).Handle)
+	worker.Register(models.JobTypeAudioWaveform, jobs.NewWaveformJob(memoRepo, waveformRepo, firebaseService, waveformGenerator).Handle)
+	worker.RegisterWithLease(
+		models.JobTypeTranscribe,
+		jobs.NewTranscribeJob(memoRepo, revisionRepo, transcriptRepo, jobRepo, firebaseService, transcriber, cfg.TranscriptionLanguage, cfg.TranscriptionMaxDuration).Handle,
+		jobs.TranscribeLease(cfg.TranscriptionMaxDuration),
+	)
+	worker.Register(models.JobTypeSuggestTags, jobs.NewSuggestTagsJob(memoRepo, tagSuggestionRepo, tagger).Handle)
+	worker.Register(models.JobTypeMatchSearches, jobs.NewMatchSavedSearchesJob(memoRepo, savedSearchRepo).Handle)
+	worker.Register(models.JobTypeMentions, jobs.NewMentionsJob(memoRepo, userRepo, mentionRepo).Handle)
+	worker.Register(models.JobTypeSearchWebhook, jobs.NewSavedSearchWebhookJob(memoRepo, savedSearchRepo).Handle)
+	worker.Register(models.JobTypeDeliverWebhook, jobs.NewWebhookDeliveryJob(memoRepo, webhookRepo).Handle)
+	worker.Register(models.JobTypeEmail, jobs.NewEmailNotificationJob(userRepo, notificationPrefRepo, mailer, emailTemplates).Handle)
+	worker.Register(models.JobTypeDigest, jobs.NewDigestJob(userRepo, notificationPrefRepo, memoRepo, mailer, emailTemplates).Handle)
+	worker.Register(models.JobTypePush, jobs.NewPushNotificationJob(pushDeviceRepo, notificationPrefRepo, pushSender).Handle)
+	worker.Register(models.JobTypeReport, jobs.NewReportJob(reportRepo, memoRepo, statsRepo, userRepo, notificationRepo, firebaseService).Handle)
+	worker.Start(context.Background())
+	jobs.NewDigestScheduler(notificationPrefRepo).Start(context.Background())
+	jobs.NewUploadSweeper(uploadRepo, firebaseService).Start(context.Background())
+
+	// Start the memo change feed for streaming clients
+	memoFeed := realtime.NewMemoFeed(cfg.DatabaseURL, memoEventRepo, memoRepo)
+	if err := memoFeed.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start memo feed: %v", err)
+	}
+
+	// Start relaying crew positions between presence connections
+	presenceHub := realtime.NewPresenceHub(cfg.DatabaseURL, crewPositionRepo)
+	if err := presenceHub.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start presence hub: %v", err)
+	}
 
 	// Initialize handlers
+	auditLogger := handlers.NewAuditLogger(auditRepo)
 	healthHandler := handlers.NewHealthHandler()
-	authHandler := handlers.NewAuthHandler(userRepo, firebaseService)
-	memoHandler := handlers.NewMemoHandler(memoRepo, userRepo, firebaseService, cfg.MaxUploadSize)
+	authHandler := handlers.NewAuthHandler(userRepo, firebaseService, auditLogger)
+	memoHandler := handlers.NewMemoHandler(memoRepo, userRepo, uploadRepo, jobRepo, firebaseService, audioValidator, auditLogger, cfg.MaxUploadSize, cfg.AudioURLExpiry)
+	revisionHandler := handlers.NewRevisionHandler(memoRepo, revisionRepo)
+	audioHandler := handlers.NewAudioHandler(memoRepo, waveformRepo)
+	tagHandler := handlers.NewTagHandler(memoRepo, tagSuggestionRepo, jobRepo, auditLogger)
+	savedSearchHandler := handlers.NewSavedSearchHandler(savedSearchRepo)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo, notificationPrefRepo)
+	streamHandler := handlers.NewStreamHandler(memoFeed)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, auditLogger)
+	deviceHandler := handlers.NewDeviceHandler(pushDeviceRepo)
+	mentionHandler := handlers.NewMentionHandler(mentionRepo)
+	statsHandler := handlers.NewStatsHandler(statsRepo)
+	reportHandler := handlers.NewReportHandler(reportRepo, jobRepo, firebaseService, auditLogger, cfg.AudioURLExpiry)
+	exportHandler := handlers.NewExportHandler(memoRepo, firebaseService, audioLinks, auditLogger, cfg.PublicBaseURL, cfg.AudioURLExpiry)
+	adminHandler := handlers.NewAdminHandler(userRepo, auditRepo, auditLogger)
+	presenceHandler := handlers.NewPresenceHandler(userRepo, crewPositionRepo, presenceHub)
+	transcriptHandler := handlers.NewTranscriptHandler(memoRepo, transcriptRepo, jobRepo, transcriber, cfg.TranscriptionMaxDuration)
+	uploadHandler := handlers.NewUploadHandler(
+		uploadRepo,
+		firebaseService,
+		cfg.MaxUploadSize,
+		cfg.PresignedURLExpiry,
+		cfg.ResumableUploadExpiry,
+	)
 
 	// Set up Gin router
 	r := gin.Default()
@@ -79,9 +200,132 @@ func main() {
 			memos.GET("", memoHandler.List)
 			memos.GET("/nearby", memoHandler.GetNearby)
 			memos.GET("/search", memoHandler.Search)
+			memos.GET("/autocomplete", memoHandler.Autocomplete)
 			memos.GET("/:id", memoHandler.GetByID)
 			memos.PUT("/:id", memoHandler.Update)
 			memos.DELETE("/:id", memoHandler.Delete)
+			memos.GET("/:id/revisions", revisionHandler.List)
+			memos.GET("/:id/revisions/diff", revisionHandler.Diff)
+			memos.GET("/:id/waveform", audioHandler.GetWaveform)
+			memos.GET("/:id/transcript", transcriptHandler.Get)
+			memos.POST("/:id/transcription", transcriptHandler.Request)
+			memos.GET("/:id/suggestions", tagHandler.GetSuggestions)
+			memos.POST("/:id/suggestions/accept", tagHandler.AcceptSuggestions)
+		}
+
+		// Saved search routes (all require authentication)
+		savedSearches := v1.Group("/saved-searches")
+		savedSearches.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			savedSearches.POST("", savedSearchHandler.Create)
+			savedSearches.GET("", savedSearchHandler.List)
+			savedSearches.GET("/:id", savedSearchHandler.GetByID)
+			savedSearches.PUT("/:id", savedSearchHandler.Update)
+			savedSearches.DELETE("/:id", savedSearchHandler.Delete)
+		}
+
+		// Notification routes (all require authentication)
+		notifications := v1.Group("/notifications")
+		notifications.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			notifications.GET("", notificationHandler.List)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+			notifications.POST("/read-all", notificationHandler.MarkAllRead)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+		}
+
+		// Statistics routes (require authentication and the supervisor or admin role)
+		stats := v1.Group("/stats")
+		stats.Use(middleware.AuthMiddleware(firebaseService), middleware.RequireRole(userRepo, models.RoleSupervisor, models.RoleAdmin))
+		{
+			stats.GET("/summary", statsHandler.Summary)
+			stats.GET("/memos", statsHandler.Memos)
+			stats.GET("/breakdown", statsHandler.Breakdown)
+		}
+
+		// Report routes (all require authentication)
+		reports := v1.Group("/reports")
+		reports.Use(middleware.AuthMiddleware(firebaseService), middleware.RequireRole(userRepo, models.RoleSupervisor, models.RoleAdmin))
+		{
+			reports.POST("", reportHandler.Create)
+			reports.GET("", reportHandler.List)
+			reports.GET("/:id", reportHandler.GetByID)
+			reports.GET("/:id/download", reportHandler.Download)
+			reports.DELETE("/:id", reportHandler.Delete)
+		}
+
+		// Export routes, for supervisors and admins. Audio links are signed,
+		// so they open from a spreadsheet without a token.
+		export := v1.Group("/export")
+		{
+			requireSupervisor := middleware.RequireRole(userRepo, models.RoleSupervisor, models.RoleAdmin)
+			export.GET("/memos.csv", middleware.AuthMiddleware(firebaseService), requireSupervisor, exportHandler.MemosCSV)
+			export.GET("/memos.xlsx", middleware.AuthMiddleware(firebaseService), requireSupervisor, exportHandler.MemosXLSX)
+			export.GET("/audio/:id", exportHandler.Audio)
+		}
+
+		// Admin routes (require authentication and the admin role)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(firebaseService), middleware.RequireRole(userRepo, models.RoleAdmin))
+		{
+			admin.GET("/audit", adminHandler.ListAudit)
+			admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
+			admin.GET("/users/:id/parks", adminHandler.GetUserParks)
+			admin.PUT("/users/:id/parks", adminHandler.UpdateUserParks)
+		}
+
+		// Mention routes (require authentication)
+		v1.GET("/mentions", middleware.AuthMiddleware(firebaseService), mentionHandler.List)
+
+		// Push device routes (all require authentication)
+		devices := v1.Group("/devices")
+		devices.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			devices.POST("", deviceHandler.Register)
+			devices.GET("", deviceHandler.List)
+			devices.DELETE("/:id", deviceHandler.Delete)
+		}
+
+		// Webhook routes (all require authentication)
+		webhooks := v1.Group("/webhooks")
+		webhooks.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			webhooks.POST("", webhookHandler.Create)
+			webhooks.GET("", webhookHandler.List)
+			webhooks.GET("/:id", webhookHandler.GetByID)
+			webhooks.PUT("/:id", webhookHandler.Update)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooks.GET("/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+
+		// Server-Sent Events streams (all require authentication)
+		stream := v1.Group("/stream")
+		stream.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			stream.GET("/memos", streamHandler.Memos)
+		}
+
+		// Crew presence WebSocket (requires authentication)
+		v1.GET("/presence", middleware.AuthMiddleware(firebaseService), presenceHandler.Connect)
+
+		// Upload routes (all require authentication)
+		upload := v1.Group("/upload")
+		upload.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			upload.GET("/presigned-url", uploadHandler.GetPresignedURL)
+		}
+
+		// Resumable (tus-style) upload routes (all require authentication)
+		uploads := v1.Group("/uploads")
+		uploads.Use(middleware.AuthMiddleware(firebaseService))
+		{
+			uploads.POST("", uploadHandler.CreateResumable)
+			uploads.HEAD("/:id", uploadHandler.GetResumableOffset)
+			uploads.PATCH("/:id", uploadHandler.PatchResumable)
+			uploads.DELETE("/:id", uploadHandler.DeleteResumable)
 		}
 	}
 
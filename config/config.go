@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +20,35 @@ type Config struct {
 	FirebaseServiceAccountJSON string
 	JWTSecret                  string
 	MaxUploadSize              int64
+	PresignedURLExpiry         time.Duration
+	ResumableUploadExpiry      time.Duration
+	FFprobePath                string
+	FFmpegPath                 string
+	TranscodeOpusBitrateKbps   int
+	TranscodeMP3BitrateKbps    int
+	WorkerConcurrency          int
+	AudioURLExpiry             time.Duration
+	TranscriberEngine          string
+	WhisperPath                string
+	WhisperModelPath           string
+	TranscriptionLanguage      string
+	TranscriptionMaxDuration   time.Duration
+	TagVocabularyPath          string
+	Mailer                     string
+	SMTPHost                   string
+	SMTPPort                   int
+	SMTPUsername               string
+	SMTPPassword               string
+	EmailFrom                  string
+	PushSender                 string
+	APNsKeyPath                string
+	APNsKeyID                  string
+	APNsTeamID                 string
+	APNsTopic                  string
+	APNsEnvironment            string
+	PublicBaseURL              string
+	ExportLinkSecret           string
+	ExportLinkExpiry           time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -35,6 +65,20 @@ func Load() *Config {
 		}
 	}
 
+	presignedURLExpiry := 15 * time.Minute
+	if minutes := os.Getenv("PRESIGNED_URL_EXPIRY_MINUTES"); minutes != "" {
+		if parsed, err := strconv.Atoi(minutes); err == nil && parsed > 0 {
+			presignedURLExpiry = time.Duration(parsed) * time.Minute
+		}
+	}
+
+	resumableUploadExpiry := 24 * time.Hour
+	if hours := os.Getenv("RESUMABLE_UPLOAD_EXPIRY_HOURS"); hours != "" {
+		if parsed, err := strconv.Atoi(hours); err == nil && parsed > 0 {
+			resumableUploadExpiry = time.Duration(parsed) * time.Hour
+		}
+	}
+
 	return &Config{
 		Port:                       getEnv("PORT", "8080"),
 		Environment:                getEnv("ENV", "development"),
@@ -45,6 +89,35 @@ func Load() *Config {
 		FirebaseServiceAccountJSON: getEnv("FIREBASE_SERVICE_ACCOUNT_JSON", ""),
 		JWTSecret:                  getEnv("JWT_SECRET", ""),
 		MaxUploadSize:              maxUploadSize,
+		PresignedURLExpiry:         presignedURLExpiry,
+		ResumableUploadExpiry:      resumableUploadExpiry,
+		FFprobePath:                getEnv("FFPROBE_PATH", "ffprobe"),
+		FFmpegPath:                 getEnv("FFMPEG_PATH", "ffmpeg"),
+		TranscodeOpusBitrateKbps:   getEnvInt("TRANSCODE_OPUS_BITRATE_KBPS", 32),
+		TranscodeMP3BitrateKbps:    getEnvInt("TRANSCODE_MP3_BITRATE_KBPS", 64),
+		WorkerConcurrency:          getEnvInt("WORKER_CONCURRENCY", 2),
+		AudioURLExpiry:             time.Duration(getEnvInt("AUDIO_URL_EXPIRY_MINUTES", 60)) * time.Minute,
+		TranscriberEngine:          getEnv("TRANSCRIBER_ENGINE", "whisper"),
+		WhisperPath:                getEnv("WHISPER_PATH", "whisper-cli"),
+		WhisperModelPath:           getEnv("WHISPER_MODEL_PATH", ""),
+		TranscriptionLanguage:      getEnv("TRANSCRIPTION_LANGUAGE", "en"),
+		TranscriptionMaxDuration:   time.Duration(getEnvInt("TRANSCRIPTION_MAX_MINUTES", 60)) * time.Minute,
+		TagVocabularyPath:          getEnv("TAG_VOCABULARY_PATH", ""),
+		Mailer:                     getEnv("MAILER", "smtp"),
+		SMTPHost:                   getEnv("SMTP_HOST", ""),
+		SMTPPort:                   getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:               getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:               getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:                  getEnv("EMAIL_FROM", "TrailMemo <noreply@trailmemo.app>"),
+		PushSender:                 getEnv("PUSH_SENDER", "live"),
+		APNsKeyPath:                getEnv("APNS_KEY_PATH", ""),
+		APNsKeyID:                  getEnv("APNS_KEY_ID", ""),
+		APNsTeamID:                 getEnv("APNS_TEAM_ID", ""),
+		APNsTopic:                  getEnv("APNS_TOPIC", ""),
+		APNsEnvironment:            getEnv("APNS_ENVIRONMENT", "production"),
+		PublicBaseURL:              getEnv("PUBLIC_BASE_URL", ""),
+		ExportLinkSecret:           getEnv("EXPORT_LINK_SECRET", ""),
+		ExportLinkExpiry:           time.Duration(getEnvInt("EXPORT_LINK_EXPIRY_HOURS", 24)) * time.Hour,
 	}
 }
 
@@ -56,6 +129,16 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt gets a positive integer environment variable with a fallback default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
 // Validate checks if required configuration is present
 func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
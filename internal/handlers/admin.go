@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// AdminHandler handles organisation administration requests. Its routes are
+// restricted to admins.
+type AdminHandler struct {
+	userRepo  *repository.UserRepository
+	auditRepo *repository.AuditRepository
+	audit     *AuditLogger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(userRepo *repository.UserRepository, auditRepo *repository.AuditRepository, audit *AuditLogger) *AdminHandler {
+	return &AdminHandler{
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		audit:     audit,
+	}
+}
+
+// ListAudit retrieves the audit log, newest first
+// GET /api/v1/admin/audit
+func (h *AdminHandler) ListAudit(c *gin.Context) {
+	filter := &models.AuditFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	if value := c.Query("start_date"); value != "" {
+		start, ok := parseFilterDate(value, false)
+		if !ok {
+			writeFilterError(c, "start_date", filterDateFormat)
+			return
+		}
+		filter.StartDate = &start
+	}
+
+	if value := c.Query("end_date"); value != "" {
+		end, ok := parseFilterDate(value, true)
+		if !ok {
+			writeFilterError(c, "end_date", filterDateFormat)
+			return
+		}
+		filter.EndDate = &end
+	}
+
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.StartDate.Before(*filter.EndDate) {
+		writeFilterError(c, "end_date", "Must be after start_date")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	entries, total, err := h.auditRepo.List(c.Request.Context(), filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching audit log",
+			},
+		})
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, models.AuditLogResponse{
+		Entries: entries,
+		Pagination: models.PaginationResponse{
+			CurrentPage:  page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: limit,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
+
+// UpdateUserRole changes a user's role. The last admin can't be demoted, so
+// the organisation is never left without one.
+// PUT /api/v1/admin/users/:id/role
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Role == req.Role {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	audit := h.audit.Entry(c, models.AuditActionRoleChange, models.AuditTargetUser, user.UserID, nil, nil, nil)
+	changed, err := h.userRepo.SetRole(c.Request.Context(), user.UserID, req.Role, audit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating user role",
+			},
+		})
+		return
+	}
+
+	if !changed {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Can't demote the last admin",
+			},
+		})
+		return
+	}
+
+	user.Role = req.Role
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUserParks lists the parks a user is assigned to
+// GET /api/v1/admin/users/:id/parks
+func (h *AdminHandler) GetUserParks(c *gin.Context) {
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	parks, err := h.userRepo.ListParks(c.Request.Context(), user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching user parks",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserParks{UserID: user.UserID, ParkNames: parks})
+}
+
+// UpdateUserParks replaces the parks a user is assigned to. Members can
+// follow the crew presence of these parks besides their department.
+// PUT /api/v1/admin/users/:id/parks
+func (h *AdminHandler) UpdateUserParks(c *gin.Context) {
+	var req models.UpdateParksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	parks := []string{}
+	for _, park := range req.ParkNames {
+		if park = strings.TrimSpace(park); park != "" && !utils.ContainsString(parks, park) {
+			parks = append(parks, park)
+		}
+	}
+	sort.Strings(parks)
+
+	audit := h.audit.Entry(c, models.AuditActionParksChange, models.AuditTargetUser, user.UserID, nil, nil, nil)
+	if err := h.userRepo.SetParks(c.Request.Context(), user.UserID, parks, audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating user parks",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserParks{UserID: user.UserID, ParkNames: parks})
+}
+
+// loadUser fetches the user named in the path. It writes the error response
+// and returns false if the user can't be loaded.
+func (h *AdminHandler) loadUser(c *gin.Context) (*models.User, bool) {
+	user, err := h.userRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching user",
+			},
+		})
+		return nil, false
+	}
+
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "User not found",
+			},
+		})
+		return nil, false
+	}
+
+	return user, true
+}
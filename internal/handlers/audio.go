@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// maxWaveformPoints caps the ?points= resolution a client may request
+const maxWaveformPoints = 5000
+
+// AudioHandler handles audio metadata requests
+type AudioHandler struct {
+	memoRepo     *repository.MemoRepository
+	waveformRepo *repository.WaveformRepository
+}
+
+// NewAudioHandler creates a new audio handler
+func NewAudioHandler(memoRepo *repository.MemoRepository, waveformRepo *repository.WaveformRepository) *AudioHandler {
+	return &AudioHandler{
+		memoRepo:     memoRepo,
+		waveformRepo: waveformRepo,
+	}
+}
+
+// GetWaveform retrieves the waveform peaks of a memo's recording
+// GET /api/v1/memos/:id/waveform
+func (h *AudioHandler) GetWaveform(c *gin.Context) {
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return
+	}
+
+	points := 0
+	if pointsStr := c.Query("points"); pointsStr != "" {
+		points, err = strconv.Atoi(pointsStr)
+		if err != nil || points < 1 || points > maxWaveformPoints {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid points",
+					"details": gin.H{
+						"points": "Must be between 1 and " + strconv.Itoa(maxWaveformPoints),
+					},
+				},
+			})
+			return
+		}
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return
+	}
+
+	if memo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo not found",
+			},
+		})
+		return
+	}
+
+	waveform, err := h.waveformRepo.GetByMemo(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching waveform",
+			},
+		})
+		return
+	}
+
+	if waveform == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Waveform not available yet",
+			},
+		})
+		return
+	}
+
+	if points > 0 && points < len(waveform.Peaks) {
+		waveform.PeaksPerSecond = waveform.PeaksPerSecond * float64(points) / float64(len(waveform.Peaks))
+		waveform.Peaks = downsamplePeaks(waveform.Peaks, points)
+	}
+
+	c.JSON(http.StatusOK, waveform)
+}
+
+// downsamplePeaks reduces peaks to n buckets, keeping the maximum of each
+// bucket so short loud sounds stay visible
+func downsamplePeaks(peaks []float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		start := i * len(peaks) / n
+		end := (i + 1) * len(peaks) / n
+		for _, p := range peaks[start:end] {
+			if p > out[i] {
+				out[i] = p
+			}
+		}
+	}
+	return out
+}
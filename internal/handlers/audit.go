@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// AuditLogger records mutating actions in the audit log, with the actor and
+// request they came from
+type AuditLogger struct {
+	auditRepo *repository.AuditRepository
+}
+
+// NewAuditLogger creates a new audit logger
+func NewAuditLogger(auditRepo *repository.AuditRepository) *AuditLogger {
+	return &AuditLogger{auditRepo: auditRepo}
+}
+
+// Record appends an entry for an action that has already succeeded. before
+// and after are snapshots of the target (nil when it didn't exist); metadata
+// may be nil. Failures are logged rather than returned, so that a completed
+// action is never reported as failed. Memo changes are instead recorded with
+// the change itself, from an Entry.
+func (a *AuditLogger) Record(c *gin.Context, action, targetType, targetID string, before, after interface{}, metadata gin.H) {
+	entry := a.Entry(c, action, targetType, targetID, before, after, metadata)
+
+	// Record even if the client has gone away since the action completed
+	if err := a.auditRepo.Record(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+		log.Printf("Error recording audit entry %s %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// Entry builds an entry for an action with the actor and request it came
+// from, for a repository to record in the same transaction as the action.
+// The repository fills in whatever isn't known until then, such as the ID
+// of a new target.
+func (a *AuditLogger) Entry(c *gin.Context, action, targetType, targetID string, before, after interface{}, metadata gin.H) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   auditString(targetID),
+		Before:     models.AuditSnapshot(before),
+		After:      models.AuditSnapshot(after),
+		RequestID:  auditString(c.GetHeader("X-Request-ID")),
+		IPAddress:  auditString(c.ClientIP()),
+		UserAgent:  auditString(c.Request.UserAgent()),
+		Method:     auditString(c.Request.Method),
+		Path:       auditString(c.Request.URL.Path),
+	}
+
+	if userID, ok := middleware.GetUserID(c); ok {
+		entry.ActorID = &userID
+	}
+
+	if metadata != nil {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			log.Printf("Error encoding audit metadata for %s: %v", action, err)
+		} else {
+			entry.Metadata = data
+		}
+	}
+
+	return entry
+}
+
+// auditString returns nil for an empty string, to store it as NULL
+func auditString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
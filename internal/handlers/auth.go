@@ -15,13 +15,15 @@ import (
 type AuthHandler struct {
 	userRepo        *repository.UserRepository
 	firebaseService *services.FirebaseService
+	audit           *AuditLogger
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(userRepo *repository.UserRepository, firebaseService *services.FirebaseService) *AuthHandler {
+func NewAuthHandler(userRepo *repository.UserRepository, firebaseService *services.FirebaseService, audit *AuditLogger) *AuthHandler {
 	return &AuthHandler{
 		userRepo:        userRepo,
 		firebaseService: firebaseService,
+		audit:           audit,
 	}
 }
 
@@ -98,7 +100,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Color:       utils.GenerateUserColor(userID),
 	}
 
-	if err := h.userRepo.Create(c.Request.Context(), user); err != nil {
+	audit := h.audit.Entry(c, models.AuditActionUserRegister, models.AuditTargetUser, user.UserID, nil, nil, nil)
+	if err := h.userRepo.Create(c.Request.Context(), user, audit); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "INTERNAL_ERROR",
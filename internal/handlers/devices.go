@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// DeviceHandler handles push device registration requests
+type DeviceHandler struct {
+	deviceRepo *repository.PushDeviceRepository
+}
+
+// NewDeviceHandler creates a new device handler
+func NewDeviceHandler(deviceRepo *repository.PushDeviceRepository) *DeviceHandler {
+	return &DeviceHandler{deviceRepo: deviceRepo}
+}
+
+// Register registers the current user's device for push notifications. Apps
+// call it on every launch, since tokens can change.
+// POST /api/v1/devices
+func (h *DeviceHandler) Register(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	device := &models.PushDevice{
+		UserID:     userID,
+		Platform:   req.Platform,
+		Provider:   req.Provider,
+		Token:      strings.TrimSpace(req.Token),
+		AppVersion: req.AppVersion,
+	}
+	if device.Provider == "" {
+		device.Provider = models.PushProviderFCM
+		if device.Platform == models.PlatformIOS {
+			device.Provider = models.PushProviderAPNs
+		}
+	}
+
+	if device.Provider == models.PushProviderAPNs {
+		if device.Platform != models.PlatformIOS {
+			writeFilterError(c, "provider", "APNs tokens are only valid on iOS")
+			return
+		}
+		if _, err := hex.DecodeString(device.Token); err != nil || device.Token == "" {
+			writeFilterError(c, "token", "APNs device tokens must be hex encoded")
+			return
+		}
+		device.Token = strings.ToLower(device.Token)
+	}
+	if device.Token == "" {
+		writeFilterError(c, "token", "Must not be empty")
+		return
+	}
+
+	created, err := h.deviceRepo.Register(c.Request.Context(), device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error registering device",
+			},
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, device)
+}
+
+// List retrieves the current user's registered devices
+// GET /api/v1/devices
+func (h *DeviceHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.deviceRepo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching devices",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DevicesListResponse{Devices: devices})
+}
+
+// Delete unregisters one of the current user's devices, e.g. on sign out
+// DELETE /api/v1/devices/:id
+func (h *DeviceHandler) Delete(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid device ID",
+			},
+		})
+		return
+	}
+
+	device, err := h.deviceRepo.GetByID(c.Request.Context(), deviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching device",
+			},
+		})
+		return
+	}
+
+	if device == nil || device.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Device not found",
+			},
+		})
+		return
+	}
+
+	if err := h.deviceRepo.Delete(c.Request.Context(), device.DeviceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error deleting device",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
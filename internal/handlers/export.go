@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// exportFlushRows is how many rows are buffered before they are sent
+const exportFlushRows = 500
+
+// exportTimeFormat is how times are written to CSV, which Excel reads as a
+// date and time
+const exportTimeFormat = "2006-01-02 15:04:05"
+
+// exportColumns are the export's column titles; exportRow fills them in.
+// Times are labelled with their time zone by exportHeader.
+var exportColumns = []string{
+	"Memo ID", "Created At", "Updated At", "Author", "Author ID", "Department",
+	"Park", "Latitude", "Longitude", "Accuracy (m)", "Address",
+	"Title", "Text", "Category", "Tags", "Language", "Duration (s)", "Status", "Audio",
+}
+
+// ExportHandler handles memo export requests
+type ExportHandler struct {
+	memoRepo        *repository.MemoRepository
+	firebaseService *services.FirebaseService
+	audioLinks      *services.AudioLinkSigner
+	audit           *AuditLogger
+	publicBaseURL   string
+	audioURLExpiry  time.Duration
+}
+
+// NewExportHandler creates a new export handler. publicBaseURL is where the
+// API is reached from outside, for audio links; without it exports have no
+// audio links, since request headers can't be trusted to say where that is.
+func NewExportHandler(
+	memoRepo *repository.MemoRepository,
+	firebaseService *services.FirebaseService,
+	audioLinks *services.AudioLinkSigner,
+	audit *AuditLogger,
+	publicBaseURL string,
+	audioURLExpiry time.Duration,
+) *ExportHandler {
+	return &ExportHandler{
+		memoRepo:        memoRepo,
+		firebaseService: firebaseService,
+		audioLinks:      audioLinks,
+		audit:           audit,
+		publicBaseURL:   publicBaseURL,
+		audioURLExpiry:  audioURLExpiry,
+	}
+}
+
+// MemosCSV exports the memos matching the filter as CSV, newest first
+// GET /api/v1/export/memos.csv
+func (h *ExportHandler) MemosCSV(c *gin.Context) {
+	filter, loc, ok := parseExportFilter(c)
+	if !ok {
+		return
+	}
+
+	w := csv.NewWriter(c.Writer)
+	rows := 0
+	start := func() error {
+		setExportHeaders(c, "text/csv; charset=utf-8", "csv")
+		// The byte order mark tells Excel the file is UTF-8
+		if _, err := c.Writer.WriteString("\ufeff"); err != nil {
+			return err
+		}
+		return w.Write(exportHeader(loc))
+	}
+
+	err := h.memoRepo.Export(c.Request.Context(), filter, 0, func(memo *models.MemoExportRow) error {
+		if rows == 0 {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+
+		record := []string{}
+		for _, value := range h.exportRow(memo, loc) {
+			record = append(record, csvCell(value))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		if rows%exportFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	if err == nil && rows == 0 {
+		err = start()
+	}
+	if err != nil {
+		h.writeExportError(c, rows, err)
+		h.recordExport(c, "csv", filter, rows, false)
+		return
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Error writing memo export: %v", err)
+	}
+	h.recordExport(c, "csv", filter, rows, true)
+}
+
+// MemosXLSX exports the memos matching the filter as an Excel workbook,
+// newest first. A sheet holds at most 1,048,575 memos; use CSV for more.
+// GET /api/v1/export/memos.xlsx
+func (h *ExportHandler) MemosXLSX(c *gin.Context) {
+	filter, loc, ok := parseExportFilter(c)
+	if !ok {
+		return
+	}
+
+	var x *utils.XLSXWriter
+	rows := 0
+	start := func() error {
+		setExportHeaders(c, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx")
+		var err error
+		if x, err = utils.NewXLSXWriter(c.Writer, "Memos"); err != nil {
+			return err
+		}
+		return x.WriteHeader(exportHeader(loc))
+	}
+
+	err := h.memoRepo.Export(c.Request.Context(), filter, utils.XLSXMaxRows-1, func(memo *models.MemoExportRow) error {
+		if rows == 0 {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+
+		if err := x.WriteRow(h.exportRow(memo, loc)); err != nil {
+			return err
+		}
+		if rows%exportFlushRows == 0 {
+			if err := x.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil && rows == 0 {
+		err = start()
+	}
+	if err != nil {
+		// The workbook is left unfinished, so Excel rejects it rather than
+		// showing a partial export
+		h.writeExportError(c, rows, err)
+		h.recordExport(c, "xlsx", filter, rows, false)
+		return
+	}
+
+	if err := x.Close(); err != nil {
+		log.Printf("Error writing memo export: %v", err)
+	}
+	h.recordExport(c, "xlsx", filter, rows, true)
+}
+
+// recordExport records an export in the audit log once any memos have been
+// sent, including exports cut short by an error
+func (h *ExportHandler) recordExport(c *gin.Context, format string, filter *models.MemoFilter, rows int, completed bool) {
+	if !completed && rows == 0 {
+		return
+	}
+
+	h.audit.Record(c, models.AuditActionMemoExport, models.AuditTargetMemo, "", nil, nil, gin.H{
+		"format":    format,
+		"filter":    filter,
+		"rows":      rows,
+		"completed": completed,
+	})
+}
+
+// Audio redirects an export's audio link to a short-lived URL for the
+// recording. The link's signature stands in for authentication, so it can be
+// opened from a spreadsheet; each use is recorded in the audit log.
+// GET /api/v1/export/audio/:id
+func (h *ExportHandler) Audio(c *gin.Context) {
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return
+	}
+
+	if !h.audioLinks.Verify(memoID, c.Query("expires"), c.Query("sig")) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "Audio link is invalid or has expired; export the memos again",
+			},
+		})
+		return
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return
+	}
+	if memo == nil || memo.AudioKey == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Recording not found",
+			},
+		})
+		return
+	}
+
+	url, _, err := h.firebaseService.SignedDownloadURL(*memo.AudioKey, h.audioURLExpiry)
+	if err != nil {
+		writeAudioURLError(c)
+		return
+	}
+
+	h.audit.Record(c, models.AuditActionAudioLink, models.AuditTargetMemo, memoID.String(), nil, nil, gin.H{
+		"expires": c.Query("expires"),
+	})
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// exportRow lays a memo out in exportColumns order. Times are in loc and
+// empty values are nil.
+func (h *ExportHandler) exportRow(memo *models.MemoExportRow, loc *time.Location) []interface{} {
+	var audio interface{}
+	if memo.HasAudio && h.publicBaseURL != "" {
+		audio = utils.XLSXLink{URL: h.audioLinks.Link(h.publicBaseURL, memo.MemoID), Text: "Play audio"}
+	}
+	var duration interface{}
+	if memo.HasAudio || memo.DurationSeconds > 0 {
+		duration = memo.DurationSeconds
+	}
+	var tags interface{}
+	if len(memo.Tags) > 0 {
+		tags = strings.Join(memo.Tags, "; ")
+	}
+
+	return []interface{}{
+		memo.MemoID.String(),
+		memo.CreatedAt.In(loc),
+		memo.UpdatedAt.In(loc),
+		memo.UserName,
+		memo.UserID,
+		optionalString(memo.Department),
+		optionalString(memo.ParkName),
+		optionalFloat(memo.Latitude),
+		optionalFloat(memo.Longitude),
+		optionalFloat(memo.LocationAccuracy),
+		optionalString(memo.Address),
+		optionalString(memo.Title),
+		memo.Text,
+		optionalString(memo.Category),
+		tags,
+		memo.Language,
+		duration,
+		memo.Status,
+		audio,
+	}
+}
+
+// exportHeader returns the column titles, with the time zone of the times
+func exportHeader(loc *time.Location) []string {
+	header := append([]string{}, exportColumns...)
+	header[1] += " (" + loc.String() + ")"
+	header[2] += " (" + loc.String() + ")"
+	return header
+}
+
+// writeExportError reports a failed export. Once rows have been sent the
+// response can't be changed, so the error is logged and the download cut off.
+func (h *ExportHandler) writeExportError(c *gin.Context, rows int, err error) {
+	if rows > 0 || c.Writer.Written() {
+		log.Printf("Error exporting memos after %d rows: %v", rows, err)
+		c.Abort()
+		return
+	}
+
+	c.Header("Content-Type", "")
+	c.Header("Content-Disposition", "")
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "Error exporting memos",
+		},
+	})
+}
+
+// parseExportFilter reads the memo filter and the time zone times are
+// written in, UTC by default
+func parseExportFilter(c *gin.Context) (*models.MemoFilter, *time.Location, bool) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return nil, nil, false
+	}
+	loc, ok := parseStatsTimezone(c)
+	if !ok {
+		return nil, nil, false
+	}
+	return filter, loc, true
+}
+
+// setExportHeaders starts a file download named after today's date
+func setExportHeaders(c *gin.Context, contentType, extension string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="memos-%s.%s"`, time.Now().UTC().Format("2006-01-02"), extension))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// csvCell formats an export value for CSV. Text that Excel would run as a
+// formula is prefixed with an apostrophe.
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(exportTimeFormat)
+	case utils.XLSXLink:
+		return v.URL
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func optionalString(s *string) interface{} {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return *s
+}
+
+func optionalFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
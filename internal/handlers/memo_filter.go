@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+const (
+	defaultRadiusMeters = 1000
+	maxRadiusMeters     = 50000
+	filterDateFormat    = "Must be an RFC 3339 timestamp or a YYYY-MM-DD date"
+)
+
+// parseMemoFilter reads the filter parameters shared by List, Search and
+// GetNearby. On invalid input it writes a validation error and returns false.
+func parseMemoFilter(c *gin.Context) (*models.MemoFilter, bool) {
+	filter := &models.MemoFilter{
+		Query:    strings.TrimSpace(c.Query("q")),
+		UserID:   c.Query("user_id"),
+		Category: c.Query("category"),
+		Status:   c.Query("status"),
+		Sort:     c.Query("sort"),
+	}
+
+	if filter.Status != "" && !models.IsValidMemoStatus(filter.Status) {
+		writeFilterError(c, "status", "Must be one of "+strings.Join(models.MemoStatuses, ", "))
+		return nil, false
+	}
+
+	for _, park := range c.QueryArray("park_name") {
+		if park = strings.TrimSpace(park); park != "" {
+			filter.ParkNames = append(filter.ParkNames, park)
+		}
+	}
+
+	for _, tag := range c.QueryArray("tag") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	if value := c.Query("start_date"); value != "" {
+		start, ok := parseFilterDate(value, false)
+		if !ok {
+			writeFilterError(c, "start_date", filterDateFormat)
+			return nil, false
+		}
+		filter.StartDate = &start
+	}
+
+	if value := c.Query("end_date"); value != "" {
+		end, ok := parseFilterDate(value, true)
+		if !ok {
+			writeFilterError(c, "end_date", filterDateFormat)
+			return nil, false
+		}
+		filter.EndDate = &end
+	}
+
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.StartDate.Before(*filter.EndDate) {
+		writeFilterError(c, "end_date", "Must be after start_date")
+		return nil, false
+	}
+
+	latStr, lonStr := c.Query("latitude"), c.Query("longitude")
+	if latStr != "" || lonStr != "" {
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil || lat < -90 || lat > 90 {
+			writeFilterError(c, "latitude", "Must be a number between -90 and 90")
+			return nil, false
+		}
+
+		lon, err := strconv.ParseFloat(lonStr, 64)
+		if err != nil || lon < -180 || lon > 180 {
+			writeFilterError(c, "longitude", "Must be a number between -180 and 180")
+			return nil, false
+		}
+
+		radius, err := strconv.Atoi(c.DefaultQuery("radius_meters", strconv.Itoa(defaultRadiusMeters)))
+		if err != nil || radius < 0 || radius > maxRadiusMeters {
+			radius = defaultRadiusMeters
+		}
+
+		filter.Near = &models.GeoRadius{Latitude: lat, Longitude: lon, RadiusMeters: radius}
+	}
+
+	if value := c.Query("bbox"); value != "" {
+		bbox, ok := parseBoundingBox(value)
+		if !ok {
+			writeFilterError(c, "bbox", "Must be min_lng,min_lat,max_lng,max_lat in range, with each minimum below its maximum")
+			return nil, false
+		}
+		filter.BBox = bbox
+	}
+
+	if value := c.Query("language"); value != "" {
+		language, ok := validateLanguage(c, value)
+		if !ok {
+			return nil, false
+		}
+		filter.Language = language
+	}
+
+	switch filter.Sort {
+	case "", models.MemoSortRecent, models.MemoSortRelevance, models.MemoSortDistance:
+	default:
+		writeFilterError(c, "sort", "Must be one of recent, relevance, distance")
+		return nil, false
+	}
+
+	return filter, true
+}
+
+// normalizeMemoFilter cleans up a filter read from a JSON body the same way
+// parseMemoFilter does for query parameters, and validates it. It returns the
+// offending field and the reason when the filter is invalid.
+func normalizeMemoFilter(filter *models.MemoFilter) (string, string) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.Sort = ""
+
+	parks := []string{}
+	for _, park := range filter.ParkNames {
+		if park = strings.TrimSpace(park); park != "" {
+			parks = append(parks, park)
+		}
+	}
+	filter.ParkNames = parks
+
+	tags := []string{}
+	for _, tag := range filter.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	filter.Tags = tags
+
+	filter.Language = strings.ToLower(strings.TrimSpace(filter.Language))
+	if filter.Language != "" && !models.IsSupportedLanguage(filter.Language) {
+		return "language", "Unsupported language"
+	}
+
+	filter.Status = strings.TrimSpace(filter.Status)
+	if filter.Status != "" && !models.IsValidMemoStatus(filter.Status) {
+		return "status", "Must be one of " + strings.Join(models.MemoStatuses, ", ")
+	}
+
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.StartDate.Before(*filter.EndDate) {
+		return "end_date", "Must be after start_date"
+	}
+
+	if near := filter.Near; near != nil {
+		if near.Latitude < -90 || near.Latitude > 90 || near.Longitude < -180 || near.Longitude > 180 {
+			return "near", "Coordinates are out of range"
+		}
+		if near.RadiusMeters < 1 || near.RadiusMeters > maxRadiusMeters {
+			return "near", "radius_meters must be between 1 and " + strconv.Itoa(maxRadiusMeters)
+		}
+	}
+
+	if bbox := filter.BBox; bbox != nil {
+		if bbox.MinLatitude < -90 || bbox.MaxLatitude > 90 || bbox.MinLongitude < -180 || bbox.MaxLongitude > 180 ||
+			bbox.MinLatitude > bbox.MaxLatitude || bbox.MinLongitude > bbox.MaxLongitude {
+			return "bbox", "Coordinates must be in range, with each minimum below its maximum"
+		}
+	}
+
+	return "", ""
+}
+
+// parseFilterDate accepts an RFC 3339 timestamp or a YYYY-MM-DD date. A date
+// used as an end bound covers the whole day.
+func parseFilterDate(value string, end bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+// parseBoundingBox parses "min_lng,min_lat,max_lng,max_lat", the GeoJSON order
+func parseBoundingBox(value string) (*models.BoundingBox, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, false
+	}
+
+	coords := make([]float64, 4)
+	for i, part := range parts {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, false
+		}
+		coords[i] = parsed
+	}
+
+	bbox := &models.BoundingBox{
+		MinLongitude: coords[0],
+		MinLatitude:  coords[1],
+		MaxLongitude: coords[2],
+		MaxLatitude:  coords[3],
+	}
+	if bbox.MinLatitude < -90 || bbox.MaxLatitude > 90 || bbox.MinLongitude < -180 || bbox.MaxLongitude > 180 {
+		return nil, false
+	}
+	if bbox.MinLatitude > bbox.MaxLatitude || bbox.MinLongitude > bbox.MaxLongitude {
+		return nil, false
+	}
+	return bbox, true
+}
+
+// writeFilterError responds with a validation error for one filter parameter
+func writeFilterError(c *gin.Context, param, reason string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "Invalid " + param + " value",
+			"details": gin.H{
+				param: reason,
+			},
+		},
+	})
+}
+
+// loadFacets counts facets when the request asks for them with facets=true.
+// It returns nil facets when they were not requested, and writes an error
+// response and returns false on failure.
+func (h *MemoHandler) loadFacets(c *gin.Context, filter *models.MemoFilter) (*models.MemoFacets, bool) {
+	if want, _ := strconv.ParseBool(c.Query("facets")); !want {
+		return nil, true
+	}
+
+	facets, err := h.memoRepo.Facets(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error counting facets",
+			},
+		})
+		return nil, false
+	}
+	return facets, true
+}
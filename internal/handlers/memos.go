@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,28 +18,44 @@ import (
 	"github.com/tom-fitz/trailmemo-api/internal/models"
 	"github.com/tom-fitz/trailmemo-api/internal/repository"
 	"github.com/tom-fitz/trailmemo-api/internal/services"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
 )
 
 // MemoHandler handles memo-related requests
 type MemoHandler struct {
 	memoRepo        *repository.MemoRepository
 	userRepo        *repository.UserRepository
+	uploadRepo      *repository.UploadRepository
+	jobRepo         *repository.JobRepository
 	firebaseService *services.FirebaseService
+	audioValidator  *services.AudioValidator
+	audit           *AuditLogger
 	maxUploadSize   int64
+	audioURLExpiry  time.Duration
 }
 
 // NewMemoHandler creates a new memo handler
 func NewMemoHandler(
 	memoRepo *repository.MemoRepository,
 	userRepo *repository.UserRepository,
+	uploadRepo *repository.UploadRepository,
+	jobRepo *repository.JobRepository,
 	firebaseService *services.FirebaseService,
+	audioValidator *services.AudioValidator,
+	audit *AuditLogger,
 	maxUploadSize int64,
+	audioURLExpiry time.Duration,
 ) *MemoHandler {
 	return &MemoHandler{
 		memoRepo:        memoRepo,
 		userRepo:        userRepo,
+		uploadRepo:      uploadRepo,
+		jobRepo:         jobRepo,
 		firebaseService: firebaseService,
+		audioValidator:  audioValidator,
+		audit:           audit,
 		maxUploadSize:   maxUploadSize,
+		audioURLExpiry:  audioURLExpiry,
 	}
 }
 
@@ -62,12 +86,30 @@ func (h *MemoHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Parse multipart form
-	if err := c.Request.ParseMultipartForm(h.maxUploadSize); err != nil {
+	// Parse multipart form (audio uploaded directly to storage may be
+	// referenced from a plain urlencoded form instead)
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.Request.ParseMultipartForm(h.maxUploadSize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Error parsing form data",
+					"details": gin.H{
+						"reason": err.Error(),
+					},
+				},
+			})
+			return
+		}
+	}
+
+	// Parse form data
+	var req models.CreateMemoRequest
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": gin.H{
 				"code":    "VALIDATION_ERROR",
-				"message": "Error parsing form data",
+				"message": "Invalid form data",
 				"details": gin.H{
 					"reason": err.Error(),
 				},
@@ -76,11 +118,64 @@ func (h *MemoHandler) Create(c *gin.Context) {
 		return
 	}
 
+	req.Text = strings.TrimSpace(req.Text)
+
+	language := models.DefaultLanguage
+	if req.Language != nil {
+		var ok bool
+		if language, ok = validateLanguage(c, *req.Language); !ok {
+			return
+		}
+	} else if detected, ok := utils.DetectLanguage(req.Text); ok {
+		language = detected
+	}
+
 	// Get audio file (optional for MVP)
 	audioFile, err := c.FormFile("audio")
-	var audioURL string
+	hasFile := err == nil
+	hasFilePath := req.FilePath != nil && *req.FilePath != ""
+	hasUploadID := req.UploadID != nil && *req.UploadID != ""
+	var audioKey *string
+	var probedDuration float64
+	uploadedHere := false
+
+	sources := 0
+	for _, provided := range []bool{hasFile, hasFilePath, hasUploadID} {
+		if provided {
+			sources++
+		}
+	}
+	if sources > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Provide only one of audio, file_path or upload_id",
+			},
+		})
+		return
+	}
 
-	if err == nil {
+	// Without a recording there is nothing to transcribe, so text is required
+	if sources == 0 && req.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Text is required when no audio is attached",
+			},
+		})
+		return
+	}
+	if sources == 0 && req.Transcribe {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Transcription requires audio",
+			},
+		})
+		return
+	}
+
+	if hasFile {
 		// Audio file provided - validate size
 		if audioFile.Size > h.maxUploadSize {
 			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
@@ -95,8 +190,15 @@ func (h *MemoHandler) Create(c *gin.Context) {
 			return
 		}
 
+		// Verify the content really is audio before storing it
+		audioInfo, ok := h.validateAudioFile(c, audioFile, req.DurationSeconds)
+		if !ok {
+			return
+		}
+		probedDuration = audioInfo.DurationSeconds
+
 		// Upload audio file to Firebase Storage
-		audioURL, err = h.firebaseService.UploadAudioFile(c.Request.Context(), audioFile, userID)
+		key, err := h.firebaseService.UploadAudioFile(c.Request.Context(), audioFile, userID, audioInfo.Format)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": gin.H{
@@ -109,24 +211,37 @@ func (h *MemoHandler) Create(c *gin.Context) {
 			})
 			return
 		}
-	} else {
-		// No audio file provided - use placeholder for MVP
-		audioURL = "https://placeholder.com/audio.m4a"
+		audioKey = &key
+		uploadedHere = true
+	} else if hasFilePath || hasUploadID {
+		filePath := ""
+		if hasFilePath {
+			// Audio already uploaded via a presigned URL
+			filePath = *req.FilePath
+		} else {
+			// Audio sent through the resumable upload endpoints
+			var ok bool
+			filePath, ok = h.resolveResumableUpload(c, userID, *req.UploadID)
+			if !ok {
+				return
+			}
+		}
+
+		if !h.resolveUploadedAudio(c, userID, filePath) {
+			return
+		}
+		audioKey = &filePath
+
+		audioInfo, ok := h.validateStoredAudio(c, filePath, req.DurationSeconds)
+		if !ok {
+			return
+		}
+		probedDuration = audioInfo.DurationSeconds
 	}
 
-	// Parse form data
-	var req models.CreateMemoRequest
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid form data",
-				"details": gin.H{
-					"reason": err.Error(),
-				},
-			},
-		})
-		return
+	// Prefer the probed duration over the client-reported one
+	if probedDuration > 0 {
+		req.DurationSeconds = int(math.Round(probedDuration))
 	}
 
 	// Create memo in database
@@ -135,18 +250,37 @@ func (h *MemoHandler) Create(c *gin.Context) {
 		UserName:         user.DisplayName,
 		UserColor:        user.Color,
 		Title:            req.Title,
-		AudioURL:         audioURL,
+		AudioKey:         audioKey,
 		Text:             req.Text,
 		DurationSeconds:  req.DurationSeconds,
 		Latitude:         &req.Latitude,
 		Longitude:        &req.Longitude,
 		LocationAccuracy: req.LocationAccuracy,
 		ParkName:         req.ParkName,
+		Language:         language,
+	}
+
+	// Real recordings are transcoded in the background
+	hasAudio := hasFile || hasFilePath || hasUploadID
+	if hasAudio {
+		status := models.TranscodeStatusPending
+		memo.TranscodeStatus = &status
+	}
+
+	// Memos without text, or whose client asked for it, are transcribed on the server
+	transcribe := hasAudio && (req.Text == "" || req.Transcribe)
+	if transcribe {
+		status := models.TranscriptionStatusPending
+		memo.TranscriptionStatus = &status
 	}
 
-	if err := h.memoRepo.Create(c.Request.Context(), memo); err != nil {
-		// Try to delete uploaded file on failure
-		_ = h.firebaseService.DeleteAudioFile(c.Request.Context(), audioURL)
+	audit := h.audit.Entry(c, models.AuditActionMemoCreate, models.AuditTargetMemo, "", nil, nil, nil)
+	if err := h.memoRepo.Create(c.Request.Context(), memo, audit); err != nil {
+		// Try to delete uploaded file on failure (presigned uploads are kept
+		// so the client can retry with the same file_path)
+		if uploadedHere {
+			_ = h.firebaseService.DeleteObject(c.Request.Context(), *audioKey)
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -157,17 +291,272 @@ func (h *MemoHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Build location object (always present now since required)
-	memo.Location = &models.Location{
-		Latitude:  *memo.Latitude,
-		Longitude: *memo.Longitude,
-		Accuracy:  memo.LocationAccuracy,
-		Address:   memo.Address,
+	if hasAudio {
+		payload := models.MemoJobPayload{MemoID: memo.MemoID.String()}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeTranscodeAudio, payload); err != nil {
+			log.Printf("Error enqueuing transcode for memo %s: %v", memo.MemoID, err)
+		}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeAudioWaveform, payload); err != nil {
+			log.Printf("Error enqueuing waveform for memo %s: %v", memo.MemoID, err)
+		}
+	}
+	if req.Text != "" {
+		payload := models.MemoJobPayload{MemoID: memo.MemoID.String()}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeSuggestTags, payload); err != nil {
+			log.Printf("Error enqueuing tag suggestions for memo %s: %v", memo.MemoID, err)
+		}
+	}
+	if req.Text != "" || req.Title != nil {
+		payload := models.MemoJobPayload{MemoID: memo.MemoID.String()}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeMentions, payload); err != nil {
+			log.Printf("Error enqueuing mentions for memo %s: %v", memo.MemoID, err)
+		}
+	}
+	matchPayload := models.MemoJobPayload{MemoID: memo.MemoID.String()}
+	if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeMatchSearches, matchPayload); err != nil {
+		log.Printf("Error enqueuing saved search matching for memo %s: %v", memo.MemoID, err)
+	}
+	if transcribe {
+		payload := models.TranscribeJobPayload{
+			MemoID:      memo.MemoID.String(),
+			ReplaceText: req.Text != "",
+		}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeTranscribe, payload); err != nil {
+			log.Printf("Error enqueuing transcription for memo %s: %v", memo.MemoID, err)
+		}
+	}
+
+	if !h.signMemoAudio(c, memo) {
+		return
 	}
 
 	c.JSON(http.StatusCreated, memo)
 }
 
+// resolveUploadedAudio verifies that an object uploaded via a presigned URL
+// exists, belongs to the user and is not attached to another memo. It writes
+// the error response and returns false otherwise.
+func (h *MemoHandler) resolveUploadedAudio(c *gin.Context, userID, filePath string) bool {
+	if !services.IsUserObjectKey(filePath, userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "You can only attach your own uploads",
+			},
+		})
+		return false
+	}
+
+	object, err := h.firebaseService.GetUploadedObject(c.Request.Context(), filePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error verifying uploaded file",
+			},
+		})
+		return false
+	}
+
+	if object == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Uploaded file not found",
+				"details": gin.H{
+					"file_path": filePath,
+				},
+			},
+		})
+		return false
+	}
+
+	if object.UploadedBy != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "You can only attach your own uploads",
+			},
+		})
+		return false
+	}
+
+	if object.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "File size exceeds maximum allowed size",
+				"details": gin.H{
+					"max_size_mb": h.maxUploadSize / (1024 * 1024),
+				},
+			},
+		})
+		return false
+	}
+
+	attached, err := h.memoRepo.ExistsByAudioKey(c.Request.Context(), filePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error verifying uploaded file",
+			},
+		})
+		return false
+	}
+
+	if attached {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Uploaded file is already attached to a memo",
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+// resolveResumableUpload looks up a completed resumable upload owned by the
+// user and returns its storage key. It writes the error response and returns
+// false otherwise.
+func (h *MemoHandler) resolveResumableUpload(c *gin.Context, userID, uploadIDStr string) (string, bool) {
+	uploadID, err := uuid.Parse(uploadIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid upload ID",
+			},
+		})
+		return "", false
+	}
+
+	upload, err := h.uploadRepo.GetByID(c.Request.Context(), uploadID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching upload",
+			},
+		})
+		return "", false
+	}
+
+	if upload == nil || upload.UserID != userID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Upload not found",
+				"details": gin.H{
+					"upload_id": uploadIDStr,
+				},
+			},
+		})
+		return "", false
+	}
+
+	if !upload.IsComplete() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Upload is not complete",
+				"details": gin.H{
+					"upload_offset": upload.UploadOffset,
+					"upload_length": upload.UploadLength,
+				},
+			},
+		})
+		return "", false
+	}
+
+	return upload.ObjectKey, true
+}
+
+// validateAudioFile checks the content of an uploaded multipart file against
+// the audio allow-list. It writes the error response and returns false if the
+// file is rejected.
+func (h *MemoHandler) validateAudioFile(c *gin.Context, file *multipart.FileHeader, reportedDuration int) (*services.AudioInfo, bool) {
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Error reading audio file",
+			},
+		})
+		return nil, false
+	}
+	defer src.Close()
+
+	return h.validateAudio(c, reportedDuration, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// validateStoredAudio checks the content of an object uploaded directly to
+// storage against the audio allow-list. It writes the error response and
+// returns false if the file is rejected.
+func (h *MemoHandler) validateStoredAudio(c *gin.Context, filePath string, reportedDuration int) (*services.AudioInfo, bool) {
+	return h.validateAudio(c, reportedDuration, func(w io.Writer) error {
+		return h.firebaseService.DownloadObject(c.Request.Context(), filePath, w)
+	})
+}
+
+// validateAudio copies audio into a temporary file with fill and validates it
+func (h *MemoHandler) validateAudio(c *gin.Context, reportedDuration int, fill func(io.Writer) error) (*services.AudioInfo, bool) {
+	tmp, err := os.CreateTemp("", "trailmemo-audio-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error validating audio file",
+			},
+		})
+		return nil, false
+	}
+	defer os.Remove(tmp.Name())
+
+	err = fill(tmp)
+	tmp.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error reading audio file",
+			},
+		})
+		return nil, false
+	}
+
+	info, err := h.audioValidator.Validate(c.Request.Context(), tmp.Name(), reportedDuration)
+	if err != nil {
+		var validationErr *services.AudioValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": validationErr.Message,
+					"details": validationErr.Details,
+				},
+			})
+			return nil, false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error validating audio file",
+			},
+		})
+		return nil, false
+	}
+
+	return info, true
+}
+
 // List retrieves all memos with optional filters
 // GET /api/v1/memos
 func (h *MemoHandler) List(c *gin.Context) {
@@ -183,23 +572,19 @@ func (h *MemoHandler) List(c *gin.Context) {
 		limit = 100
 	}
 
-	// Build filters
-	filters := make(map[string]interface{})
-	if parkName := c.Query("park_name"); parkName != "" {
-		filters["park_name"] = parkName
-	}
-	if userID := c.Query("user_id"); userID != "" {
-		filters["user_id"] = userID
-	}
-	if startDate := c.Query("start_date"); startDate != "" {
-		filters["start_date"] = startDate
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
 	}
-	if endDate := c.Query("end_date"); endDate != "" {
-		filters["end_date"] = endDate
+	if filter.Sort == "" {
+		filter.Sort = models.MemoSortRecent
+		if filter.Query != "" {
+			filter.Sort = models.MemoSortRelevance
+		}
 	}
 
 	// Fetch memos
-	memos, total, err := h.memoRepo.List(c.Request.Context(), page, limit, filters)
+	memos, total, err := h.memoRepo.Query(c.Request.Context(), filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -210,6 +595,11 @@ func (h *MemoHandler) List(c *gin.Context) {
 		return
 	}
 
+	facets, ok := h.loadFacets(c, filter)
+	if !ok {
+		return
+	}
+
 	// Build pagination response
 	totalPages := (total + limit - 1) / limit
 	pagination := models.PaginationResponse{
@@ -221,9 +611,20 @@ func (h *MemoHandler) List(c *gin.Context) {
 		HasPrevious:  page > 1,
 	}
 
+	if filter.Query != "" {
+		for i := range memos {
+			memos[i].Match = utils.FindTranscriptMatch(memos[i].TranscriptWords, filter.Query)
+		}
+	}
+
+	if !h.signListAudio(c, memos) {
+		return
+	}
+
 	c.JSON(http.StatusOK, models.MemosListResponse{
 		Memos:      memos,
 		Pagination: pagination,
+		Facets:     facets,
 	})
 }
 
@@ -265,6 +666,10 @@ func (h *MemoHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	if !h.signMemoAudio(c, memo) {
+		return
+	}
+
 	c.JSON(http.StatusOK, memo)
 }
 
@@ -318,17 +723,6 @@ func (h *MemoHandler) Update(c *gin.Context) {
 		return
 	}
 
-	// Check if user owns the memo
-	if memo.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": gin.H{
-				"code":    "AUTHORIZATION_ERROR",
-				"message": "You can only update your own memos",
-			},
-		})
-		return
-	}
-
 	// Parse request body
 	var req models.UpdateMemoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -341,6 +735,34 @@ func (h *MemoHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// Check if user owns the memo. Supervisors and admins may also move
+	// anyone's memo through the workflow, but not edit its report.
+	if memo.UserID != userID {
+		statusOnly := req.Status != nil && req.Title == nil && req.Text == nil && req.ParkName == nil &&
+			req.Language == nil && req.Latitude == nil && req.Longitude == nil
+
+		user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error fetching user",
+				},
+			})
+			return
+		}
+
+		if !statusOnly || user == nil || !user.IsSupervisor() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":    "AUTHORIZATION_ERROR",
+					"message": "You can only update your own memos",
+				},
+			})
+			return
+		}
+	}
+
 	// Build updates map
 	updates := make(map[string]interface{})
 	if req.Title != nil {
@@ -352,6 +774,29 @@ func (h *MemoHandler) Update(c *gin.Context) {
 	if req.ParkName != nil {
 		updates["park_name"] = req.ParkName
 	}
+	if req.Language != nil {
+		language, ok := validateLanguage(c, *req.Language)
+		if !ok {
+			return
+		}
+		updates["language"] = language
+	}
+	if req.Status != nil {
+		if !models.IsValidMemoStatus(*req.Status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid status",
+					"details": gin.H{
+						"status":  *req.Status,
+						"allowed": models.MemoStatuses,
+					},
+				},
+			})
+			return
+		}
+		updates["status"] = *req.Status
+	}
 	if req.Latitude != nil {
 		updates["latitude"] = req.Latitude
 	}
@@ -370,7 +815,8 @@ func (h *MemoHandler) Update(c *gin.Context) {
 	}
 
 	// Update memo
-	updatedMemo, err := h.memoRepo.Update(c.Request.Context(), memoID, updates)
+	audit := h.audit.Entry(c, models.AuditActionMemoUpdate, models.AuditTargetMemo, memoID.String(), memo, nil, nil)
+	updatedMemo, err := h.memoRepo.Update(c.Request.Context(), memoID, updates, userID, audit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -381,6 +827,32 @@ func (h *MemoHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// Edited text may suggest different tags
+	if req.Text != nil {
+		payload := models.MemoJobPayload{MemoID: memoID.String()}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeSuggestTags, payload); err != nil {
+			log.Printf("Error enqueuing tag suggestions for memo %s: %v", memoID, err)
+		}
+	}
+
+	// Edits can add or remove @mentions
+	if req.Text != nil || req.Title != nil {
+		payload := models.MemoJobPayload{MemoID: memoID.String()}
+		if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeMentions, payload); err != nil {
+			log.Printf("Error enqueuing mentions for memo %s: %v", memoID, err)
+		}
+	}
+
+	// The edit may make the memo match saved searches it didn't before
+	payload := models.MemoJobPayload{MemoID: memoID.String()}
+	if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeMatchSearches, payload); err != nil {
+		log.Printf("Error enqueuing saved search matching for memo %s: %v", memoID, err)
+	}
+
+	if !h.signMemoAudio(c, updatedMemo) {
+		return
+	}
+
 	c.JSON(http.StatusOK, updatedMemo)
 }
 
@@ -445,14 +917,10 @@ func (h *MemoHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	// Delete audio file from storage
-	if err := h.firebaseService.DeleteAudioFile(c.Request.Context(), memo.AudioURL); err != nil {
-		// Log error but continue with database deletion
-		// In production, you might want to queue this for retry
-	}
-
-	// Delete memo from database
-	if err := h.memoRepo.Delete(c.Request.Context(), memoID); err != nil {
+	// Delete memo from database, with its audit entry, before its audio so
+	// a failure leaves the memo intact
+	audit := h.audit.Entry(c, models.AuditActionMemoDelete, models.AuditTargetMemo, memoID.String(), memo, nil, nil)
+	if err := h.memoRepo.Delete(c.Request.Context(), memoID, audit); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "INTERNAL_ERROR",
@@ -462,62 +930,104 @@ func (h *MemoHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Delete audio files from storage
+	if memo.AudioKey != nil {
+		if err := h.firebaseService.DeleteObject(c.Request.Context(), *memo.AudioKey); err != nil {
+			// Log error; the memo is already gone
+			// In production, you might want to queue this for retry
+		}
+	}
+	for _, variant := range memo.AudioVariants {
+		_ = h.firebaseService.DeleteObject(c.Request.Context(), variant.AudioKey)
+	}
+
 	c.Status(http.StatusNoContent)
 }
 
-// GetNearby finds memos near a location
-// GET /api/v1/memos/nearby
-func (h *MemoHandler) GetNearby(c *gin.Context) {
-	// Parse query parameters
-	latStr := c.Query("latitude")
-	lonStr := c.Query("longitude")
-	radiusStr := c.DefaultQuery("radius_meters", "1000")
-	limitStr := c.DefaultQuery("limit", "50")
-
-	if latStr == "" || lonStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "latitude and longitude are required",
-			},
-		})
-		return
+// signMemoAudio fills in short-lived signed URLs for a memo's recording and
+// its variants. It writes the error response and returns false on failure.
+func (h *MemoHandler) signMemoAudio(c *gin.Context, memo *models.Memo) bool {
+	if memo.AudioKey == nil {
+		return true
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
+	url, expiresAt, err := h.firebaseService.SignedDownloadURL(*memo.AudioKey, h.audioURLExpiry)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "VALIDATION_ERROR",
-				"message": "Invalid latitude value",
-			},
-		})
-		return
+		writeAudioURLError(c)
+		return false
 	}
+	memo.AudioURL = &url
+	memo.AudioURLExpiresAt = &expiresAt
 
-	lon, err := strconv.ParseFloat(lonStr, 64)
-	if err != nil {
+	for i := range memo.AudioVariants {
+		variant := &memo.AudioVariants[i]
+		variant.AudioURL, _, err = h.firebaseService.SignedDownloadURL(variant.AudioKey, h.audioURLExpiry)
+		if err != nil {
+			writeAudioURLError(c)
+			return false
+		}
+	}
+
+	return true
+}
+
+// signListAudio fills in short-lived signed URLs for memos in a list view.
+// It writes the error response and returns false on failure.
+func (h *MemoHandler) signListAudio(c *gin.Context, memos []models.MemoListItem) bool {
+	for i := range memos {
+		memo := &memos[i]
+		if memo.AudioKey == nil {
+			continue
+		}
+
+		url, expiresAt, err := h.firebaseService.SignedDownloadURL(*memo.AudioKey, h.audioURLExpiry)
+		if err != nil {
+			writeAudioURLError(c)
+			return false
+		}
+		memo.AudioURL = &url
+		memo.AudioURLExpiresAt = &expiresAt
+	}
+
+	return true
+}
+
+func writeAudioURLError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": gin.H{
+			"code":    "INTERNAL_ERROR",
+			"message": "Error generating audio URL",
+		},
+	})
+}
+
+// GetNearby finds memos near a location
+// GET /api/v1/memos/nearby
+func (h *MemoHandler) GetNearby(c *gin.Context) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Near == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": gin.H{
 				"code":    "VALIDATION_ERROR",
-				"message": "Invalid longitude value",
+				"message": "latitude and longitude are required",
 			},
 		})
 		return
 	}
-
-	radius, err := strconv.Atoi(radiusStr)
-	if err != nil || radius < 0 || radius > 50000 {
-		radius = 1000
+	if filter.Sort == "" {
+		filter.Sort = models.MemoSortDistance
 	}
 
-	limit, err := strconv.Atoi(limitStr)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	if err != nil || limit < 1 || limit > 200 {
 		limit = 50
 	}
 
 	// Fetch nearby memos
-	memos, err := h.memoRepo.GetNearby(c.Request.Context(), lat, lon, radius, limit)
+	memos, total, err := h.memoRepo.Query(c.Request.Context(), filter, 1, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -528,14 +1038,37 @@ func (h *MemoHandler) GetNearby(c *gin.Context) {
 		return
 	}
 
+	facets, ok := h.loadFacets(c, filter)
+	if !ok {
+		return
+	}
+
+	nearby := make([]models.NearbyMemo, 0, len(memos))
+	for _, m := range memos {
+		nm := models.NearbyMemo{
+			MemoID:    m.MemoID,
+			UserName:  m.UserName,
+			UserColor: m.UserColor,
+			Title:     m.Title,
+			ParkName:  m.ParkName,
+			Location:  m.Location,
+			CreatedAt: m.CreatedAt,
+		}
+		if m.DistanceMeters != nil {
+			nm.DistanceMeters = *m.DistanceMeters
+		}
+		nearby = append(nearby, nm)
+	}
+
 	response := models.NearbyMemosResponse{
-		Memos: memos,
+		Memos: nearby,
 		Center: models.Location{
-			Latitude:  lat,
-			Longitude: lon,
+			Latitude:  filter.Near.Latitude,
+			Longitude: filter.Near.Longitude,
 		},
-		RadiusMeters: radius,
-		TotalFound:   len(memos),
+		RadiusMeters: filter.Near.RadiusMeters,
+		TotalFound:   total,
+		Facets:       facets,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -544,9 +1077,11 @@ func (h *MemoHandler) GetNearby(c *gin.Context) {
 // Search performs full-text search on memos
 // GET /api/v1/memos/search
 func (h *MemoHandler) Search(c *gin.Context) {
-	// Parse query parameters
-	query := c.Query("q")
-	if query == "" {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": gin.H{
 				"code":    "VALIDATION_ERROR",
@@ -567,8 +1102,12 @@ func (h *MemoHandler) Search(c *gin.Context) {
 		limit = 20
 	}
 
+	if filter.Sort == "" {
+		filter.Sort = models.MemoSortRelevance
+	}
+
 	// Perform search
-	memos, total, err := h.memoRepo.SearchByText(c.Request.Context(), query, page, limit)
+	memos, total, err := h.memoRepo.Query(c.Request.Context(), filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
@@ -579,6 +1118,11 @@ func (h *MemoHandler) Search(c *gin.Context) {
 		return
 	}
 
+	facets, ok := h.loadFacets(c, filter)
+	if !ok {
+		return
+	}
+
 	// Build pagination response
 	totalPages := (total + limit - 1) / limit
 	pagination := models.PaginationResponse{
@@ -590,9 +1134,90 @@ func (h *MemoHandler) Search(c *gin.Context) {
 		HasPrevious:  page > 1,
 	}
 
+	// Point each hit at the moment its phrase is spoken
+	for i := range memos {
+		memos[i].Match = utils.FindTranscriptMatch(memos[i].TranscriptWords, filter.Query)
+	}
+
+	if !h.signListAudio(c, memos) {
+		return
+	}
+
 	c.JSON(http.StatusOK, models.SearchResponse{
 		Results:    memos,
-		Query:      query,
+		Query:      filter.Query,
 		Pagination: pagination,
+		Facets:     facets,
+	})
+}
+
+// Autocomplete suggests park names and titles for partial input
+// GET /api/v1/memos/autocomplete
+func (h *MemoHandler) Autocomplete(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Autocomplete query (q) is required",
+			},
+		})
+		return
+	}
+
+	var types []string
+	switch t := c.Query("type"); t {
+	case "":
+	case models.AutocompleteTypePark, models.AutocompleteTypeTitle:
+		types = []string{t}
+	default:
+		writeFilterError(c, "type", "Must be park or title")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := h.memoRepo.Autocomplete(c.Request.Context(), query, types, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching suggestions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AutocompleteResponse{
+		Query:       query,
+		Suggestions: suggestions,
+	})
+}
+
+// validateLanguage normalizes a client-supplied language code. It writes a
+// validation error and returns false if the language is not supported.
+func validateLanguage(c *gin.Context, language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if models.IsSupportedLanguage(language) {
+		return language, true
+	}
+
+	supported := make([]string, 0, len(models.Languages))
+	for _, lang := range models.Languages {
+		supported = append(supported, lang.Code)
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "Unsupported language",
+			"details": gin.H{
+				"language":  language,
+				"supported": supported,
+			},
+		},
 	})
+	return "", false
 }
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// MentionHandler handles @mention requests
+type MentionHandler struct {
+	mentionRepo *repository.MentionRepository
+}
+
+// NewMentionHandler creates a new mention handler
+func NewMentionHandler(mentionRepo *repository.MentionRepository) *MentionHandler {
+	return &MentionHandler{mentionRepo: mentionRepo}
+}
+
+// List retrieves the memos that mention the current user, newest first
+// GET /api/v1/mentions
+func (h *MentionHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	mentions, total, err := h.mentionRepo.ListByUser(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching mentions",
+			},
+		})
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, models.MentionsListResponse{
+		Mentions: mentions,
+		Pagination: models.PaginationResponse{
+			CurrentPage:  page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: limit,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
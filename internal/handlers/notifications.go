@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// maxDigestParks caps the parks a digest can follow
+const maxDigestParks = 50
+
+// NotificationHandler handles in-app notification and notification
+// preference requests
+type NotificationHandler struct {
+	notificationRepo *repository.NotificationRepository
+	prefRepo         *repository.NotificationPreferenceRepository
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(
+	notificationRepo *repository.NotificationRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
+	}
+}
+
+// List retrieves the current user's notifications, newest first
+// GET /api/v1/notifications
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	notifications, total, err := h.notificationRepo.ListByUser(c.Request.Context(), userID, unreadOnly, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching notifications",
+			},
+		})
+		return
+	}
+
+	unread, err := h.notificationRepo.CountUnread(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching notifications",
+			},
+		})
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, models.NotificationsListResponse{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		Pagination: models.PaginationResponse{
+			CurrentPage:  page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: limit,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
+
+// MarkRead marks one notification as read
+// POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid notification ID",
+			},
+		})
+		return
+	}
+
+	found, err := h.notificationRepo.MarkRead(c.Request.Context(), notificationID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating notification",
+			},
+		})
+		return
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Notification not found",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllRead marks all of the current user's notifications as read
+// POST /api/v1/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.notificationRepo.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating notifications",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"marked_read": marked,
+	})
+}
+
+// GetPreferences retrieves the current user's notification preferences
+// GET /api/v1/notifications/preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.prefRepo.Get(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching notification preferences",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences changes the current user's email preferences and
+// reschedules their digest
+// PUT /api/v1/notifications/preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	prefs, err := h.prefRepo.Get(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching notification preferences",
+			},
+		})
+		return
+	}
+
+	if req.EmailImmediate != nil {
+		prefs.EmailImmediate = *req.EmailImmediate
+	}
+	if req.PushEnabled != nil {
+		prefs.PushEnabled = *req.PushEnabled
+	}
+
+	if req.DigestFrequency != nil {
+		switch *req.DigestFrequency {
+		case models.DigestOff, models.DigestDaily, models.DigestWeekly:
+			prefs.DigestFrequency = *req.DigestFrequency
+		default:
+			writeFilterError(c, "digest_frequency", "Must be one of off, daily, weekly")
+			return
+		}
+	}
+
+	if req.DigestParks != nil {
+		parks := []string{}
+		for _, park := range req.DigestParks {
+			if park = strings.TrimSpace(park); park != "" && !utils.ContainsString(parks, park) {
+				parks = append(parks, park)
+			}
+		}
+		if len(parks) > maxDigestParks {
+			writeFilterError(c, "digest_parks", "Must list at most 50 parks")
+			return
+		}
+		prefs.DigestParks = parks
+	}
+
+	if req.DigestHour != nil {
+		if *req.DigestHour < 0 || *req.DigestHour > 23 {
+			writeFilterError(c, "digest_hour", "Must be an hour between 0 and 23")
+			return
+		}
+		prefs.DigestHour = *req.DigestHour
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			writeFilterError(c, "timezone", "Must be an IANA time zone such as America/Denver")
+			return
+		}
+		prefs.Timezone = *req.Timezone
+	}
+
+	prefs.NextDigestAt = prefs.NextDigestAfter(time.Now().UTC())
+
+	if err := h.prefRepo.Save(c.Request.Context(), prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error saving notification preferences",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
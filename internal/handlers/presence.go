@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/realtime"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+const (
+	presenceWriteWait    = 10 * time.Second
+	presencePongWait     = 60 * time.Second
+	presencePingInterval = 25 * time.Second
+
+	// presenceMaxMessageSize bounds client messages, which are small
+	presenceMaxMessageSize = 1024
+
+	// presenceMinInterval is the shortest time between accepted positions
+	// from one connection; more frequent ones are ignored
+	presenceMinInterval = 2 * time.Second
+)
+
+// presenceUpgrader accepts connections from any origin, like the CORS
+// policy; clients authenticate with a bearer token rather than cookies
+var presenceUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// PresenceHandler handles live crew presence connections
+type PresenceHandler struct {
+	userRepo     *repository.UserRepository
+	positionRepo *repository.CrewPositionRepository
+	hub          *realtime.PresenceHub
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(
+	userRepo *repository.UserRepository,
+	positionRepo *repository.CrewPositionRepository,
+	hub *realtime.PresenceHub,
+) *PresenceHandler {
+	return &PresenceHandler{
+		userRepo:     userRepo,
+		positionRepo: positionRepo,
+		hub:          hub,
+	}
+}
+
+// Connect upgrades to a WebSocket on which the user shares their position
+// and receives the positions of their department and of the requested parks.
+// Members may only request the parks they are assigned to; supervisors and
+// admins may request any park.
+// GET /api/v1/presence
+func (h *PresenceHandler) Connect(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching user",
+			},
+		})
+		return
+	}
+
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "User not found",
+			},
+		})
+		return
+	}
+
+	var parkNames []string
+	for _, park := range c.QueryArray("park_name") {
+		if park = strings.TrimSpace(park); park != "" {
+			parkNames = append(parkNames, park)
+		}
+	}
+
+	session := &presenceSession{handler: h, user: user, anyPark: user.IsSupervisor()}
+	if !session.anyPark {
+		session.parks, err = h.userRepo.ListParks(c.Request.Context(), user.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error fetching user parks",
+				},
+			})
+			return
+		}
+
+		denied := []string{}
+		for _, park := range parkNames {
+			if !utils.ContainsString(session.parks, park) {
+				denied = append(denied, park)
+			}
+		}
+		if len(denied) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":    "AUTHORIZATION_ERROR",
+					"message": "You can only follow your department and the parks you are assigned to",
+					"details": gin.H{
+						"park_names": denied,
+					},
+				},
+			})
+			return
+		}
+	}
+
+	if user.Department == "" && len(parkNames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "park_name is required for users without a department",
+			},
+		})
+		return
+	}
+
+	// Upgrade writes its own error response
+	conn, err := presenceUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	client := h.hub.Join(user.UserID, user.Department, parkNames)
+	defer client.Close()
+
+	session.conn = conn
+	defer session.stopSharing()
+
+	snapshot, err := h.hub.Snapshot(c.Request.Context(), client)
+	if err != nil {
+		log.Printf("Error loading presence snapshot for %s: %v", user.UserID, err)
+		return
+	}
+	if err := session.write(snapshot); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	incoming := session.readMessages(done)
+
+	ping := time.NewTicker(presencePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case message, ok := <-incoming:
+			if !ok {
+				// Closed by the client or timed out
+				return
+			}
+			if reply := session.handle(message); reply != nil {
+				if err := session.write(reply); err != nil {
+					return
+				}
+			}
+
+		case message := <-client.Messages:
+			if err := session.write(message); err != nil {
+				return
+			}
+
+		case <-client.Dropped:
+			// Too slow to keep up; the client reconnects for a fresh snapshot
+			return
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(presenceWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// presenceSession is the state of one presence connection
+type presenceSession struct {
+	handler *PresenceHandler
+	conn    *websocket.Conn
+	user    *models.User
+
+	anyPark bool     // The user may share a position in any park
+	parks   []string // Otherwise, the parks the user is assigned to
+
+	sharing    bool      // This connection has stored a position
+	lastShared time.Time // When the last position was accepted
+}
+
+// readMessages reads client messages until the connection fails or done is
+// closed, closing the returned channel then. Malformed messages are delivered
+// with an empty type.
+func (s *presenceSession) readMessages(done <-chan struct{}) <-chan *models.PresenceClientMessage {
+	messages := make(chan *models.PresenceClientMessage)
+
+	s.conn.SetReadLimit(presenceMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(presencePongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(presencePongWait))
+	})
+
+	go func() {
+		defer close(messages)
+		for {
+			_, data, err := s.conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.conn.SetReadDeadline(time.Now().Add(presencePongWait))
+
+			var message models.PresenceClientMessage
+			if err := json.Unmarshal(data, &message); err != nil {
+				message = models.PresenceClientMessage{}
+			}
+
+			select {
+			case messages <- &message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return messages
+}
+
+// handle applies a client message and returns an error message to send back,
+// if any
+func (s *presenceSession) handle(message *models.PresenceClientMessage) *models.PresenceServerMessage {
+	switch message.Type {
+	case models.PresenceMessagePosition:
+		return s.share(message)
+	case models.PresenceMessageStop:
+		s.stopSharing()
+		return nil
+	default:
+		return presenceError("Unknown message type; expected position or stop")
+	}
+}
+
+// share stores the position in a client message
+func (s *presenceSession) share(message *models.PresenceClientMessage) *models.PresenceServerMessage {
+	if message.Latitude == nil || *message.Latitude < -90 || *message.Latitude > 90 {
+		return presenceError("latitude must be a number between -90 and 90")
+	}
+	if message.Longitude == nil || *message.Longitude < -180 || *message.Longitude > 180 {
+		return presenceError("longitude must be a number between -180 and 180")
+	}
+	if message.Accuracy != nil && *message.Accuracy < 0 {
+		return presenceError("accuracy must not be negative")
+	}
+	if message.Heading != nil && (*message.Heading < 0 || *message.Heading >= 360) {
+		return presenceError("heading must be at least 0 and below 360")
+	}
+
+	// Positions are relayed to everyone following the park, so members
+	// can't announce themselves in parks they aren't assigned to
+	park := ""
+	if message.ParkName != nil {
+		park = strings.TrimSpace(*message.ParkName)
+	}
+	if park != "" && !s.anyPark && !utils.ContainsString(s.parks, park) {
+		return presenceError("park_name must be one of the parks you are assigned to")
+	}
+
+	if time.Since(s.lastShared) < presenceMinInterval {
+		return nil
+	}
+
+	position := &models.CrewPosition{
+		UserID:    s.user.UserID,
+		UserName:  s.user.DisplayName,
+		UserColor: s.user.Color,
+		Latitude:  *message.Latitude,
+		Longitude: *message.Longitude,
+		Accuracy:  message.Accuracy,
+		Heading:   message.Heading,
+	}
+	if s.user.Department != "" {
+		position.Department = &s.user.Department
+	}
+	if park != "" {
+		position.ParkName = &park
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), presenceWriteWait)
+	defer cancel()
+
+	if err := s.handler.positionRepo.Upsert(ctx, position); err != nil {
+		log.Printf("Error sharing position of %s: %v", s.user.UserID, err)
+		return presenceError("Error saving position")
+	}
+
+	s.sharing = true
+	s.lastShared = time.Now()
+	return nil
+}
+
+// stopSharing removes the position this connection stored, if any. It also
+// runs when the connection closes, so it does not use the request context.
+func (s *presenceSession) stopSharing() {
+	if !s.sharing {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), presenceWriteWait)
+	defer cancel()
+
+	if err := s.handler.positionRepo.Delete(ctx, s.user.UserID); err != nil {
+		log.Printf("Error removing position of %s: %v", s.user.UserID, err)
+		return
+	}
+
+	s.sharing = false
+	s.lastShared = time.Time{}
+}
+
+// write sends a message as JSON
+func (s *presenceSession) write(message interface{}) error {
+	s.conn.SetWriteDeadline(time.Now().Add(presenceWriteWait))
+	return s.conn.WriteJSON(message)
+}
+
+// presenceError builds an error message for a rejected client message
+func presenceError(message string) *models.PresenceServerMessage {
+	return &models.PresenceServerMessage{Type: models.PresenceMessageError, Message: message}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// ReportHandler handles PDF field report requests
+type ReportHandler struct {
+	reportRepo      *repository.ReportRepository
+	jobRepo         *repository.JobRepository
+	firebaseService *services.FirebaseService
+	audit           *AuditLogger
+	downloadExpiry  time.Duration
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(
+	reportRepo *repository.ReportRepository,
+	jobRepo *repository.JobRepository,
+	firebaseService *services.FirebaseService,
+	audit *AuditLogger,
+	downloadExpiry time.Duration,
+) *ReportHandler {
+	return &ReportHandler{
+		reportRepo:      reportRepo,
+		jobRepo:         jobRepo,
+		firebaseService: firebaseService,
+		audit:           audit,
+		downloadExpiry:  downloadExpiry,
+	}
+}
+
+// Create queues a PDF report of the memos matching a filter. Poll the
+// report until its status is completed, then download it.
+// POST /api/v1/reports
+func (h *ReportHandler) Create(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	report := &models.Report{
+		UserID:   userID,
+		Title:    strings.TrimSpace(req.Title),
+		Filter:   req.Filter,
+		Timezone: strings.TrimSpace(req.Timezone),
+	}
+	if report.Title == "" {
+		writeFilterError(c, "title", "Must not be empty")
+		return
+	}
+	if field, reason := normalizeMemoFilter(&report.Filter); field != "" {
+		writeFilterError(c, "filter."+field, reason)
+		return
+	}
+	if report.Filter.IsEmpty() {
+		writeFilterError(c, "filter", "Must set a park, date range or other criterion")
+		return
+	}
+	if report.Timezone == "" {
+		report.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(report.Timezone); err != nil || report.Timezone == "Local" {
+		writeFilterError(c, "timezone", "Must be an IANA time zone such as America/Denver")
+		return
+	}
+
+	if err := h.reportRepo.Create(c.Request.Context(), report); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error creating report",
+			},
+		})
+		return
+	}
+
+	payload := models.ReportJobPayload{ReportID: report.ReportID.String()}
+	if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeReport, payload); err != nil {
+		_ = h.reportRepo.Delete(c.Request.Context(), report.ReportID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error queuing report",
+			},
+		})
+		return
+	}
+
+	h.audit.Record(c, models.AuditActionReportCreate, models.AuditTargetReport, report.ReportID.String(), nil, report, nil)
+
+	c.JSON(http.StatusAccepted, report)
+}
+
+// List retrieves the current user's reports, newest first
+// GET /api/v1/reports
+func (h *ReportHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	reports, total, err := h.reportRepo.ListByUser(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching reports",
+			},
+		})
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, models.ReportsListResponse{
+		Reports: reports,
+		Pagination: models.PaginationResponse{
+			CurrentPage:  page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: limit,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
+
+// GetByID retrieves one of the current user's reports, to check its status
+// GET /api/v1/reports/:id
+func (h *ReportHandler) GetByID(c *gin.Context) {
+	report, ok := h.loadOwnReport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Download redirects to a short-lived URL for a completed report's PDF
+// GET /api/v1/reports/:id/download
+func (h *ReportHandler) Download(c *gin.Context) {
+	report, ok := h.loadOwnReport(c)
+	if !ok {
+		return
+	}
+
+	if report.Status != models.ReportStatusCompleted || report.StorageKey == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Report is not ready",
+				"details": gin.H{"status": report.Status},
+			},
+		})
+		return
+	}
+
+	url, _, err := h.firebaseService.SignedDownloadURL(*report.StorageKey, h.downloadExpiry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error generating download URL",
+			},
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// Delete deletes a report and its PDF
+// DELETE /api/v1/reports/:id
+func (h *ReportHandler) Delete(c *gin.Context) {
+	report, ok := h.loadOwnReport(c)
+	if !ok {
+		return
+	}
+
+	if report.StorageKey != nil {
+		// A leftover file is harmless; don't keep the report over it
+		_ = h.firebaseService.DeleteObject(c.Request.Context(), *report.StorageKey)
+	}
+
+	if err := h.reportRepo.Delete(c.Request.Context(), report.ReportID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error deleting report",
+			},
+		})
+		return
+	}
+
+	h.audit.Record(c, models.AuditActionReportDelete, models.AuditTargetReport, report.ReportID.String(), report, nil, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadOwnReport parses the report ID and fetches the report if it belongs to
+// the current user. Other users' reports are reported as not found. It
+// writes the error response and returns false on failure.
+func (h *ReportHandler) loadOwnReport(c *gin.Context) (*models.Report, bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	reportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid report ID",
+			},
+		})
+		return nil, false
+	}
+
+	report, err := h.reportRepo.GetByID(c.Request.Context(), reportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching report",
+			},
+		})
+		return nil, false
+	}
+
+	if report == nil || report.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Report not found",
+			},
+		})
+		return nil, false
+	}
+
+	return report, true
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// RevisionHandler handles memo revision history requests
+type RevisionHandler struct {
+	memoRepo     *repository.MemoRepository
+	revisionRepo *repository.RevisionRepository
+}
+
+// NewRevisionHandler creates a new revision handler
+func NewRevisionHandler(memoRepo *repository.MemoRepository, revisionRepo *repository.RevisionRepository) *RevisionHandler {
+	return &RevisionHandler{
+		memoRepo:     memoRepo,
+		revisionRepo: revisionRepo,
+	}
+}
+
+// List retrieves the edit history of a memo
+// GET /api/v1/memos/:id/revisions
+func (h *RevisionHandler) List(c *gin.Context) {
+	memoID, ok := h.loadMemoID(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.revisionRepo.ListByMemo(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching revisions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevisionsListResponse{
+		MemoID:    memoID,
+		Revisions: revisions,
+	})
+}
+
+// Diff compares two revisions of a memo
+// GET /api/v1/memos/:id/revisions/diff
+func (h *RevisionHandler) Diff(c *gin.Context) {
+	memoID, ok := h.loadMemoID(c)
+	if !ok {
+		return
+	}
+
+	latest, err := h.revisionRepo.GetLatestNumber(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching revisions",
+			},
+		})
+		return
+	}
+
+	if latest == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo has not been edited",
+			},
+		})
+		return
+	}
+
+	// Default to comparing the latest revision with the one before it
+	to := latest
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid 'to' revision number",
+				},
+			})
+			return
+		}
+	}
+
+	from := to - 1
+	if from < 1 {
+		from = 1
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err = strconv.Atoi(fromStr)
+		if err != nil || from < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid 'from' revision number",
+				},
+			})
+			return
+		}
+	}
+
+	fromRev, err := h.revisionRepo.GetByNumber(c.Request.Context(), memoID, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching revision",
+			},
+		})
+		return
+	}
+
+	toRev, err := h.revisionRepo.GetByNumber(c.Request.Context(), memoID, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching revision",
+			},
+		})
+		return
+	}
+
+	if fromRev == nil || toRev == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Revision not found",
+				"details": gin.H{
+					"latest_revision": latest,
+				},
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevisionDiffResponse{
+		MemoID:   memoID,
+		From:     *fromRev,
+		To:       *toRev,
+		Changes:  diffRevisionFields(fromRev, toRev),
+		TextDiff: utils.DiffWords(fromRev.Text, toRev.Text),
+	})
+}
+
+// loadMemoID parses the memo ID path parameter and checks that the memo exists.
+// It writes the error response and returns false if the request cannot proceed.
+func (h *RevisionHandler) loadMemoID(c *gin.Context) (uuid.UUID, bool) {
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	if memo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo not found",
+			},
+		})
+		return uuid.Nil, false
+	}
+
+	return memoID, true
+}
+
+// diffRevisionFields lists the editable fields that differ between two revisions
+func diffRevisionFields(from, to *models.MemoRevision) []models.FieldChange {
+	changes := []models.FieldChange{}
+
+	if !equalStringPtr(from.Title, to.Title) {
+		changes = append(changes, models.FieldChange{Field: "title", From: from.Title, To: to.Title})
+	}
+	if from.Text != to.Text {
+		changes = append(changes, models.FieldChange{Field: "text", From: from.Text, To: to.Text})
+	}
+	if !equalStringPtr(from.ParkName, to.ParkName) {
+		changes = append(changes, models.FieldChange{Field: "park_name", From: from.ParkName, To: to.ParkName})
+	}
+	if !equalFloatPtr(from.Latitude, to.Latitude) {
+		changes = append(changes, models.FieldChange{Field: "latitude", From: from.Latitude, To: to.Latitude})
+	}
+	if !equalFloatPtr(from.Longitude, to.Longitude) {
+		changes = append(changes, models.FieldChange{Field: "longitude", From: from.Longitude, To: to.Longitude})
+	}
+
+	return changes
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
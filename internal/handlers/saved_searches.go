@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// SavedSearchHandler handles saved search requests
+type SavedSearchHandler struct {
+	savedSearchRepo *repository.SavedSearchRepository
+}
+
+// NewSavedSearchHandler creates a new saved search handler
+func NewSavedSearchHandler(savedSearchRepo *repository.SavedSearchRepository) *SavedSearchHandler {
+	return &SavedSearchHandler{savedSearchRepo: savedSearchRepo}
+}
+
+// Create saves a search for the current user
+// POST /api/v1/saved-searches
+func (h *SavedSearchHandler) Create(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	search := &models.SavedSearch{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Filter:      req.Filter,
+		NotifyInApp: req.NotifyInApp == nil || *req.NotifyInApp,
+		NotifyEmail: req.NotifyEmail,
+		NotifyPush:  req.NotifyPush,
+		WebhookURL:  req.WebhookURL,
+	}
+	if !validateSavedSearch(c, search) || !setSearchWebhookSecret(c, search) {
+		return
+	}
+
+	if err := h.savedSearchRepo.Create(c.Request.Context(), search); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error saving search",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// List retrieves the current user's saved searches
+// GET /api/v1/saved-searches
+func (h *SavedSearchHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	searches, err := h.savedSearchRepo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching saved searches",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SavedSearchesListResponse{SavedSearches: searches})
+}
+
+// GetByID retrieves one of the current user's saved searches
+// GET /api/v1/saved-searches/:id
+func (h *SavedSearchHandler) GetByID(c *gin.Context) {
+	search, ok := h.loadOwnSearch(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// Update changes a saved search's name, filter or notification settings
+// PUT /api/v1/saved-searches/:id
+func (h *SavedSearchHandler) Update(c *gin.Context) {
+	var req models.UpdateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	search, ok := h.loadOwnSearch(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		search.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Filter != nil {
+		search.Filter = *req.Filter
+	}
+	if req.NotifyInApp != nil {
+		search.NotifyInApp = *req.NotifyInApp
+	}
+	if req.NotifyEmail != nil {
+		search.NotifyEmail = *req.NotifyEmail
+	}
+	if req.NotifyPush != nil {
+		search.NotifyPush = *req.NotifyPush
+	}
+	if req.WebhookURL != nil {
+		search.WebhookURL = req.WebhookURL
+	}
+	if !validateSavedSearch(c, search) || !setSearchWebhookSecret(c, search) {
+		return
+	}
+
+	if err := h.savedSearchRepo.Update(c.Request.Context(), search); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating saved search",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// Delete deletes a saved search
+// DELETE /api/v1/saved-searches/:id
+func (h *SavedSearchHandler) Delete(c *gin.Context) {
+	search, ok := h.loadOwnSearch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.savedSearchRepo.Delete(c.Request.Context(), search.SearchID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error deleting saved search",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadOwnSearch parses the saved search ID and fetches the search if it
+// belongs to the current user. Other users' searches are reported as not
+// found. It writes the error response and returns false on failure.
+func (h *SavedSearchHandler) loadOwnSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	searchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid saved search ID",
+			},
+		})
+		return nil, false
+	}
+
+	search, err := h.savedSearchRepo.GetByID(c.Request.Context(), searchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching saved search",
+			},
+		})
+		return nil, false
+	}
+
+	if search == nil || search.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Saved search not found",
+			},
+		})
+		return nil, false
+	}
+
+	return search, true
+}
+
+// validateSavedSearch normalizes and checks a saved search before it is
+// stored. It writes a validation error and returns false if it is invalid.
+func validateSavedSearch(c *gin.Context, search *models.SavedSearch) bool {
+	if search.Name == "" {
+		writeFilterError(c, "name", "Must not be empty")
+		return false
+	}
+
+	if field, reason := normalizeMemoFilter(&search.Filter); field != "" {
+		writeFilterError(c, "filter."+field, reason)
+		return false
+	}
+	if search.Filter.IsEmpty() {
+		writeFilterError(c, "filter", "Must set at least one criterion")
+		return false
+	}
+
+	if search.WebhookURL != nil {
+		webhookURL := strings.TrimSpace(*search.WebhookURL)
+		if webhookURL == "" {
+			search.WebhookURL = nil
+		} else {
+			if !isWebhookURL(webhookURL) {
+				writeFilterError(c, "webhook_url", "Must be an absolute http or https URL on a public host")
+				return false
+			}
+			search.WebhookURL = &webhookURL
+		}
+	}
+
+	return true
+}
+
+// setSearchWebhookSecret generates a signing secret for a saved search that
+// has just been given a webhook, and drops it when the webhook is removed. It
+// writes an error and returns false if no secret can be generated.
+func setSearchWebhookSecret(c *gin.Context, search *models.SavedSearch) bool {
+	if search.WebhookURL == nil {
+		search.WebhookSecret = nil
+		return true
+	}
+	if search.WebhookSecret != nil {
+		return true
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error generating webhook secret",
+			},
+		})
+		return false
+	}
+	search.WebhookSecret = &secret
+
+	return true
+}
+
+// isWebhookURL reports whether value is an absolute http or https URL. Hosts
+// that are obviously not public are refused up front; hostnames that resolve
+// to a private address are refused when the webhook is sent.
+func isWebhookURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && !utils.IsPublicIP(ip) {
+		return false
+	}
+
+	return true
+}
+
+// requireUserID returns the authenticated user's ID. It writes an
+// authentication error and returns false if there is none.
+func requireUserID(c *gin.Context) (string, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+// writeInvalidBody responds with a validation error for a malformed body
+func writeInvalidBody(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "VALIDATION_ERROR",
+			"message": "Invalid request body",
+			"details": gin.H{
+				"reason": err.Error(),
+			},
+		},
+	})
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+const (
+	// maxStatsBuckets caps the length of a time series
+	maxStatsBuckets = 400
+
+	defaultStatsGroups = 10
+	maxStatsGroups     = 100
+)
+
+// StatsHandler handles memo statistics requests
+type StatsHandler struct {
+	statsRepo *repository.StatsRepository
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsRepo *repository.StatsRepository) *StatsHandler {
+	return &StatsHandler{statsRepo: statsRepo}
+}
+
+// Summary totals the memos matching the filter: how many, how many have
+// audio, recorded minutes, contributors and parks
+// GET /api/v1/stats/summary
+func (h *StatsHandler) Summary(c *gin.Context) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.statsRepo.Summary(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error computing statistics",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// Memos counts the memos matching the filter per day, week or month, in
+// total and optionally for the largest groups
+// GET /api/v1/stats/memos
+func (h *StatsHandler) Memos(c *gin.Context) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+
+	interval := c.DefaultQuery("interval", models.StatsIntervalDay)
+	var defaultRange func(time.Time) time.Time
+	switch interval {
+	case models.StatsIntervalDay:
+		defaultRange = func(end time.Time) time.Time { return end.AddDate(0, 0, -30) }
+	case models.StatsIntervalWeek:
+		defaultRange = func(end time.Time) time.Time { return end.AddDate(0, 0, -12*7) }
+	case models.StatsIntervalMonth:
+		defaultRange = func(end time.Time) time.Time { return end.AddDate(0, -12, 0) }
+	default:
+		writeFilterError(c, "interval", "Must be one of day, week, month")
+		return
+	}
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && !utils.ContainsString(models.StatsGroupings, groupBy) {
+		writeFilterError(c, "group_by", "Must be one of "+strings.Join(models.StatsGroupings, ", "))
+		return
+	}
+
+	loc, ok := parseStatsTimezone(c)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseStatsLimit(c)
+	if !ok {
+		return
+	}
+
+	// Without dates, cover the last 30 days, 12 weeks or 12 months
+	if filter.EndDate == nil {
+		end := time.Now().UTC()
+		filter.EndDate = &end
+	}
+	if filter.StartDate == nil {
+		start := defaultRange(*filter.EndDate)
+		filter.StartDate = &start
+	}
+	if !filter.StartDate.Before(*filter.EndDate) {
+		writeFilterError(c, "start_date", "Must be before end_date")
+		return
+	}
+	if len(models.StatsBucketStarts(*filter.StartDate, *filter.EndDate, interval, loc)) > maxStatsBuckets {
+		writeFilterError(c, "start_date", fmt.Sprintf("Range must cover at most %d buckets; use a longer interval", maxStatsBuckets))
+		return
+	}
+
+	buckets, series, err := h.statsRepo.TimeSeries(c.Request.Context(), filter, interval, loc, groupBy, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error computing statistics",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.StatsTimeSeriesResponse{
+		Interval: interval,
+		Timezone: loc.String(),
+		GroupBy:  groupBy,
+		Buckets:  buckets,
+		Series:   series,
+	})
+}
+
+// Breakdown counts the memos matching the filter per park, user,
+// department, tag or category, largest first
+// GET /api/v1/stats/breakdown
+func (h *StatsHandler) Breakdown(c *gin.Context) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+
+	groupBy := c.Query("group_by")
+	if !utils.ContainsString(models.StatsGroupings, groupBy) {
+		writeFilterError(c, "group_by", "Must be one of "+strings.Join(models.StatsGroupings, ", "))
+		return
+	}
+
+	limit, ok := parseStatsLimit(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.statsRepo.Breakdown(c.Request.Context(), filter, groupBy, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error computing statistics",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.StatsBreakdownResponse{
+		GroupBy: groupBy,
+		Groups:  groups,
+	})
+}
+
+// parseStatsTimezone reads the time zone buckets are cut in, UTC by default
+func parseStatsTimezone(c *gin.Context) (*time.Location, bool) {
+	name := c.DefaultQuery("timezone", "UTC")
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		writeFilterError(c, "timezone", "Must be an IANA time zone such as America/Denver")
+		return nil, false
+	}
+	return loc, true
+}
+
+// parseStatsLimit reads how many groups to return
+func parseStatsLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultStatsGroups)))
+	if err != nil || limit < 1 || limit > maxStatsGroups {
+		writeFilterError(c, "limit", fmt.Sprintf("Must be between 1 and %d", maxStatsGroups))
+		return 0, false
+	}
+	return limit, true
+}
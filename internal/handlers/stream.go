@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/realtime"
+)
+
+const (
+	// streamReplayLimit is the most missed events replayed on resumption
+	streamReplayLimit = 500
+
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 25 * time.Second
+)
+
+// StreamHandler handles Server-Sent Events streams
+type StreamHandler struct {
+	memoFeed *realtime.MemoFeed
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(memoFeed *realtime.MemoFeed) *StreamHandler {
+	return &StreamHandler{
+		memoFeed: memoFeed,
+	}
+}
+
+// Memos streams memo created, updated and deleted events
+// GET /api/v1/stream/memos
+func (h *StreamHandler) Memos(c *gin.Context) {
+	filter, ok := parseMemoFilter(c)
+	if !ok {
+		return
+	}
+	if param := unsupportedStreamFilter(filter); param != "" {
+		writeFilterError(c, param, "Streams can only be filtered by user_id, park_name and bbox")
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			writeFilterError(c, "last_event_id", "Must be an event ID")
+			return
+		}
+		afterID = id
+	}
+
+	// Subscribe before replaying so nothing is missed in between; events
+	// received in both are skipped by ID
+	sub := h.memoFeed.Subscribe()
+	defer sub.Close()
+
+	var replay []*models.MemoEvent
+	complete := true
+	if lastEventID != "" {
+		var err error
+		replay, complete, err = h.memoFeed.Replay(c.Request.Context(), afterID, streamReplayLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error replaying memo events",
+				},
+			})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: 5000\n\n")
+
+	if !complete {
+		// The missed events are gone; the client should reload its memos
+		fmt.Fprintf(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+
+	sentID := afterID
+	for _, event := range replay {
+		if event.MatchesStream(filter) {
+			writeMemoEvent(c, event)
+		}
+		sentID = event.EventID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case <-sub.Dropped:
+			// Too slow to keep up; the client reconnects with Last-Event-ID
+			return
+
+		case event := <-sub.Events:
+			if event.EventID <= sentID || !event.MatchesStream(filter) {
+				continue
+			}
+			writeMemoEvent(c, event)
+			sentID = event.EventID
+			c.Writer.Flush()
+
+		case <-heartbeat.C:
+			fmt.Fprintf(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeMemoEvent writes an event in the text/event-stream format
+func writeMemoEvent(c *gin.Context, event *models.MemoEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding memo event %d: %v", event.EventID, err)
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.EventID, event.EventType, data)
+}
+
+// unsupportedStreamFilter returns the first filter parameter that streams
+// cannot apply, or an empty string
+func unsupportedStreamFilter(filter *models.MemoFilter) string {
+	switch {
+	case filter.Query != "":
+		return "q"
+	case filter.Category != "":
+		return "category"
+	case filter.Language != "":
+		return "language"
+	case len(filter.Tags) > 0:
+		return "tag"
+	case filter.StartDate != nil:
+		return "start_date"
+	case filter.EndDate != nil:
+		return "end_date"
+	case filter.Near != nil:
+		return "latitude"
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// TagHandler handles memo tag suggestion requests
+type TagHandler struct {
+	memoRepo       *repository.MemoRepository
+	suggestionRepo *repository.TagSuggestionRepository
+	jobRepo        *repository.JobRepository
+	audit          *AuditLogger
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(
+	memoRepo *repository.MemoRepository,
+	suggestionRepo *repository.TagSuggestionRepository,
+	jobRepo *repository.JobRepository,
+	audit *AuditLogger,
+) *TagHandler {
+	return &TagHandler{
+		memoRepo:       memoRepo,
+		suggestionRepo: suggestionRepo,
+		jobRepo:        jobRepo,
+		audit:          audit,
+	}
+}
+
+// GetSuggestions retrieves the tags and category suggested for a memo
+// GET /api/v1/memos/:id/suggestions
+func (h *TagHandler) GetSuggestions(c *gin.Context) {
+	memo, ok := h.loadMemo(c)
+	if !ok {
+		return
+	}
+
+	suggestion, ok := h.loadSuggestion(c, memo.MemoID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// AcceptSuggestions applies suggested tags and category to a memo
+// POST /api/v1/memos/:id/suggestions/accept
+func (h *TagHandler) AcceptSuggestions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return
+	}
+
+	// The body is optional; an empty one accepts everything
+	var req models.AcceptSuggestionsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid request body",
+					"details": gin.H{
+						"reason": err.Error(),
+					},
+				},
+			})
+			return
+		}
+	}
+
+	memo, ok := h.loadMemo(c)
+	if !ok {
+		return
+	}
+
+	if memo.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "You can only tag your own memos",
+			},
+		})
+		return
+	}
+
+	suggestion, ok := h.loadSuggestion(c, memo.MemoID)
+	if !ok {
+		return
+	}
+
+	tags := []string(suggestion.Tags)
+	if req.Tags != nil {
+		for _, tag := range req.Tags {
+			if !utils.ContainsString(suggestion.Tags, tag) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": gin.H{
+						"code":    "VALIDATION_ERROR",
+						"message": "Tag was not suggested for this memo",
+						"details": gin.H{
+							"tag":       tag,
+							"suggested": suggestion.Tags,
+						},
+					},
+				})
+				return
+			}
+		}
+		tags = req.Tags
+	}
+
+	var category *string
+	if req.AcceptCategory == nil || *req.AcceptCategory {
+		category = suggestion.Category
+	}
+
+	audit := h.audit.Entry(c, models.AuditActionMemoUpdate, models.AuditTargetMemo, memo.MemoID.String(), nil, nil,
+		gin.H{"source": "tag_suggestions"})
+	result, err := h.suggestionRepo.Accept(c.Request.Context(), memo.MemoID, tags, category, audit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error accepting suggestions",
+			},
+		})
+		return
+	}
+
+	// New tags may match saved searches
+	payload := models.MemoJobPayload{MemoID: memo.MemoID.String()}
+	if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeMatchSearches, payload); err != nil {
+		log.Printf("Error enqueuing saved search matching for memo %s: %v", memo.MemoID, err)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// loadMemo parses the memo ID and fetches the memo. It writes the error
+// response and returns false if the memo cannot be loaded.
+func (h *TagHandler) loadMemo(c *gin.Context) (*models.Memo, bool) {
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return nil, false
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return nil, false
+	}
+
+	if memo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo not found",
+			},
+		})
+		return nil, false
+	}
+
+	return memo, true
+}
+
+// loadSuggestion fetches a memo's suggestions. It writes the error response
+// and returns false if there are none yet.
+func (h *TagHandler) loadSuggestion(c *gin.Context, memoID uuid.UUID) (*models.TagSuggestion, bool) {
+	suggestion, err := h.suggestionRepo.GetByMemo(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching suggestions",
+			},
+		})
+		return nil, false
+	}
+
+	if suggestion == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Suggestions not available yet",
+			},
+		})
+		return nil, false
+	}
+
+	return suggestion, true
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// TranscriptHandler handles server-side transcription requests
+type TranscriptHandler struct {
+	memoRepo       *repository.MemoRepository
+	transcriptRepo *repository.TranscriptRepository
+	jobRepo        *repository.JobRepository
+	transcriber    services.Transcriber
+	maxDuration    time.Duration
+}
+
+// NewTranscriptHandler creates a new transcript handler
+func NewTranscriptHandler(
+	memoRepo *repository.MemoRepository,
+	transcriptRepo *repository.TranscriptRepository,
+	jobRepo *repository.JobRepository,
+	transcriber services.Transcriber,
+	maxDuration time.Duration,
+) *TranscriptHandler {
+	return &TranscriptHandler{
+		memoRepo:       memoRepo,
+		transcriptRepo: transcriptRepo,
+		jobRepo:        jobRepo,
+		transcriber:    transcriber,
+		maxDuration:    maxDuration,
+	}
+}
+
+// Get retrieves the server transcript of a memo with word and segment timings
+// GET /api/v1/memos/:id/transcript
+func (h *TranscriptHandler) Get(c *gin.Context) {
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return
+	}
+
+	if memo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo not found",
+			},
+		})
+		return
+	}
+
+	transcript, err := h.transcriptRepo.GetByMemo(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching transcript",
+			},
+		})
+		return
+	}
+
+	if transcript == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Transcript not available",
+				"details": gin.H{
+					"transcription_status": memo.TranscriptionStatus,
+				},
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, transcript)
+}
+
+// Request queues a server-side transcription of a memo's recording
+// POST /api/v1/memos/:id/transcription
+func (h *TranscriptHandler) Request(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return
+	}
+
+	memoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid memo ID",
+			},
+		})
+		return
+	}
+
+	memo, err := h.memoRepo.GetByID(c.Request.Context(), memoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching memo",
+			},
+		})
+		return
+	}
+
+	if memo == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Memo not found",
+			},
+		})
+		return
+	}
+
+	if memo.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "You can only transcribe your own memos",
+			},
+		})
+		return
+	}
+
+	if memo.AudioKey == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Memo has no audio to transcribe",
+			},
+		})
+		return
+	}
+
+	if h.maxDuration > 0 && time.Duration(memo.DurationSeconds)*time.Second > h.maxDuration {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Recording is longer than the transcription limit",
+				"details": gin.H{
+					"max_duration_seconds": int(h.maxDuration.Seconds()),
+				},
+			},
+		})
+		return
+	}
+
+	if !h.transcriber.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": gin.H{
+				"code":    "SERVICE_UNAVAILABLE",
+				"message": "Transcription is not available",
+			},
+		})
+		return
+	}
+
+	if err := h.memoRepo.SetTranscriptionStatus(c.Request.Context(), memoID, models.TranscriptionStatusPending); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error queuing transcription",
+			},
+		})
+		return
+	}
+
+	payload := models.TranscribeJobPayload{MemoID: memoID.String(), ReplaceText: true, Requested: true}
+	if _, err := h.jobRepo.Enqueue(c.Request.Context(), models.JobTypeTranscribe, payload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error queuing transcription",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"memo_id":              memoID,
+		"transcription_status": models.TranscriptionStatusPending,
+	})
+}
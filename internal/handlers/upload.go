@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/middleware"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// tusVersion is the tus protocol version implemented by the resumable upload endpoints
+const tusVersion = "1.0.0"
+
+// uploadLease bounds how long a single PATCH request may hold an upload
+const uploadLease = 10 * time.Minute
+
+// minUploadChunk is the floor for the minimum chunk size of resumable uploads
+const minUploadChunk = 256 * 1024
+
+// UploadHandler handles direct-to-storage and resumable upload requests
+type UploadHandler struct {
+	uploadRepo      *repository.UploadRepository
+	firebaseService *services.FirebaseService
+	maxUploadSize   int64
+	urlExpiry       time.Duration
+	uploadExpiry    time.Duration
+}
+
+// NewUploadHandler creates a new upload handler
+func NewUploadHandler(
+	uploadRepo *repository.UploadRepository,
+	firebaseService *services.FirebaseService,
+	maxUploadSize int64,
+	urlExpiry time.Duration,
+	uploadExpiry time.Duration,
+) *UploadHandler {
+	return &UploadHandler{
+		uploadRepo:      uploadRepo,
+		firebaseService: firebaseService,
+		maxUploadSize:   maxUploadSize,
+		urlExpiry:       urlExpiry,
+		uploadExpiry:    uploadExpiry,
+	}
+}
+
+// GetPresignedURL returns a signed URL for uploading audio directly to storage
+// GET /api/v1/upload/presigned-url
+func (h *UploadHandler) GetPresignedURL(c *gin.Context) {
+	// Get authenticated user ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return
+	}
+
+	filename := c.Query("filename")
+	contentType := c.Query("content_type")
+	if filename == "" || contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "filename and content_type are required",
+			},
+		})
+		return
+	}
+
+	if !services.IsAllowedAudioMIME(contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Unsupported audio format",
+				"details": gin.H{
+					"content_type": contentType,
+					"allowed":      services.AllowedAudioFormatNames(),
+				},
+			},
+		})
+		return
+	}
+
+	upload, err := h.firebaseService.GeneratePresignedUploadURL(c.Request.Context(), userID, filename, contentType, h.urlExpiry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error generating upload URL",
+				"details": gin.H{
+					"reason": err.Error(),
+				},
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// CreateResumable starts a resumable upload
+// POST /api/v1/uploads
+func (h *UploadHandler) CreateResumable(c *gin.Context) {
+	// Get authenticated user ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return
+	}
+
+	c.Header("Tus-Resumable", tusVersion)
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Upload-Length header is required",
+			},
+		})
+		return
+	}
+
+	if length > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "File size exceeds maximum allowed size",
+				"details": gin.H{
+					"max_size_mb": h.maxUploadSize / (1024 * 1024),
+				},
+			},
+		})
+		return
+	}
+
+	metadata := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	contentType := metadata["filetype"]
+	if !services.IsAllowedAudioMIME(contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Upload-Metadata must include a supported audio filetype",
+				"details": gin.H{
+					"filetype": contentType,
+					"allowed":  services.AllowedAudioFormatNames(),
+				},
+			},
+		})
+		return
+	}
+
+	var filename *string
+	if name := metadata["filename"]; name != "" {
+		filename = &name
+	}
+
+	upload := &models.Upload{
+		UserID:       userID,
+		ObjectKey:    services.AudioObjectKey(userID, metadata["filename"]),
+		Filename:     filename,
+		ContentType:  contentType,
+		UploadLength: length,
+		ExpiresAt:    time.Now().Add(h.uploadExpiry).UTC(),
+	}
+
+	if err := h.uploadRepo.Create(c.Request.Context(), upload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error creating upload",
+			},
+		})
+		return
+	}
+
+	c.Header("Location", "/api/v1/uploads/"+upload.UploadID.String())
+	c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+	c.JSON(http.StatusCreated, upload)
+}
+
+// GetResumableOffset reports how many bytes of an upload have been received
+// HEAD /api/v1/uploads/:id
+func (h *UploadHandler) GetResumableOffset(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+
+	upload, ok := h.loadOwnedUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.UploadOffset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.UploadLength, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// PatchResumable appends a chunk to an upload
+// PATCH /api/v1/uploads/:id
+func (h *UploadHandler) PatchResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Content-Type must be application/offset+octet-stream",
+			},
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Upload-Offset header is required",
+			},
+		})
+		return
+	}
+
+	upload, ok := h.loadOwnedUpload(c)
+	if !ok {
+		return
+	}
+
+	if offset != upload.UploadOffset || upload.IsComplete() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Upload-Offset does not match the current upload offset",
+				"details": gin.H{
+					"upload_offset": upload.UploadOffset,
+					"upload_length": upload.UploadLength,
+				},
+			},
+		})
+		return
+	}
+
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Content-Length header is required",
+			},
+		})
+		return
+	}
+
+	remaining := upload.UploadLength - upload.UploadOffset
+	if c.Request.ContentLength > remaining {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Chunk exceeds the remaining upload length",
+				"details": gin.H{
+					"remaining_bytes": remaining,
+				},
+			},
+		})
+		return
+	}
+
+	// Every chunk but the last must be large enough that an upload of the
+	// maximum size stays within the storage compose limit
+	if minChunk := h.minChunkSize(); c.Request.ContentLength < remaining && c.Request.ContentLength < minChunk {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Chunk is smaller than the minimum chunk size",
+				"details": gin.H{
+					"min_chunk_bytes": minChunk,
+				},
+			},
+		})
+		return
+	}
+
+	lease, err := h.uploadRepo.Claim(c.Request.Context(), upload.UploadID, offset, uploadLease)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating upload",
+			},
+		})
+		return
+	}
+
+	if lease == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Upload is being written by another request",
+			},
+		})
+		return
+	}
+
+	// Keep going if the client disconnects: whatever arrived is committed
+	// and the offset advanced, so the client can resume from there
+	ctx := context.WithoutCancel(c.Request.Context())
+	body := io.LimitReader(c.Request.Body, remaining)
+	written, writeErr := h.firebaseService.AppendObjectChunk(ctx, upload.ObjectKey, offset, upload.ContentType, upload.UserID, body)
+
+	updated, err := h.uploadRepo.Release(ctx, upload.UploadID, offset, *lease, written)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating upload",
+			},
+		})
+		return
+	}
+
+	// The lease ran out and another request took the upload over, so this
+	// chunk does not count; the client should query the offset and resume
+	if updated == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Upload lease expired before the chunk was committed",
+			},
+		})
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(updated.UploadOffset, 10))
+	c.Header("Upload-Expires", updated.ExpiresAt.Format(http.TimeFormat))
+
+	if errors.Is(writeErr, services.ErrTooManyChunks) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Upload has reached the maximum number of chunks",
+				"details": gin.H{
+					"max_chunks": services.MaxObjectComponents,
+				},
+			},
+		})
+		return
+	}
+
+	if errors.Is(writeErr, services.ErrChunkOffsetMismatch) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Stored upload data does not match the upload offset",
+				"details": gin.H{
+					"upload_offset": updated.UploadOffset,
+				},
+			},
+		})
+		return
+	}
+
+	if writeErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error storing upload chunk",
+				"details": gin.H{
+					"upload_offset": updated.UploadOffset,
+				},
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteResumable abandons an incomplete upload
+// DELETE /api/v1/uploads/:id
+func (h *UploadHandler) DeleteResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	upload, ok := h.loadOwnedUpload(c)
+	if !ok {
+		return
+	}
+
+	if upload.IsComplete() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Completed uploads cannot be terminated",
+			},
+		})
+		return
+	}
+
+	// The partial object and any chunks left over from an interrupted compose
+	if upload.UploadOffset > 0 {
+		if err := h.firebaseService.DeleteObjectsWithPrefix(c.Request.Context(), upload.ObjectKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error deleting uploaded data",
+				},
+			})
+			return
+		}
+	}
+
+	if err := h.uploadRepo.Delete(c.Request.Context(), upload.UploadID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error deleting upload",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// minChunkSize returns the smallest chunk accepted before the final one.
+// Each chunk becomes one storage component, so chunks must be large enough
+// for an upload of the maximum size to fit in services.MaxObjectComponents,
+// with some headroom for chunks cut short by a dropped connection.
+func (h *UploadHandler) minChunkSize() int64 {
+	minChunk := h.maxUploadSize / (services.MaxObjectComponents / 2)
+	if minChunk < minUploadChunk {
+		return minUploadChunk
+	}
+	return minChunk
+}
+
+// loadOwnedUpload fetches the upload named in the path and checks that it
+// belongs to the current user and has not expired. It writes the error
+// response and returns false if the request cannot proceed.
+func (h *UploadHandler) loadOwnedUpload(c *gin.Context) (*models.Upload, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": gin.H{
+				"code":    "AUTHENTICATION_ERROR",
+				"message": "Authentication required",
+			},
+		})
+		return nil, false
+	}
+
+	uploadID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid upload ID",
+			},
+		})
+		return nil, false
+	}
+
+	upload, err := h.uploadRepo.GetByID(c.Request.Context(), uploadID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching upload",
+			},
+		})
+		return nil, false
+	}
+
+	// Uploads of other users are reported as missing
+	if upload == nil || upload.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Upload not found",
+			},
+		})
+		return nil, false
+	}
+
+	if !upload.IsComplete() && time.Now().After(upload.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Upload has expired",
+			},
+		})
+		return nil, false
+	}
+
+	return upload, true
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header
+// ("key base64value,key2 base64value2") into a map
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 {
+			continue
+		}
+		value := ""
+		if len(parts) > 1 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				continue
+			}
+			value = string(decoded)
+		}
+		metadata[parts[0]] = value
+	}
+	return metadata
+}
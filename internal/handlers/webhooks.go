@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// WebhookHandler handles outbound webhook subscription requests
+type WebhookHandler struct {
+	webhookRepo *repository.WebhookRepository
+	audit       *AuditLogger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookRepo *repository.WebhookRepository, audit *AuditLogger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+		audit:       audit,
+	}
+}
+
+// Create subscribes a URL to memo events
+// POST /api/v1/webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	secret := ""
+	if req.Secret != nil {
+		secret = *req.Secret
+	} else {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error generating webhook secret",
+				},
+			})
+			return
+		}
+		secret = generated
+	}
+
+	webhook := &models.WebhookSubscription{
+		UserID:      userID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.Events,
+		Secret:      secret,
+		Active:      req.Active == nil || *req.Active,
+	}
+	if !validateWebhook(c, webhook) {
+		return
+	}
+
+	audit := h.audit.Entry(c, models.AuditActionWebhookCreate, models.AuditTargetWebhook, "", nil, nil, nil)
+	if err := h.webhookRepo.Create(c.Request.Context(), webhook, audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error creating webhook",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateWebhookResponse{
+		WebhookSubscription: *webhook,
+		Secret:              webhook.Secret,
+	})
+}
+
+// List retrieves the current user's webhooks
+// GET /api/v1/webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookRepo.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching webhooks",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebhooksListResponse{Webhooks: webhooks})
+}
+
+// GetByID retrieves one of the current user's webhooks
+// GET /api/v1/webhooks/:id
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	webhook, ok := h.loadOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Update changes a webhook's URL, description, events or active state
+// PUT /api/v1/webhooks/:id
+func (h *WebhookHandler) Update(c *gin.Context) {
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeInvalidBody(c, err)
+		return
+	}
+
+	webhook, ok := h.loadOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Description != nil {
+		webhook.Description = req.Description
+	}
+	if req.Events != nil {
+		webhook.EventTypes = req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if !validateWebhook(c, webhook) {
+		return
+	}
+
+	audit := h.audit.Entry(c, models.AuditActionWebhookUpdate, models.AuditTargetWebhook, webhook.SubscriptionID.String(), nil, nil, nil)
+	if err := h.webhookRepo.Update(c.Request.Context(), webhook, audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error updating webhook",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Delete deletes a webhook and its delivery log
+// DELETE /api/v1/webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	webhook, ok := h.loadOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	audit := h.audit.Entry(c, models.AuditActionWebhookDelete, models.AuditTargetWebhook, webhook.SubscriptionID.String(), nil, nil, nil)
+	if err := h.webhookRepo.Delete(c.Request.Context(), webhook.SubscriptionID, audit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error deleting webhook",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries retrieves a webhook's delivery log, newest first
+// GET /api/v1/webhooks/:id/deliveries
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	webhook, ok := h.loadOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		writeFilterError(c, "status", "Must be one of pending, succeeded, failed")
+		return
+	}
+
+	deliveries, total, err := h.webhookRepo.ListDeliveries(c.Request.Context(), webhook.SubscriptionID, status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching webhook deliveries",
+			},
+		})
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, models.WebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Pagination: models.PaginationResponse{
+			CurrentPage:  page,
+			TotalPages:   totalPages,
+			TotalItems:   total,
+			ItemsPerPage: limit,
+			HasNext:      page < totalPages,
+			HasPrevious:  page > 1,
+		},
+	})
+}
+
+// GetDelivery retrieves a delivery with its payload and every attempt made
+// GET /api/v1/webhooks/:id/deliveries/:delivery_id
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	delivery, ok := h.loadOwnDelivery(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.webhookRepo.ListAttempts(c.Request.Context(), delivery.DeliveryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching webhook delivery",
+			},
+		})
+		return
+	}
+	delivery.AttemptLog = attempts
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver queues a succeeded or failed delivery to be sent again with the
+// same payload and a fresh set of retries. Pending deliveries are refused, as
+// they are already queued.
+// POST /api/v1/webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, ok := h.loadOwnDelivery(c)
+	if !ok {
+		return
+	}
+
+	queued, err := h.webhookRepo.Redeliver(c.Request.Context(), delivery.DeliveryID)
+	if err != nil {
+		log.Printf("Error redelivering %s: %v", delivery.DeliveryID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error redelivering webhook",
+			},
+		})
+		return
+	}
+
+	if !queued {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CONFLICT",
+				"message": "Delivery is already pending",
+			},
+		})
+		return
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// loadOwnWebhook parses the webhook ID and fetches the webhook if it belongs
+// to the current user. Other users' webhooks are reported as not found. It
+// writes the error response and returns false on failure.
+func (h *WebhookHandler) loadOwnWebhook(c *gin.Context) (*models.WebhookSubscription, bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid webhook ID",
+			},
+		})
+		return nil, false
+	}
+
+	webhook, err := h.webhookRepo.GetByID(c.Request.Context(), subscriptionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching webhook",
+			},
+		})
+		return nil, false
+	}
+
+	if webhook == nil || webhook.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Webhook not found",
+			},
+		})
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// loadOwnDelivery fetches a delivery of one of the current user's webhooks.
+// It writes the error response and returns false on failure.
+func (h *WebhookHandler) loadOwnDelivery(c *gin.Context) (*models.WebhookDelivery, bool) {
+	webhook, ok := h.loadOwnWebhook(c)
+	if !ok {
+		return nil, false
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid delivery ID",
+			},
+		})
+		return nil, false
+	}
+
+	delivery, err := h.webhookRepo.GetDelivery(c.Request.Context(), deliveryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Error fetching webhook delivery",
+			},
+		})
+		return nil, false
+	}
+
+	if delivery == nil || delivery.SubscriptionID != webhook.SubscriptionID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "NOT_FOUND",
+				"message": "Webhook delivery not found",
+			},
+		})
+		return nil, false
+	}
+
+	return delivery, true
+}
+
+// validateWebhook normalizes and checks a webhook before it is stored. It
+// writes a validation error and returns false if it is invalid.
+func validateWebhook(c *gin.Context, webhook *models.WebhookSubscription) bool {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	if !isWebhookURL(webhook.URL) {
+		writeFilterError(c, "url", "Must be an absolute http or https URL on a public host")
+		return false
+	}
+
+	if webhook.Description != nil {
+		if description := strings.TrimSpace(*webhook.Description); description == "" {
+			webhook.Description = nil
+		} else {
+			webhook.Description = &description
+		}
+	}
+
+	events := []string{}
+	for _, event := range webhook.EventTypes {
+		if !utils.ContainsString(models.WebhookEventTypes, event) {
+			writeFilterError(c, "events", "Must contain only "+strings.Join(models.WebhookEventTypes, ", "))
+			return false
+		}
+		if !utils.ContainsString(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		writeFilterError(c, "events", "Must subscribe to at least one event")
+		return false
+	}
+	webhook.EventTypes = events
+
+	return true
+}
+
+// generateWebhookSecret returns a random signing secret
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
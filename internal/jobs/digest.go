@@ -0,0 +1,219 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+const (
+	// digestMemoLimit caps the memos listed in a digest
+	digestMemoLimit = 10
+
+	// highPriorityCategory is the category counted as high priority
+	highPriorityCategory = "hazard"
+
+	// digestParkLookback is how far back a user's own memos are used to
+	// pick their parks when they have not chosen any
+	digestParkLookback = 90 * 24 * time.Hour
+
+	// digestSchedulerInterval is how often due digests are queued
+	digestSchedulerInterval = 5 * time.Minute
+
+	// digestClaimBatch is how many digests are queued per query
+	digestClaimBatch = 100
+)
+
+// DigestJob emails a user a summary of the memos created in their parks
+// during a digest period. Nothing is sent for a period without memos.
+type DigestJob struct {
+	userRepo  *repository.UserRepository
+	prefRepo  *repository.NotificationPreferenceRepository
+	memoRepo  *repository.MemoRepository
+	mailer    services.Mailer
+	templates *services.EmailTemplates
+}
+
+// NewDigestJob creates a new digest job handler
+func NewDigestJob(
+	userRepo *repository.UserRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+	memoRepo *repository.MemoRepository,
+	mailer services.Mailer,
+	templates *services.EmailTemplates,
+) *DigestJob {
+	return &DigestJob{
+		userRepo:  userRepo,
+		prefRepo:  prefRepo,
+		memoRepo:  memoRepo,
+		mailer:    mailer,
+		templates: templates,
+	}
+}
+
+// Handle processes a notification.digest job
+func (j *DigestJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.DigestJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	if !j.mailer.Enabled() {
+		log.Printf("Email is not configured; skipping digest for user %s", payload.UserID)
+		return nil
+	}
+
+	user, err := j.userRepo.GetByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	prefs, err := j.prefRepo.Get(ctx, user.UserID)
+	if err != nil {
+		return err
+	}
+	if prefs.DigestFrequency == models.DigestOff {
+		// Turned off since the digest was queued
+		return nil
+	}
+
+	parks := []string(prefs.DigestParks)
+	if len(parks) == 0 {
+		parks, err = j.memoRepo.ListUserParks(ctx, user.UserID, payload.PeriodEnd.Add(-digestParkLookback))
+		if err != nil {
+			return err
+		}
+	}
+	if len(parks) == 0 {
+		return nil
+	}
+
+	filter := &models.MemoFilter{
+		ParkNames: parks,
+		StartDate: &payload.PeriodStart,
+		EndDate:   &payload.PeriodEnd,
+		Sort:      models.MemoSortRecent,
+	}
+	memos, total, err := j.memoRepo.Query(ctx, filter, 1, digestMemoLimit)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		// Nothing to send, but the period is covered
+		return j.prefRepo.SetLastDigest(ctx, user.UserID, payload.PeriodEnd)
+	}
+
+	highPriorityFilter := *filter
+	highPriorityFilter.Category = highPriorityCategory
+	_, highPriority, err := j.memoRepo.Query(ctx, &highPriorityFilter, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("%d new %s in your parks", total, plural(total, "memo", "memos"))
+	if highPriority > 0 {
+		summary += fmt.Sprintf(", %d high priority", highPriority)
+	}
+
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	data := services.DigestEmailData{
+		UserName: user.DisplayName,
+		Summary:  summary,
+		Period:   "Since " + payload.PeriodStart.In(loc).Format("Mon, Jan 2 at 3:04 PM"),
+		Parks:    parks,
+		More:     total - len(memos),
+	}
+	for _, memo := range memos {
+		item := services.DigestEmailMemo{
+			Title:        "Untitled memo",
+			UserName:     memo.UserName,
+			Excerpt:      excerpt(memo.Text),
+			HighPriority: memo.Category != nil && *memo.Category == highPriorityCategory,
+			CreatedAt:    memo.CreatedAt.In(loc),
+		}
+		if memo.Title != nil && *memo.Title != "" {
+			item.Title = *memo.Title
+		}
+		if memo.ParkName != nil {
+			item.ParkName = *memo.ParkName
+		}
+		data.Memos = append(data.Memos, item)
+	}
+
+	msg, err := j.templates.Render("digest", user.Email, summary, data)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	if err := j.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	if err := j.prefRepo.SetLastDigest(ctx, user.UserID, payload.PeriodEnd); err != nil {
+		// The digest was sent; retrying just to record it would send it twice
+		log.Printf("Error recording digest for user %s: %v", user.UserID, err)
+	}
+
+	return nil
+}
+
+// DigestScheduler periodically queues the digests that are due
+type DigestScheduler struct {
+	prefRepo *repository.NotificationPreferenceRepository
+}
+
+// NewDigestScheduler creates a new digest scheduler
+func NewDigestScheduler(prefRepo *repository.NotificationPreferenceRepository) *DigestScheduler {
+	return &DigestScheduler{prefRepo: prefRepo}
+}
+
+// Start launches the scheduler goroutine. It stops when ctx is cancelled.
+func (s *DigestScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(digestSchedulerInterval)
+		defer ticker.Stop()
+
+		for {
+			s.queueDue(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// queueDue queues every digest due now
+func (s *DigestScheduler) queueDue(ctx context.Context) {
+	for {
+		queued, err := s.prefRepo.ClaimDueDigests(ctx, time.Now().UTC(), digestClaimBatch)
+		if err != nil {
+			log.Printf("Error queuing digests: %v", err)
+			return
+		}
+		if queued < digestClaimBatch {
+			return
+		}
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
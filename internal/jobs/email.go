@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// EmailNotificationJob emails a notification to a user right away, unless
+// they turned immediate emails off
+type EmailNotificationJob struct {
+	userRepo  *repository.UserRepository
+	prefRepo  *repository.NotificationPreferenceRepository
+	mailer    services.Mailer
+	templates *services.EmailTemplates
+}
+
+// NewEmailNotificationJob creates a new notification email job handler
+func NewEmailNotificationJob(
+	userRepo *repository.UserRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+	mailer services.Mailer,
+	templates *services.EmailTemplates,
+) *EmailNotificationJob {
+	return &EmailNotificationJob{
+		userRepo:  userRepo,
+		prefRepo:  prefRepo,
+		mailer:    mailer,
+		templates: templates,
+	}
+}
+
+// Handle processes a notification.email job
+func (j *EmailNotificationJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.EmailJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	if !j.mailer.Enabled() {
+		log.Printf("Email is not configured; skipping email to user %s", payload.UserID)
+		return nil
+	}
+
+	user, err := j.userRepo.GetByID(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	prefs, err := j.prefRepo.Get(ctx, user.UserID)
+	if err != nil {
+		return err
+	}
+	if !prefs.EmailImmediate {
+		return nil
+	}
+
+	msg, err := j.templates.Render("notification", user.Email, payload.Title, services.NotificationEmailData{
+		UserName: user.DisplayName,
+		Title:    payload.Title,
+		Body:     payload.Body,
+	})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	return j.mailer.Send(ctx, msg)
+}
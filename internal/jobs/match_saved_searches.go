@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+const (
+	// notificationExcerptLength caps the memo text quoted in a notification
+	notificationExcerptLength = 140
+
+	// notificationTitleLength is the longest title a notification can store
+	notificationTitleLength = 200
+)
+
+// MatchSavedSearchesJob checks a memo against other users' saved searches and
+// notifies the owners of those it matches. Each search notifies about a memo
+// once, however often the memo is edited or the job retried.
+type MatchSavedSearchesJob struct {
+	memoRepo        *repository.MemoRepository
+	savedSearchRepo *repository.SavedSearchRepository
+}
+
+// NewMatchSavedSearchesJob creates a new saved search matching job handler
+func NewMatchSavedSearchesJob(
+	memoRepo *repository.MemoRepository,
+	savedSearchRepo *repository.SavedSearchRepository,
+) *MatchSavedSearchesJob {
+	return &MatchSavedSearchesJob{
+		memoRepo:        memoRepo,
+		savedSearchRepo: savedSearchRepo,
+	}
+}
+
+// Handle processes a memo.match_saved_searches job. A search that fails
+// doesn't hold up the others; the job is retried for it afterwards.
+func (j *MatchSavedSearchesJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.MemoJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		// Deleted since the job was queued
+		return nil
+	}
+
+	searches, err := j.savedSearchRepo.ListOthers(ctx, memo.UserID)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for i := range searches {
+		if err := j.match(ctx, &searches[i], memo); err != nil {
+			log.Printf("Error matching saved search %s against memo %s: %v", searches[i].SearchID, memoID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// match notifies a search's owner if the memo matches it and it hasn't
+// notified about the memo before
+func (j *MatchSavedSearchesJob) match(ctx context.Context, search *models.SavedSearch, memo *models.Memo) error {
+	notified, err := j.savedSearchRepo.HasMatch(ctx, search.SearchID, memo.MemoID)
+	if err != nil || notified {
+		return err
+	}
+
+	matches, err := j.memoRepo.Matches(ctx, memo.MemoID, &search.Filter)
+	if err != nil || !matches {
+		return err
+	}
+
+	// The match is recorded with the notification, so a retry can't notify twice
+	_, err = j.savedSearchRepo.RecordMatch(ctx, search.SearchID, memo.MemoID, matchDelivery(search, memo))
+	return err
+}
+
+// matchDelivery builds the notification of a match on each of the search's
+// channels
+func matchDelivery(search *models.SavedSearch, memo *models.Memo) *models.NotificationDelivery {
+	const titleFormat = "New memo matches \"%s\""
+	name := truncateText(search.Name, notificationTitleLength-len([]rune(titleFormat))+2)
+	title := fmt.Sprintf(titleFormat, name)
+	body := matchSummary(memo)
+
+	delivery := &models.NotificationDelivery{}
+	if search.NotifyInApp {
+		delivery.Notification = &models.Notification{
+			UserID:   search.UserID,
+			Type:     models.NotificationTypeSavedSearchMatch,
+			Title:    title,
+			Body:     body,
+			MemoID:   &memo.MemoID,
+			SearchID: &search.SearchID,
+		}
+	}
+
+	if search.WebhookURL != nil {
+		delivery.Jobs = append(delivery.Jobs, models.QueuedJob{
+			JobType: models.JobTypeSearchWebhook,
+			Payload: models.SavedSearchMatchPayload{
+				SearchID: search.SearchID.String(),
+				MemoID:   memo.MemoID.String(),
+			},
+		})
+	}
+
+	if search.NotifyEmail {
+		delivery.Jobs = append(delivery.Jobs, models.QueuedJob{
+			JobType: models.JobTypeEmail,
+			Payload: models.EmailJobPayload{UserID: search.UserID, Title: title, Body: body},
+		})
+	}
+
+	if search.NotifyPush {
+		delivery.Jobs = append(delivery.Jobs, models.QueuedJob{
+			JobType: models.JobTypePush,
+			Payload: models.PushJobPayload{
+				UserID: search.UserID,
+				Type:   models.NotificationTypeSavedSearchMatch,
+				Title:  title,
+				Body:   body,
+				Data: map[string]string{
+					"memo_id":   memo.MemoID.String(),
+					"search_id": search.SearchID.String(),
+				},
+			},
+		})
+	}
+
+	return delivery
+}
+
+// matchSummary describes a memo in one line: who recorded it, where, and the
+// start of its title or text
+func matchSummary(memo *models.Memo) string {
+	var b strings.Builder
+	b.WriteString(memo.UserName)
+	if memo.ParkName != nil && *memo.ParkName != "" {
+		b.WriteString(" in ")
+		b.WriteString(*memo.ParkName)
+	}
+
+	text := memo.Text
+	if memo.Title != nil && *memo.Title != "" {
+		text = *memo.Title
+	}
+	if text == "" {
+		return b.String()
+	}
+
+	b.WriteString(": ")
+	b.WriteString(excerpt(text))
+	return b.String()
+}
+
+// excerpt shortens text to notificationExcerptLength characters
+func excerpt(text string) string {
+	return truncateText(text, notificationExcerptLength)
+}
+
+// truncateText shortens text to at most limit characters, ending it with an
+// ellipsis if anything was cut
+func truncateText(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return strings.TrimSpace(string(runes[:limit-1])) + "…"
+	}
+	return text
+}
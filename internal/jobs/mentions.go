@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// MentionsJob resolves the @mentions in a memo's title and text against
+// users' display names, records them and notifies each newly mentioned user
+// in-app, by email and by push. Mentions an edit removed are hidden but
+// kept, so adding them back does not notify again.
+type MentionsJob struct {
+	memoRepo    *repository.MemoRepository
+	userRepo    *repository.UserRepository
+	mentionRepo *repository.MentionRepository
+}
+
+// NewMentionsJob creates a new mention job handler
+func NewMentionsJob(
+	memoRepo *repository.MemoRepository,
+	userRepo *repository.UserRepository,
+	mentionRepo *repository.MentionRepository,
+) *MentionsJob {
+	return &MentionsJob{
+		memoRepo:    memoRepo,
+		userRepo:    userRepo,
+		mentionRepo: mentionRepo,
+	}
+}
+
+// Handle processes a memo.mentions job
+func (j *MentionsJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.MemoJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		// Deleted since the job was queued
+		return nil
+	}
+
+	text := memo.Text
+	if memo.Title != nil && *memo.Title != "" {
+		text = *memo.Title + "\n" + text
+	}
+
+	candidates := services.ParseMentions(text)
+	mentioned := []services.ResolvedMention{}
+	if len(candidates) > 0 {
+		keys := []string{}
+		for _, candidate := range candidates {
+			keys = append(keys, candidate.Keys()...)
+		}
+		users, err := j.userRepo.ListByMentionKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		mentioned = services.ResolveMentions(text, candidates, users)
+	}
+
+	existing, err := j.mentionRepo.ListUserIDsByMemo(ctx, memoID)
+	if err != nil {
+		return err
+	}
+
+	userIDs := []string{}
+	for _, mention := range mentioned {
+		// Crews talk about themselves in the third person; don't notify them
+		if mention.UserID == memo.UserID {
+			continue
+		}
+		userIDs = append(userIDs, mention.UserID)
+		if utils.ContainsString(existing, mention.UserID) {
+			continue
+		}
+
+		// The mention is recorded with its notification, so a retry
+		// can't notify twice
+		record := &models.Mention{
+			MemoID:      memoID,
+			UserID:      mention.UserID,
+			MentionedBy: memo.UserID,
+			Handle:      mention.Handle,
+			Context:     mention.Context,
+		}
+		if _, err := j.mentionRepo.Create(ctx, record, mentionDelivery(memo, mention)); err != nil {
+			return err
+		}
+	}
+
+	return j.mentionRepo.RemoveExcept(ctx, memoID, userIDs)
+}
+
+// mentionDelivery builds the notification of a mention on every channel. The
+// email and push jobs apply the user's preferences.
+func mentionDelivery(memo *models.Memo, mention services.ResolvedMention) *models.NotificationDelivery {
+	title := fmt.Sprintf("%s mentioned you", memo.UserName)
+	if memo.ParkName != nil && *memo.ParkName != "" {
+		title += " in " + *memo.ParkName
+	}
+	title = truncateText(title, notificationTitleLength)
+	body := excerpt(mention.Context)
+
+	return &models.NotificationDelivery{
+		Notification: &models.Notification{
+			UserID: mention.UserID,
+			Type:   models.NotificationTypeMention,
+			Title:  title,
+			Body:   body,
+			MemoID: &memo.MemoID,
+		},
+		Jobs: []models.QueuedJob{
+			{
+				JobType: models.JobTypeEmail,
+				Payload: models.EmailJobPayload{UserID: mention.UserID, Title: title, Body: body},
+			},
+			{
+				JobType: models.JobTypePush,
+				Payload: models.PushJobPayload{
+					UserID: mention.UserID,
+					Type:   models.NotificationTypeMention,
+					Title:  title,
+					Body:   body,
+					Data:   map[string]string{"memo_id": memo.MemoID.String()},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// PushNotificationJob pushes a notification to each of a user's registered
+// devices, unless they turned push off. Devices whose token the provider
+// rejects are removed.
+type PushNotificationJob struct {
+	deviceRepo *repository.PushDeviceRepository
+	prefRepo   *repository.NotificationPreferenceRepository
+	sender     services.PushSender
+}
+
+// NewPushNotificationJob creates a new push notification job handler
+func NewPushNotificationJob(
+	deviceRepo *repository.PushDeviceRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+	sender services.PushSender,
+) *PushNotificationJob {
+	return &PushNotificationJob{
+		deviceRepo: deviceRepo,
+		prefRepo:   prefRepo,
+		sender:     sender,
+	}
+}
+
+// Handle processes a notification.push job
+func (j *PushNotificationJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.PushJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	prefs, err := j.prefRepo.Get(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+	if !prefs.PushEnabled {
+		return nil
+	}
+
+	devices, err := j.deviceRepo.ListByUser(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	msg := &services.PushMessage{
+		Type:  payload.Type,
+		Title: payload.Title,
+		Body:  payload.Body,
+		Data:  payload.Data,
+	}
+
+	delivered, err := pushToDevices(ctx, j.sender, devices, msg, j.deviceRepo.DeleteByToken)
+	if delivered == 0 {
+		return err
+	}
+	return nil
+}
+
+// pushToDevices sends msg to each device the sender supports and calls remove
+// for those whose token was rejected. It returns how many devices got the
+// notification and the last error. A retry sends to every device again, so
+// callers should only retry when none did; other failures are just logged.
+func pushToDevices(
+	ctx context.Context,
+	sender services.PushSender,
+	devices []models.PushDevice,
+	msg *services.PushMessage,
+	remove func(ctx context.Context, provider, token string) error,
+) (int, error) {
+	var delivered int
+	var lastErr error
+	for _, device := range devices {
+		if !sender.Supports(device.Provider) {
+			continue
+		}
+
+		err := sender.Send(ctx, device.Provider, device.Token, msg)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, services.ErrPushTokenInvalid):
+			log.Printf("Removing %s device %s: token no longer valid", device.Provider, device.DeviceID)
+			if err := remove(ctx, device.Provider, device.Token); err != nil {
+				return delivered, err
+			}
+		default:
+			log.Printf("Error pushing to device %s: %v", device.DeviceID, err)
+			lastErr = err
+		}
+	}
+
+	return delivered, lastErr
+}
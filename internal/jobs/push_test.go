@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+func TestPushToDevicesRemovesInvalidTokens(t *testing.T) {
+	sender := &services.MemoryPushSender{}
+	sender.Invalidate("uninstalled-token")
+
+	devices := []models.PushDevice{
+		{DeviceID: uuid.New(), Provider: models.PushProviderFCM, Token: "uninstalled-token"},
+		{DeviceID: uuid.New(), Provider: models.PushProviderFCM, Token: "current-token"},
+	}
+	msg := &services.PushMessage{Type: models.NotificationTypeMention, Title: "Alex mentioned you"}
+
+	var removed []string
+	remove := func(ctx context.Context, provider, token string) error {
+		removed = append(removed, token)
+		return nil
+	}
+
+	delivered, err := pushToDevices(context.Background(), sender, devices, msg, remove)
+	if err != nil {
+		t.Fatalf("pushToDevices: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("delivered to %d devices, want 1", delivered)
+	}
+	if len(removed) != 1 || removed[0] != "uninstalled-token" {
+		t.Errorf("removed %v, want only the invalidated token", removed)
+	}
+
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].Token != "current-token" || sent[0].Message.Title != msg.Title {
+		t.Errorf("sent %+v, want one push to current-token", sent)
+	}
+}
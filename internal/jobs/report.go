@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+const (
+	// maxReportMemos caps the memos in one report, to keep the PDF printable
+	maxReportMemos = 2000
+
+	// reportGroups caps the rows of the per-park and per-category tables
+	reportGroups = 100
+)
+
+// ReportJob generates a PDF field report, stores it and notifies its owner
+type ReportJob struct {
+	reportRepo       *repository.ReportRepository
+	memoRepo         *repository.MemoRepository
+	statsRepo        *repository.StatsRepository
+	userRepo         *repository.UserRepository
+	notificationRepo *repository.NotificationRepository
+	firebaseService  *services.FirebaseService
+}
+
+// NewReportJob creates a new report job handler
+func NewReportJob(
+	reportRepo *repository.ReportRepository,
+	memoRepo *repository.MemoRepository,
+	statsRepo *repository.StatsRepository,
+	userRepo *repository.UserRepository,
+	notificationRepo *repository.NotificationRepository,
+	firebaseService *services.FirebaseService,
+) *ReportJob {
+	return &ReportJob{
+		reportRepo:       reportRepo,
+		memoRepo:         memoRepo,
+		statsRepo:        statsRepo,
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		firebaseService:  firebaseService,
+	}
+}
+
+// Handle processes a report.generate job
+func (j *ReportJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.ReportJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	reportID, err := uuid.Parse(payload.ReportID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid report ID: %v", err))
+	}
+
+	report, err := j.reportRepo.GetByID(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if report == nil || report.Status == models.ReportStatusCompleted {
+		// Deleted since the job was queued, or already generated
+		return nil
+	}
+
+	if err := j.generate(ctx, report); err != nil {
+		var permanent *permanentError
+		if errors.As(err, &permanent) || job.IsLastAttempt() {
+			_ = j.reportRepo.Fail(ctx, reportID, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// generate renders the report, uploads it and marks it completed
+func (j *ReportJob) generate(ctx context.Context, report *models.Report) error {
+	if err := j.reportRepo.SetProcessing(ctx, report.ReportID); err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(report.Timezone)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid time zone: %v", err))
+	}
+	filter := &report.Filter
+
+	summary, err := j.statsRepo.Summary(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if summary.TotalMemos > maxReportMemos {
+		return Permanent(fmt.Errorf("report covers %d memos; narrow the filter to at most %d", summary.TotalMemos, maxReportMemos))
+	}
+
+	parks, err := j.statsRepo.Breakdown(ctx, filter, models.StatsGroupPark, reportGroups)
+	if err != nil {
+		return err
+	}
+	categories, err := j.statsRepo.Breakdown(ctx, filter, models.StatsGroupCategory, reportGroups)
+	if err != nil {
+		return err
+	}
+
+	memos := []models.MemoExportRow{}
+	err = j.memoRepo.Export(ctx, filter, maxReportMemos, func(memo *models.MemoExportRow) error {
+		memos = append(memos, *memo)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Reports read in the order memos were recorded
+	for i, k := 0, len(memos)-1; i < k; i, k = i+1, k-1 {
+		memos[i], memos[k] = memos[k], memos[i]
+	}
+
+	preparedBy := ""
+	owner, err := j.userRepo.GetByID(ctx, report.UserID)
+	if err != nil {
+		return err
+	}
+	if owner != nil {
+		preparedBy = owner.DisplayName
+	}
+
+	pdf, err := services.RenderReport(&services.ReportContent{
+		Title:       report.Title,
+		Filter:      report.Filter,
+		Location:    loc,
+		PreparedBy:  preparedBy,
+		GeneratedAt: time.Now(),
+		Summary:     summary,
+		Parks:       parks,
+		Categories:  categories,
+		Memos:       memos,
+	})
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("reports/%s/%s.pdf", report.UserID, report.ReportID)
+	if err := j.firebaseService.UploadObject(ctx, key, "application/pdf", bytes.NewReader(pdf)); err != nil {
+		return err
+	}
+
+	if err := j.reportRepo.Complete(ctx, report.ReportID, key, len(memos), int64(len(pdf))); err != nil {
+		return err
+	}
+
+	notification := &models.Notification{
+		UserID: report.UserID,
+		Type:   models.NotificationTypeReportReady,
+		Title:  "Your report is ready",
+		Body:   fmt.Sprintf("%s (%d memos)", report.Title, len(memos)),
+	}
+	if err := j.notificationRepo.Create(ctx, notification); err != nil {
+		// The report is done; don't generate it again over a notification
+		log.Printf("Error notifying report %s: %v", report.ReportID, err)
+	}
+
+	return nil
+}
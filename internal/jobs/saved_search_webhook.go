@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// SavedSearchWebhookJob POSTs a saved search match to the search's webhook URL,
+// signed with the search's webhook secret the same way as memo event
+// webhooks. Failed deliveries are retried by the queue unless the receiver
+// rejects the request outright.
+type SavedSearchWebhookJob struct {
+	memoRepo        *repository.MemoRepository
+	savedSearchRepo *repository.SavedSearchRepository
+	client          *http.Client
+}
+
+// NewSavedSearchWebhookJob creates a new saved search webhook job handler
+func NewSavedSearchWebhookJob(
+	memoRepo *repository.MemoRepository,
+	savedSearchRepo *repository.SavedSearchRepository,
+) *SavedSearchWebhookJob {
+	return &SavedSearchWebhookJob{
+		memoRepo:        memoRepo,
+		savedSearchRepo: savedSearchRepo,
+		client:          newWebhookClient(),
+	}
+}
+
+// Handle processes a saved_search.webhook job
+func (j *SavedSearchWebhookJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.SavedSearchMatchPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	searchID, err := uuid.Parse(payload.SearchID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid saved search ID: %v", err))
+	}
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	search, err := j.savedSearchRepo.GetByID(ctx, searchID)
+	if err != nil {
+		return err
+	}
+	if search == nil || search.WebhookURL == nil {
+		// Deleted, or the webhook was removed since the match
+		return nil
+	}
+	if search.WebhookSecret == nil {
+		return Permanent(fmt.Errorf("saved search %s has no webhook secret", search.SearchID))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(models.SavedSearchWebhookEvent{
+		Event: models.NotificationTypeSavedSearchMatch,
+		SavedSearch: models.SavedSearchInfo{
+			SearchID: search.SearchID,
+			Name:     search.Name,
+		},
+		Memo:   memo,
+		SentAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return Permanent(fmt.Errorf("error encoding webhook event: %v", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *search.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("error creating webhook request: %v", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TrailMemo-Webhook/1.0")
+	req.Header.Set("X-TrailMemo-Event", models.NotificationTypeSavedSearchMatch)
+	req.Header.Set("X-TrailMemo-Signature", WebhookSignature(*search.WebhookSecret, time.Now().Unix(), body))
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error delivering webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return Permanent(fmt.Errorf("webhook rejected with status %d", resp.StatusCode))
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// SuggestTagsJob matches a memo's text against the tag vocabulary and stores
+// the tags and category it suggests
+type SuggestTagsJob struct {
+	memoRepo       *repository.MemoRepository
+	suggestionRepo *repository.TagSuggestionRepository
+	tagger         *services.Tagger
+}
+
+// NewSuggestTagsJob creates a new tag suggestion job handler
+func NewSuggestTagsJob(
+	memoRepo *repository.MemoRepository,
+	suggestionRepo *repository.TagSuggestionRepository,
+	tagger *services.Tagger,
+) *SuggestTagsJob {
+	return &SuggestTagsJob{
+		memoRepo:       memoRepo,
+		suggestionRepo: suggestionRepo,
+		tagger:         tagger,
+	}
+}
+
+// Handle processes a memo.suggest_tags job
+func (j *SuggestTagsJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.MemoJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil || memo.Text == "" {
+		// Deleted, or still waiting for its transcript
+		return nil
+	}
+
+	result := j.tagger.Suggest(memo.Text)
+
+	// Only suggest what the memo doesn't have yet
+	suggestion := &models.TagSuggestion{
+		MemoID:   memoID,
+		Tags:     pq.StringArray{},
+		Keywords: result.Keywords,
+	}
+	for _, tag := range result.Tags {
+		if !utils.ContainsString(memo.Tags, tag) {
+			suggestion.Tags = append(suggestion.Tags, tag)
+		}
+	}
+	if result.Category != "" && (memo.Category == nil || *memo.Category != result.Category) {
+		suggestion.Category = &result.Category
+	}
+
+	return j.suggestionRepo.Upsert(ctx, suggestion)
+}
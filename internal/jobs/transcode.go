@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// TranscodeJob converts a memo's original recording into compact,
+// loudness-normalized variants and records them on the memo
+type TranscodeJob struct {
+	memoRepo        *repository.MemoRepository
+	variantRepo     *repository.AudioVariantRepository
+	firebaseService *services.FirebaseService
+	transcoder      *services.Transcoder
+}
+
+// NewTranscodeJob creates a new transcode job handler
+func NewTranscodeJob(
+	memoRepo *repository.MemoRepository,
+	variantRepo *repository.AudioVariantRepository,
+	firebaseService *services.FirebaseService,
+	transcoder *services.Transcoder,
+) *TranscodeJob {
+	return &TranscodeJob{
+		memoRepo:        memoRepo,
+		variantRepo:     variantRepo,
+		firebaseService: firebaseService,
+		transcoder:      transcoder,
+	}
+}
+
+// Handle processes an audio.transcode job
+func (j *TranscodeJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.MemoJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		// Memo was deleted before we got to it
+		return nil
+	}
+
+	if memo.AudioKey == nil || !j.transcoder.Enabled() {
+		return j.memoRepo.SetTranscodeStatus(ctx, memoID, models.TranscodeStatusSkipped)
+	}
+
+	if err := j.transcode(ctx, memo, *memo.AudioKey); err != nil {
+		if job.IsLastAttempt() {
+			_ = j.memoRepo.SetTranscodeStatus(ctx, memoID, models.TranscodeStatusFailed)
+		}
+		return err
+	}
+
+	return j.memoRepo.SetTranscodeStatus(ctx, memoID, models.TranscodeStatusCompleted)
+}
+
+// transcode downloads the original recording, renders the variants and uploads them
+func (j *TranscodeJob) transcode(ctx context.Context, memo *models.Memo, sourceKey string) error {
+	workDir, err := os.MkdirTemp("", "trailmemo-transcode-*")
+	if err != nil {
+		return fmt.Errorf("error creating work directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	inputPath := filepath.Join(workDir, "original"+path.Ext(sourceKey))
+	input, err := os.Create(inputPath)
+	if err != nil {
+		return fmt.Errorf("error creating input file: %v", err)
+	}
+	err = j.firebaseService.DownloadObject(ctx, sourceKey, input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+
+	files, err := j.transcoder.Transcode(ctx, inputPath, workDir)
+	if err != nil {
+		return err
+	}
+
+	// Variants live next to the original: memos/<user>/variants/<memo>.<ext>
+	baseKey := path.Join(path.Dir(sourceKey), "variants", memo.MemoID.String())
+
+	for _, file := range files {
+		key := baseKey + file.Target.Extension
+		if err := j.uploadFile(ctx, key, file); err != nil {
+			return err
+		}
+
+		variant := &models.AudioVariant{
+			MemoID:             memo.MemoID,
+			Format:             file.Target.Format,
+			ContentType:        file.Target.ContentType,
+			AudioKey:           key,
+			BitrateKbps:        file.Target.BitrateKbps,
+			SizeBytes:          file.Size,
+			LoudnessNormalized: true,
+		}
+		if err := j.variantRepo.Upsert(ctx, variant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *TranscodeJob) uploadFile(ctx context.Context, key string, file services.TranscodedFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("error opening %s output: %v", file.Target.Format, err)
+	}
+	defer f.Close()
+
+	return j.firebaseService.UploadObject(ctx, key, file.Target.ContentType, f)
+}
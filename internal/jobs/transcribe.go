@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// errTextEdited reports that a transcript was not applied because the memo
+// was edited before transcription finished
+var errTextEdited = errors.New("memo was edited before transcription finished")
+
+// TranscribeJob transcribes a memo's recording on the server and stores the
+// transcript with per-word timings
+type TranscribeJob struct {
+	memoRepo        *repository.MemoRepository
+	revisionRepo    *repository.RevisionRepository
+	transcriptRepo  *repository.TranscriptRepository
+	jobRepo         *repository.JobRepository
+	firebaseService *services.FirebaseService
+	transcriber     services.Transcriber
+	language        string
+	maxDuration     time.Duration
+}
+
+// NewTranscribeJob creates a new transcription job handler. language is the
+// ISO 639-1 code passed to the engine, or "auto" to detect it. Recordings
+// longer than maxDuration are skipped (no limit if zero).
+func NewTranscribeJob(
+	memoRepo *repository.MemoRepository,
+	revisionRepo *repository.RevisionRepository,
+	transcriptRepo *repository.TranscriptRepository,
+	jobRepo *repository.JobRepository,
+	firebaseService *services.FirebaseService,
+	transcriber services.Transcriber,
+	language string,
+	maxDuration time.Duration,
+) *TranscribeJob {
+	return &TranscribeJob{
+		memoRepo:        memoRepo,
+		revisionRepo:    revisionRepo,
+		transcriptRepo:  transcriptRepo,
+		jobRepo:         jobRepo,
+		firebaseService: firebaseService,
+		transcriber:     transcriber,
+		language:        language,
+		maxDuration:     maxDuration,
+	}
+}
+
+// TranscribeLease is how long a transcription may run: the default job lease
+// plus twice the longest recording transcribed, since an engine on a small
+// server can run slower than real time
+func TranscribeLease(maxDuration time.Duration) time.Duration {
+	return jobLease + 2*maxDuration
+}
+
+// Handle processes an audio.transcribe job
+func (j *TranscribeJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.TranscribeJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		// Memo was deleted before we got to it
+		return nil
+	}
+
+	if memo.AudioKey == nil || !j.transcriber.Enabled() {
+		return j.memoRepo.SetTranscriptionStatus(ctx, memoID, models.TranscriptionStatusSkipped)
+	}
+
+	if j.maxDuration > 0 && time.Duration(memo.DurationSeconds)*time.Second > j.maxDuration {
+		log.Printf("Memo %s is longer than the transcription limit of %s; skipping", memoID, j.maxDuration)
+		return j.memoRepo.SetTranscriptionStatus(ctx, memoID, models.TranscriptionStatusSkipped)
+	}
+
+	transcript, err := j.transcribe(ctx, memo)
+	if err != nil {
+		if job.IsLastAttempt() {
+			_ = j.memoRepo.SetTranscriptionStatus(ctx, memoID, models.TranscriptionStatusFailed)
+		}
+		return err
+	}
+
+	if err := j.transcriptRepo.Upsert(ctx, transcript); err != nil {
+		return err
+	}
+
+	applied, err := j.applyText(ctx, memo, transcript.Text, payload)
+	if errors.Is(err, errTextEdited) {
+		// The transcript is stored, but the memo keeps its edited text
+		log.Printf("Memo %s was edited before transcription finished; keeping its text", memoID)
+		return j.memoRepo.SetTranscriptionStatus(ctx, memoID, models.TranscriptionStatusSkipped)
+	}
+	if err != nil {
+		return err
+	}
+	if applied {
+		// Search the transcript in the language it was spoken in
+		if language, ok := transcriptLanguage(transcript); ok && language != memo.Language {
+			if err := j.memoRepo.SetLanguage(ctx, memoID, language); err != nil {
+				return err
+			}
+		}
+
+		// The new text may suggest different tags, match saved searches and
+		// mention people
+		if _, err := j.jobRepo.Enqueue(ctx, models.JobTypeSuggestTags, models.MemoJobPayload{MemoID: payload.MemoID}); err != nil {
+			log.Printf("Error enqueuing tag suggestions for memo %s: %v", memoID, err)
+		}
+		if _, err := j.jobRepo.Enqueue(ctx, models.JobTypeMatchSearches, models.MemoJobPayload{MemoID: payload.MemoID}); err != nil {
+			log.Printf("Error enqueuing saved search matching for memo %s: %v", memoID, err)
+		}
+		if _, err := j.jobRepo.Enqueue(ctx, models.JobTypeMentions, models.MemoJobPayload{MemoID: payload.MemoID}); err != nil {
+			log.Printf("Error enqueuing mentions for memo %s: %v", memoID, err)
+		}
+	}
+
+	return j.memoRepo.SetTranscriptionStatus(ctx, memoID, models.TranscriptionStatusCompleted)
+}
+
+// transcribe downloads the original recording and runs the engine on it
+func (j *TranscribeJob) transcribe(ctx context.Context, memo *models.Memo) (*models.Transcript, error) {
+	input, err := os.CreateTemp("", "trailmemo-transcribe-*"+path.Ext(*memo.AudioKey))
+	if err != nil {
+		return nil, fmt.Errorf("error creating input file: %v", err)
+	}
+	defer os.Remove(input.Name())
+
+	err = j.firebaseService.DownloadObject(ctx, *memo.AudioKey, input)
+	input.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := j.transcriber.Transcribe(ctx, input.Name(), j.language)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := &models.Transcript{
+		MemoID: memo.MemoID,
+		Engine: j.transcriber.Name(),
+		Text:   result.Text,
+		Words:  make(models.TranscriptWords, len(result.Words)),
+	}
+	if result.Language != "" {
+		transcript.Language = &result.Language
+	}
+
+	total := 0.0
+	for i, word := range result.Words {
+		transcript.Words[i] = models.TranscriptWord{
+			Word:       word.Text,
+			Start:      word.Start,
+			End:        word.End,
+			Confidence: word.Confidence,
+		}
+		total += word.Confidence
+	}
+	if len(result.Words) > 0 {
+		confidence := total / float64(len(result.Words))
+		transcript.Confidence = &confidence
+	}
+	transcript.Segments = utils.SegmentWords(transcript.Words)
+
+	return transcript, nil
+}
+
+// applyText copies the transcript into the memo's text. Memos created without
+// text always get it. A device transcript is only replaced when the client
+// asked for it, and, unless the author requested the transcription after
+// creating the memo, nobody has edited the memo since (errTextEdited). The
+// replaced text stays in the revision history. It reports whether the memo's
+// text changed.
+func (j *TranscribeJob) applyText(ctx context.Context, memo *models.Memo, text string, payload models.TranscribeJobPayload) (bool, error) {
+	if text == "" {
+		return false, nil
+	}
+
+	if memo.Text == "" {
+		return j.memoRepo.FillEmptyText(ctx, memo.MemoID, text)
+	}
+
+	if !payload.ReplaceText || memo.Text == text {
+		return false, nil
+	}
+
+	if !payload.Requested {
+		latest, err := j.revisionRepo.GetLatestNumber(ctx, memo.MemoID)
+		if err != nil {
+			return false, err
+		}
+		if latest > 0 {
+			return false, errTextEdited
+		}
+	}
+
+	if _, err := j.memoRepo.Update(ctx, memo.MemoID, map[string]interface{}{"text": text}, models.EditorTranscription, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// transcriptLanguage returns the supported language reported by the engine,
+// or else the one detected from the transcript text
+func transcriptLanguage(transcript *models.Transcript) (string, bool) {
+	if transcript.Language != nil && models.IsSupportedLanguage(*transcript.Language) {
+		return *transcript.Language, true
+	}
+	return utils.DetectLanguage(transcript.Text)
+}
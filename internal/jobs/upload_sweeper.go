@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+const (
+	// uploadSweepInterval is how often abandoned resumable uploads are removed
+	uploadSweepInterval = 15 * time.Minute
+
+	// uploadSweepBatch is how many expired uploads are removed per query
+	uploadSweepBatch = 100
+)
+
+// UploadSweeper periodically removes resumable uploads that expired before
+// they completed, along with the bytes they stored
+type UploadSweeper struct {
+	uploadRepo      *repository.UploadRepository
+	firebaseService *services.FirebaseService
+}
+
+// NewUploadSweeper creates a new upload sweeper
+func NewUploadSweeper(uploadRepo *repository.UploadRepository, firebaseService *services.FirebaseService) *UploadSweeper {
+	return &UploadSweeper{
+		uploadRepo:      uploadRepo,
+		firebaseService: firebaseService,
+	}
+}
+
+// Start launches the sweeper goroutine. It stops when ctx is cancelled.
+func (s *UploadSweeper) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(uploadSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			s.sweep(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sweep removes every expired upload. Expired uploads can no longer be
+// claimed, so their objects are deleted before the row; if that fails the
+// row stays and the next sweep tries again.
+func (s *UploadSweeper) sweep(ctx context.Context) {
+	for {
+		uploads, err := s.uploadRepo.ListExpired(ctx, uploadSweepBatch)
+		if err != nil {
+			log.Printf("Error listing expired uploads: %v", err)
+			return
+		}
+
+		removed := 0
+		for _, upload := range uploads {
+			// The partial object and any chunks left over from an interrupted compose
+			if err := s.firebaseService.DeleteObjectsWithPrefix(ctx, upload.ObjectKey); err != nil {
+				log.Printf("Error deleting data of expired upload %s: %v", upload.UploadID, err)
+				continue
+			}
+			if err := s.uploadRepo.Delete(ctx, upload.UploadID); err != nil {
+				log.Printf("Error deleting expired upload %s: %v", upload.UploadID, err)
+				continue
+			}
+			removed++
+		}
+
+		if removed > 0 {
+			log.Printf("Removed %d expired uploads", removed)
+		}
+		if len(uploads) < uploadSweepBatch || removed == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+	"github.com/tom-fitz/trailmemo-api/internal/services"
+)
+
+// WaveformJob computes the waveform peaks of a memo's original recording
+type WaveformJob struct {
+	memoRepo        *repository.MemoRepository
+	waveformRepo    *repository.WaveformRepository
+	firebaseService *services.FirebaseService
+	generator       *services.WaveformGenerator
+}
+
+// NewWaveformJob creates a new waveform job handler
+func NewWaveformJob(
+	memoRepo *repository.MemoRepository,
+	waveformRepo *repository.WaveformRepository,
+	firebaseService *services.FirebaseService,
+	generator *services.WaveformGenerator,
+) *WaveformJob {
+	return &WaveformJob{
+		memoRepo:        memoRepo,
+		waveformRepo:    waveformRepo,
+		firebaseService: firebaseService,
+		generator:       generator,
+	}
+}
+
+// Handle processes an audio.waveform job
+func (j *WaveformJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.MemoJobPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	memoID, err := uuid.Parse(payload.MemoID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid memo ID: %v", err))
+	}
+
+	memo, err := j.memoRepo.GetByID(ctx, memoID)
+	if err != nil {
+		return err
+	}
+	if memo == nil {
+		// Memo was deleted before we got to it
+		return nil
+	}
+
+	if memo.AudioKey == nil || !j.generator.Enabled() {
+		return nil
+	}
+	sourceKey := *memo.AudioKey
+
+	input, err := os.CreateTemp("", "trailmemo-waveform-*"+path.Ext(sourceKey))
+	if err != nil {
+		return fmt.Errorf("error creating input file: %v", err)
+	}
+	defer os.Remove(input.Name())
+
+	err = j.firebaseService.DownloadObject(ctx, sourceKey, input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+
+	data, err := j.generator.Generate(ctx, input.Name(), memo.DurationSeconds)
+	if err != nil {
+		return err
+	}
+
+	return j.waveformRepo.Upsert(ctx, &models.Waveform{
+		MemoID:          memo.MemoID,
+		Peaks:           data.Peaks,
+		PeaksPerSecond:  data.PeaksPerSecond,
+		SampleRate:      data.SampleRate,
+		DurationSeconds: data.DurationSeconds,
+	})
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// webhookTimeout bounds each webhook request
+const webhookTimeout = 10 * time.Second
+
+// newWebhookClient creates the HTTP client webhooks are sent with. Webhook
+// URLs are chosen by users, so connections to addresses that aren't public
+// are refused when dialled (after DNS resolution, so a public hostname that
+// resolves to a private address is caught too), and redirects aren't
+// followed so a receiver can't bounce the request somewhere else.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: refusePrivateAddress,
+	}
+
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivateAddress is a net.Dialer Control function that fails the
+// connection unless address is a public IP
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook address %q: %v", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !utils.IsPublicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+
+	return nil
+}
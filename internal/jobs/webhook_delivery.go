@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// WebhookDeliveryJob POSTs a memo event to a webhook subscription, signed with
+// the subscription's secret. Each request is logged on the delivery; failed
+// ones are retried by the queue with backoff unless the receiver rejects the
+// request outright.
+type WebhookDeliveryJob struct {
+	memoRepo    *repository.MemoRepository
+	webhookRepo *repository.WebhookRepository
+	client      *http.Client
+}
+
+// NewWebhookDeliveryJob creates a new webhook delivery job handler
+func NewWebhookDeliveryJob(memoRepo *repository.MemoRepository, webhookRepo *repository.WebhookRepository) *WebhookDeliveryJob {
+	return &WebhookDeliveryJob{
+		memoRepo:    memoRepo,
+		webhookRepo: webhookRepo,
+		client:      newWebhookClient(),
+	}
+}
+
+// Handle processes a webhook.deliver job
+func (j *WebhookDeliveryJob) Handle(ctx context.Context, job *models.Job) error {
+	var payload models.WebhookDeliveryPayload
+	if err := job.Payload.Unmarshal(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %v", err))
+	}
+
+	deliveryID, err := uuid.Parse(payload.DeliveryID)
+	if err != nil {
+		return Permanent(fmt.Errorf("invalid delivery ID: %v", err))
+	}
+
+	delivery, err := j.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery == nil || delivery.Status == models.WebhookDeliverySucceeded {
+		// Deleted with its webhook, or already delivered by another job
+		return nil
+	}
+
+	webhook, err := j.webhookRepo.GetByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if webhook == nil {
+		return nil
+	}
+	if !webhook.Active {
+		reason := "Webhook is inactive"
+		return j.webhookRepo.SetDeliveryStatus(ctx, delivery.DeliveryID, models.WebhookDeliveryFailed, &reason)
+	}
+
+	if delivery.Payload == nil {
+		body, err := j.buildPayload(ctx, delivery)
+		if err != nil {
+			return err
+		}
+		if err := j.webhookRepo.SetPayload(ctx, delivery.DeliveryID, body); err != nil {
+			return err
+		}
+		delivery.Payload = &body
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(*delivery.Payload))
+	if err != nil {
+		return j.finish(ctx, job, delivery, &models.WebhookDeliveryAttempt{}, Permanent(fmt.Errorf("error creating webhook request: %v", err)))
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TrailMemo-Webhook/1.0")
+	req.Header.Set("X-TrailMemo-Event", delivery.EventType)
+	req.Header.Set("X-TrailMemo-Delivery", delivery.DeliveryID.String())
+	req.Header.Set("X-TrailMemo-Signature", WebhookSignature(webhook.Secret, timestamp, *delivery.Payload))
+
+	start := time.Now()
+	resp, err := j.client.Do(req)
+	attempt := &models.WebhookDeliveryAttempt{}
+	if err != nil {
+		attempt.DurationMS = int(time.Since(start).Milliseconds())
+		return j.finish(ctx, job, delivery, attempt, fmt.Errorf("error delivering webhook: %v", err))
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	attempt.DurationMS = int(time.Since(start).Milliseconds())
+	attempt.StatusCode = &resp.StatusCode
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return j.finish(ctx, job, delivery, attempt, nil)
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return j.finish(ctx, job, delivery, attempt, fmt.Errorf("webhook returned status %d", resp.StatusCode))
+	default:
+		return j.finish(ctx, job, delivery, attempt, Permanent(fmt.Errorf("webhook rejected with status %d", resp.StatusCode)))
+	}
+}
+
+// buildPayload builds the body for a delivery with the memo as it is now
+func (j *WebhookDeliveryJob) buildPayload(ctx context.Context, delivery *models.WebhookDelivery) (types.JSONText, error) {
+	event := models.WebhookEvent{
+		DeliveryID: delivery.DeliveryID,
+		Event:      delivery.EventType,
+		EventID:    delivery.EventID,
+		OccurredAt: delivery.OccurredAt,
+		Data: models.WebhookEventData{
+			MemoID:  delivery.MemoID,
+			Changes: delivery.Changes,
+		},
+	}
+
+	if delivery.EventType != models.MemoEventDeleted {
+		memo, err := j.memoRepo.GetByID(ctx, delivery.MemoID)
+		if err != nil {
+			return nil, err
+		}
+		event.Data.Memo = memo
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("error encoding webhook event: %v", err))
+	}
+
+	return body, nil
+}
+
+// finish records an attempt with its outcome and returns the error for the
+// queue. The delivery stays pending while the queue will retry it.
+func (j *WebhookDeliveryJob) finish(ctx context.Context, job *models.Job, delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt, deliveryErr error) error {
+	status := models.WebhookDeliverySucceeded
+	if deliveryErr != nil {
+		message := deliveryErr.Error()
+		attempt.Error = &message
+
+		status = models.WebhookDeliveryPending
+		var permanent *permanentError
+		if errors.As(deliveryErr, &permanent) || job.IsLastAttempt() {
+			status = models.WebhookDeliveryFailed
+		}
+	}
+
+	attempt.DeliveryID = delivery.DeliveryID
+	if err := j.webhookRepo.RecordAttempt(ctx, attempt, status); err != nil {
+		// The request was made; retrying just to log it could deliver twice
+		log.Printf("Error logging webhook delivery %s: %v", delivery.DeliveryID, err)
+	}
+
+	return deliveryErr
+}
+
+// WebhookSignature signs a webhook body sent at timestamp (Unix seconds). It
+// returns the X-TrailMemo-Signature header value, "t=<timestamp>,v1=<hex
+// HMAC-SHA256 of "<timestamp>.<body>">".
+func WebhookSignature(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
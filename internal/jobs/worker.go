@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+const (
+	// pollInterval is how long an idle worker waits before checking for jobs again
+	pollInterval = 2 * time.Second
+
+	// jobLease is how long a worker may run a job before another worker
+	// assumes it died and claims the job again, unless the job type was
+	// registered with its own lease
+	jobLease = 15 * time.Minute
+
+	// sweepInterval is how often jobs abandoned on their last attempt are failed
+	sweepInterval = time.Minute
+
+	// retryBaseDelay and retryMaxDelay bound the exponential backoff between attempts
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// HandlerFunc processes a single job. Returning an error schedules a retry
+// unless the error is permanent or the job is out of attempts.
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// permanentError marks an error that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails immediately instead of being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Worker polls the jobs table and runs registered handlers
+type Worker struct {
+	jobRepo     *repository.JobRepository
+	handlers    map[string]HandlerFunc
+	leases      map[string]time.Duration
+	concurrency int
+}
+
+// NewWorker creates a new job worker
+func NewWorker(jobRepo *repository.JobRepository, concurrency int) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Worker{
+		jobRepo:     jobRepo,
+		handlers:    make(map[string]HandlerFunc),
+		leases:      make(map[string]time.Duration),
+		concurrency: concurrency,
+	}
+}
+
+// Register sets the handler for a job type. It must be called before Start.
+func (w *Worker) Register(jobType string, handler HandlerFunc) {
+	w.RegisterWithLease(jobType, handler, jobLease)
+}
+
+// RegisterWithLease sets the handler for a job type whose jobs may run for
+// up to lease, for work that routinely takes longer than jobLease. It must be
+// called before Start.
+func (w *Worker) RegisterWithLease(jobType string, handler HandlerFunc, lease time.Duration) {
+	w.handlers[jobType] = handler
+	w.leases[jobType] = lease
+}
+
+// Start launches the worker goroutines. They stop when ctx is cancelled.
+func (w *Worker) Start(ctx context.Context) {
+	for i := 0; i < w.concurrency; i++ {
+		go w.loop(ctx)
+	}
+	go w.sweep(ctx)
+}
+
+// sweep periodically fails jobs whose worker died on their last attempt
+func (w *Worker) sweep(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := w.jobRepo.FailExpired(ctx)
+			if err != nil {
+				log.Printf("Error sweeping expired jobs: %v", err)
+			} else if count > 0 {
+				log.Printf("Failed %d jobs abandoned on their last attempt", count)
+			}
+		}
+	}
+}
+
+// loop claims and runs jobs until ctx is cancelled
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		job, err := w.jobRepo.ClaimNext(ctx, w.leases)
+		if err != nil {
+			log.Printf("Error claiming job: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		w.run(ctx, job)
+	}
+}
+
+// run executes a job and records its outcome
+func (w *Worker) run(ctx context.Context, job *models.Job) {
+	jobCtx, cancel := context.WithTimeout(ctx, w.leases[job.JobType])
+	defer cancel()
+
+	err := w.handlers[job.JobType](jobCtx, job)
+	if err == nil {
+		if err := w.jobRepo.Complete(ctx, job.JobID); err != nil {
+			log.Printf("Error completing job %d: %v", job.JobID, err)
+		}
+		return
+	}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) || job.IsLastAttempt() {
+		log.Printf("Job %d (%s) failed: %v", job.JobID, job.JobType, err)
+		if err := w.jobRepo.Fail(ctx, job.JobID, err.Error()); err != nil {
+			log.Printf("Error failing job %d: %v", job.JobID, err)
+		}
+		return
+	}
+
+	delay := retryDelay(job.Attempts)
+	log.Printf("Job %d (%s) attempt %d failed, retrying in %s: %v", job.JobID, job.JobType, job.Attempts, delay, err)
+	if err := w.jobRepo.Retry(ctx, job.JobID, err.Error(), time.Now().UTC().Add(delay)); err != nil {
+		log.Printf("Error rescheduling job %d: %v", job.JobID, err)
+	}
+}
+
+// retryDelay returns the exponential backoff delay after the given attempt
+func retryDelay(attempts int) time.Duration {
+	delay := time.Duration(float64(retryBaseDelay) * math.Pow(2, float64(attempts-1)))
+	if delay > retryMaxDelay || delay <= 0 {
+		return retryMaxDelay
+	}
+	return delay
+}
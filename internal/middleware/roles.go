@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+// RequireRole allows only registered users with one of the given roles. It
+// must run after AuthMiddleware.
+func RequireRole(userRepo *repository.UserRepository, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": gin.H{
+					"code":    "AUTHENTICATION_ERROR",
+					"message": "Authentication required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		user, err := userRepo.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "INTERNAL_ERROR",
+					"message": "Error fetching user",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		if user != nil {
+			for _, role := range roles {
+				if user.Role == role {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTHORIZATION_ERROR",
+				"message": "You don't have permission to access this resource",
+				"details": gin.H{
+					"required_roles": roles,
+				},
+			},
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Transcode statuses
+const (
+	TranscodeStatusPending   = "pending"
+	TranscodeStatusCompleted = "completed"
+	TranscodeStatusFailed    = "failed"
+	TranscodeStatusSkipped   = "skipped"
+)
+
+// AudioVariant represents a transcoded rendition of a memo's recording
+type AudioVariant struct {
+	VariantID          uuid.UUID `json:"variant_id" db:"variant_id"`
+	MemoID             uuid.UUID `json:"-" db:"memo_id"`
+	Format             string    `json:"format" db:"format"`
+	ContentType        string    `json:"content_type" db:"content_type"`
+	AudioKey           string    `json:"-" db:"audio_key"`
+	AudioURL           string    `json:"audio_url" db:"-"` // Signed, see Memo.AudioURLExpiresAt
+	BitrateKbps        int       `json:"bitrate_kbps" db:"bitrate_kbps"`
+	SizeBytes          int64     `json:"size_bytes" db:"size_bytes"`
+	LoudnessNormalized bool      `json:"loudness_normalized" db:"loudness_normalized"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+}
+
+// Waveform holds the peak amplitudes of a memo's recording.
+// Each peak is the maximum absolute amplitude (0-1) over 1/peaks_per_second seconds.
+type Waveform struct {
+	MemoID          uuid.UUID       `json:"memo_id" db:"memo_id"`
+	Peaks           pq.Float64Array `json:"peaks" db:"peaks"`
+	PeaksPerSecond  float64         `json:"peaks_per_second" db:"peaks_per_second"`
+	SampleRate      int             `json:"sample_rate" db:"sample_rate"`
+	DurationSeconds float64         `json:"duration_seconds" db:"duration_seconds"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
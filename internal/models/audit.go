@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Audit actions
+const (
+	AuditActionMemoCreate    = "memo.create"
+	AuditActionMemoUpdate    = "memo.update"
+	AuditActionMemoDelete    = "memo.delete"
+	AuditActionMemoExport    = "memo.export"
+	AuditActionAudioLink     = "memo.audio_link"
+	AuditActionUserRegister  = "user.register"
+	AuditActionRoleChange    = "user.role_change"
+	AuditActionParksChange   = "user.parks_change"
+	AuditActionReportCreate  = "report.create"
+	AuditActionReportDelete  = "report.delete"
+	AuditActionWebhookCreate = "webhook.create"
+	AuditActionWebhookUpdate = "webhook.update"
+	AuditActionWebhookDelete = "webhook.delete"
+)
+
+// Audit target types
+const (
+	AuditTargetMemo    = "memo"
+	AuditTargetUser    = "user"
+	AuditTargetReport  = "report"
+	AuditTargetWebhook = "webhook"
+)
+
+// AuditEntry is one append-only record of a mutating action
+type AuditEntry struct {
+	AuditID    int64           `json:"audit_id" db:"audit_id"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+	ActorID    *string         `json:"actor_id" db:"actor_id"`
+	ActorName  *string         `json:"actor_name" db:"actor_name"` // Joined from users; nil once deleted
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   *string         `json:"target_id" db:"target_id"`
+	Before     *types.JSONText `json:"before" db:"before"`
+	After      *types.JSONText `json:"after" db:"after"`
+	Metadata   types.JSONText  `json:"metadata" db:"metadata"`
+	RequestID  *string         `json:"request_id" db:"request_id"`
+	IPAddress  *string         `json:"ip_address" db:"ip_address"`
+	UserAgent  *string         `json:"user_agent" db:"user_agent"`
+	Method     *string         `json:"method" db:"method"`
+	Path       *string         `json:"path" db:"path"`
+}
+
+// AuditSnapshot encodes a before or after value of an audit entry, or returns
+// nil for none
+func AuditSnapshot(value interface{}) *types.JSONText {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+
+	snapshot := types.JSONText(data)
+	return &snapshot
+}
+
+// AuditFilter narrows a listing of the audit log
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
+
+// AuditLogResponse represents a page of the audit log
+type AuditLogResponse struct {
+	Entries    []AuditEntry       `json:"entries"`
+	Pagination PaginationResponse `json:"pagination"`
+}
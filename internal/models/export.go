@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// MemoExportRow is one memo in a CSV or XLSX export
+type MemoExportRow struct {
+	MemoID              uuid.UUID      `db:"memo_id"`
+	UserID              string         `db:"user_id"`
+	UserName            string         `db:"user_name"`
+	Department          *string        `db:"department"` // The author's current department
+	Title               *string        `db:"title"`
+	Text                string         `db:"text"`
+	ParkName            *string        `db:"park_name"`
+	Latitude            *float64       `db:"latitude"`
+	Longitude           *float64       `db:"longitude"`
+	LocationAccuracy    *float64       `db:"location_accuracy"`
+	Address             *string        `db:"address"`
+	Category            *string        `db:"category"`
+	Tags                pq.StringArray `db:"tags"`
+	Language            string         `db:"language"`
+	DurationSeconds     int            `db:"duration_seconds"`
+	Status              string         `db:"status"`
+	TranscriptionStatus *string        `db:"transcription_status"`
+	HasAudio            bool           `db:"has_audio"`
+	CreatedAt           time.Time      `db:"created_at"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+}
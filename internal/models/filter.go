@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Memo sort orders
+const (
+	MemoSortRecent    = "recent"    // Newest first
+	MemoSortRelevance = "relevance" // Best text match first; requires Query
+	MemoSortDistance  = "distance"  // Closest first; requires Near
+)
+
+// GeoRadius selects memos within RadiusMeters of a point
+type GeoRadius struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	RadiusMeters int     `json:"radius_meters"`
+}
+
+// BoundingBox selects memos inside a latitude/longitude rectangle
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// MemoFilter selects memos for List, Search and GetNearby, and is stored as
+// JSON in saved searches. Zero values do not constrain the results; all set
+// fields must match.
+type MemoFilter struct {
+	Query     string       `json:"q,omitempty"` // Full-text search, websearch syntax
+	UserID    string       `json:"user_id,omitempty"`
+	ParkNames []string     `json:"park_names,omitempty"` // Memo is in any of these parks
+	Category  string       `json:"category,omitempty"`
+	Language  string       `json:"language,omitempty"`
+	Status    string       `json:"status,omitempty"`
+	Tags      []string     `json:"tags,omitempty"` // Memo must have every tag
+	StartDate *time.Time   `json:"start_date,omitempty"`
+	EndDate   *time.Time   `json:"end_date,omitempty"` // Exclusive
+	Near      *GeoRadius   `json:"near,omitempty"`
+	BBox      *BoundingBox `json:"bbox,omitempty"`
+	Sort      string       `json:"-"`
+}
+
+// IsEmpty reports whether the filter matches every memo
+func (f MemoFilter) IsEmpty() bool {
+	return f.Query == "" && f.UserID == "" && len(f.ParkNames) == 0 && f.Category == "" && f.Language == "" &&
+		f.Status == "" && len(f.Tags) == 0 && f.StartDate == nil && f.EndDate == nil && f.Near == nil && f.BBox == nil
+}
+
+// Value implements driver.Valuer for storing the filter as JSONB
+func (f MemoFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan implements sql.Scanner for reading the filter from JSONB
+func (f *MemoFilter) Scan(src interface{}) error {
+	return scanJSON(src, f)
+}
+
+// FacetCount is the number of matching memos with one value of a dimension
+type FacetCount struct {
+	Value string  `json:"value"`
+	Label *string `json:"label,omitempty"`
+	Count int     `json:"count"`
+}
+
+// MemoFacets counts matching memos per value of each filter dimension. Each
+// dimension is counted with every filter applied except its own, so clients
+// can show the alternatives to the current selection.
+type MemoFacets struct {
+	Users      []FacetCount `json:"users"`
+	Parks      []FacetCount `json:"parks"`
+	Tags       []FacetCount `json:"tags"`
+	Categories []FacetCount `json:"categories"`
+	Languages  []FacetCount `json:"languages"`
+	Statuses   []FacetCount `json:"statuses"`
+	Months     []FacetCount `json:"months"` // YYYY-MM
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Job types
+const (
+	JobTypeTranscodeAudio = "audio.transcode"
+	JobTypeAudioWaveform  = "audio.waveform"
+	JobTypeTranscribe     = "audio.transcribe"
+	JobTypeSuggestTags    = "memo.suggest_tags"
+	JobTypeMatchSearches  = "memo.match_saved_searches"
+	JobTypeMentions       = "memo.mentions"
+	JobTypeSearchWebhook  = "saved_search.webhook"
+	JobTypeEmail          = "notification.email"
+	JobTypeDigest         = "notification.digest"
+	JobTypePush           = "notification.push"
+	JobTypeReport         = "report.generate"
+)
+
+// Job statuses
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Job represents a unit of background work
+type Job struct {
+	JobID       int64          `json:"job_id" db:"job_id"`
+	JobType     string         `json:"job_type" db:"job_type"`
+	Payload     types.JSONText `json:"payload" db:"payload"`
+	Status      string         `json:"status" db:"status"`
+	Attempts    int            `json:"attempts" db:"attempts"`
+	MaxAttempts int            `json:"max_attempts" db:"max_attempts"`
+	RunAt       time.Time      `json:"run_at" db:"run_at"`
+	LockedUntil *time.Time     `json:"-" db:"locked_until"`
+	LastError   *string        `json:"last_error" db:"last_error"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// IsLastAttempt reports whether a failure of this run will not be retried
+func (j *Job) IsLastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// QueuedJob is a job to enqueue in the same transaction as another change
+type QueuedJob struct {
+	JobType string
+	Payload interface{}
+}
+
+// MemoJobPayload is the payload of jobs that operate on a single memo
+type MemoJobPayload struct {
+	MemoID string `json:"memo_id"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// DefaultLanguage is used when a memo's language is neither given nor detected
+const DefaultLanguage = "en"
+
+// Language is a memo language with full-text search support
+type Language struct {
+	Code         string // ISO 639-1, as reported by the transcriber
+	Name         string
+	SearchConfig string // PostgreSQL text search configuration
+}
+
+// Languages lists the supported memo languages. The codes and configurations
+// must match the memo_search_config SQL function.
+var Languages = []Language{
+	{Code: "en", Name: "English", SearchConfig: "english"},
+	{Code: "es", Name: "Spanish", SearchConfig: "spanish"},
+	{Code: "fr", Name: "French", SearchConfig: "french"},
+	{Code: "de", Name: "German", SearchConfig: "german"},
+	{Code: "it", Name: "Italian", SearchConfig: "italian"},
+	{Code: "pt", Name: "Portuguese", SearchConfig: "portuguese"},
+}
+
+// IsSupportedLanguage reports whether code is one of the supported languages
+func IsSupportedLanguage(code string) bool {
+	for _, lang := range Languages {
+		if lang.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Location represents GPS coordinates
@@ -14,51 +15,105 @@ type Location struct {
 	Address   *string  `json:"address,omitempty" db:"address"`
 }
 
+// Memo workflow statuses, tracking the issue a memo reports
+const (
+	MemoStatusOpen       = "open"
+	MemoStatusInProgress = "in_progress"
+	MemoStatusResolved   = "resolved"
+)
+
+// MemoStatuses lists the valid memo workflow statuses
+var MemoStatuses = []string{MemoStatusOpen, MemoStatusInProgress, MemoStatusResolved}
+
+// IsValidMemoStatus reports whether status is a memo workflow status
+func IsValidMemoStatus(status string) bool {
+	for _, s := range MemoStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // Memo represents a voice memo
 type Memo struct {
-	MemoID           uuid.UUID `json:"memo_id" db:"memo_id"`
-	UserID           string    `json:"user_id" db:"user_id"`
-	UserName         string    `json:"user_name" db:"user_name"`
-	UserColor        string    `json:"user_color" db:"user_color"`
-	Title            *string   `json:"title" db:"title"`
-	AudioURL         string    `json:"audio_url" db:"audio_url"`
-	Text             string    `json:"text" db:"text"`
-	DurationSeconds  int       `json:"duration_seconds" db:"duration_seconds"`
-	Latitude         *float64  `json:"-" db:"latitude"`
-	Longitude        *float64  `json:"-" db:"longitude"`
-	LocationAccuracy *float64  `json:"-" db:"location_accuracy"`
-	Address          *string   `json:"-" db:"address"`
-	ParkName         *string   `json:"park_name" db:"park_name"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
-	Location         *Location `json:"location,omitempty" db:"-"`
+	MemoID              uuid.UUID      `json:"memo_id" db:"memo_id"`
+	UserID              string         `json:"user_id" db:"user_id"`
+	UserName            string         `json:"user_name" db:"user_name"`
+	UserColor           string         `json:"user_color" db:"user_color"`
+	Title               *string        `json:"title" db:"title"`
+	AudioKey            *string        `json:"-" db:"audio_key"` // Storage object key, nil without a recording
+	Text                string         `json:"text" db:"text"`
+	DurationSeconds     int            `json:"duration_seconds" db:"duration_seconds"`
+	Latitude            *float64       `json:"-" db:"latitude"`
+	Longitude           *float64       `json:"-" db:"longitude"`
+	LocationAccuracy    *float64       `json:"-" db:"location_accuracy"`
+	Address             *string        `json:"-" db:"address"`
+	ParkName            *string        `json:"park_name" db:"park_name"`
+	Tags                pq.StringArray `json:"tags" db:"tags"`
+	Category            *string        `json:"category" db:"category"`
+	Language            string         `json:"language" db:"language"` // ISO 639-1 code
+	Status              string         `json:"status" db:"status"`
+	ResolvedAt          *time.Time     `json:"resolved_at" db:"resolved_at"`
+	TranscodeStatus     *string        `json:"transcode_status,omitempty" db:"transcode_status"`
+	TranscriptionStatus *string        `json:"transcription_status,omitempty" db:"transcription_status"`
+	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at" db:"updated_at"`
+	Location            *Location      `json:"location,omitempty" db:"-"`
+
+	// Short-lived signed URL for the recording, set when the memo is returned
+	AudioURL          *string    `json:"audio_url" db:"-"`
+	AudioURLExpiresAt *time.Time `json:"audio_url_expires_at,omitempty" db:"-"`
+
+	AudioVariants []AudioVariant `json:"audio_variants,omitempty" db:"-"`
 }
 
 // MemoListItem represents a memo in list views
 type MemoListItem struct {
-	MemoID          uuid.UUID `json:"memo_id"`
-	UserID          string    `json:"user_id"`
-	UserName        string    `json:"user_name"`
-	UserColor       string    `json:"user_color"`
-	Title           *string   `json:"title"`
-	AudioURL        string    `json:"audio_url"`
-	Text            string    `json:"text"`
-	DurationSeconds int       `json:"duration_seconds"`
-	Location        *Location `json:"location,omitempty"`
-	ParkName        *string   `json:"park_name"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	MemoID          uuid.UUID  `json:"memo_id"`
+	UserID          string     `json:"user_id"`
+	UserName        string     `json:"user_name"`
+	UserColor       string     `json:"user_color"`
+	Title           *string    `json:"title"`
+	Text            string     `json:"text"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Location        *Location  `json:"location,omitempty"`
+	ParkName        *string    `json:"park_name"`
+	Tags            []string   `json:"tags"`
+	Category        *string    `json:"category"`
+	Language        string     `json:"language"`
+	Status          string     `json:"status"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	AudioKey          *string    `json:"-"`
+	AudioURL          *string    `json:"audio_url"`
+	AudioURLExpiresAt *time.Time `json:"audio_url_expires_at,omitempty"`
+
+	// Set when filtering by text or location: the text around the matched
+	// terms (marked with **), the rank, the distance from the search center,
+	// and where the matched phrase is spoken in the recording
+	Snippet         *string          `json:"snippet,omitempty"`
+	RelevanceScore  *float64         `json:"relevance_score,omitempty"`
+	DistanceMeters  *float64         `json:"distance_meters,omitempty"`
+	Match           *TranscriptMatch `json:"match,omitempty"`
+	TranscriptWords TranscriptWords  `json:"-"`
 }
 
 // CreateMemoRequest represents the request to create a memo
 type CreateMemoRequest struct {
-	Text             string   `form:"text" binding:"required"`
+	Text             string   `form:"text"` // Required unless audio is attached
 	DurationSeconds  int      `form:"duration_seconds" binding:"required"`
 	Latitude         float64  `form:"latitude" binding:"required"`
 	Longitude        float64  `form:"longitude" binding:"required"`
 	LocationAccuracy *float64 `form:"location_accuracy"`
 	ParkName         *string  `form:"park_name"`
 	Title            *string  `form:"title"`
+	FilePath         *string  `form:"file_path"`  // Storage key from a presigned upload
+	UploadID         *string  `form:"upload_id"`  // Completed resumable upload
+	Transcribe       bool     `form:"transcribe"` // Transcribe the audio on the server
+	Language         *string  `form:"language"`   // Detected from the text when omitted
 }
 
 // UpdateMemoRequest represents the request to update a memo
@@ -66,6 +121,8 @@ type UpdateMemoRequest struct {
 	Title     *string  `json:"title"`
 	Text      *string  `json:"text"`
 	ParkName  *string  `json:"park_name"`
+	Language  *string  `json:"language"`
+	Status    *string  `json:"status"`
 	Latitude  *float64 `json:"latitude,omitempty"`
 	Longitude *float64 `json:"longitude,omitempty"`
 }
@@ -84,6 +141,7 @@ type PaginationResponse struct {
 type MemosListResponse struct {
 	Memos      []MemoListItem     `json:"memos"`
 	Pagination PaginationResponse `json:"pagination"`
+	Facets     *MemoFacets        `json:"facets,omitempty"`
 }
 
 // SearchResponse represents search results
@@ -91,6 +149,7 @@ type SearchResponse struct {
 	Results    []MemoListItem     `json:"results"`
 	Query      string             `json:"query"`
 	Pagination PaginationResponse `json:"pagination"`
+	Facets     *MemoFacets        `json:"facets,omitempty"`
 }
 
 // NearbyMemo represents a memo with distance info
@@ -111,6 +170,26 @@ type NearbyMemosResponse struct {
 	Center       Location     `json:"center"`
 	RadiusMeters int          `json:"radius_meters"`
 	TotalFound   int          `json:"total_found"`
+	Facets       *MemoFacets  `json:"facets,omitempty"`
+}
+
+// Autocomplete suggestion types
+const (
+	AutocompleteTypePark  = "park"
+	AutocompleteTypeTitle = "title"
+)
+
+// AutocompleteSuggestion is a park name or title matching partial input
+type AutocompleteSuggestion struct {
+	Value string `json:"value" db:"value"`
+	Type  string `json:"type" db:"type"`
+	Count int    `json:"count" db:"count"` // Memos with this value
+}
+
+// AutocompleteResponse represents autocomplete results
+type AutocompleteResponse struct {
+	Query       string                   `json:"query"`
+	Suggestions []AutocompleteSuggestion `json:"suggestions"`
 }
 
 // ErrorResponse represents an API error
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Memo event types
+const (
+	MemoEventCreated = "memo.created"
+	MemoEventDeleted = "memo.deleted"
+
+	// Recorded when a field users edit changes: title, text, park,
+	// category, tags, language or location
+	MemoEventUpdated = "memo.updated"
+
+	// Recorded when the workflow status, transcode_status or
+	// transcription_status changes
+	MemoEventStatusChanged = "status.changed"
+)
+
+// MemoEvent is an entry in the memo change feed
+type MemoEvent struct {
+	EventID   int64     `json:"event_id" db:"event_id"`
+	EventType string    `json:"type" db:"event_type"`
+	MemoID    uuid.UUID `json:"memo_id" db:"memo_id"`
+	UserID    *string   `json:"-" db:"user_id"`
+	ParkName  *string   `json:"-" db:"park_name"`
+	Latitude  *float64  `json:"-" db:"latitude"`
+	Longitude *float64  `json:"-" db:"longitude"`
+	CreatedAt time.Time `json:"occurred_at" db:"created_at"`
+
+	// For status.changed, the old and new value of each changed status
+	Changes *types.JSONText `json:"changes,omitempty" db:"changes"`
+
+	// The memo as it is now; absent for deletions
+	Memo *Memo `json:"memo,omitempty" db:"-"`
+}
+
+// MatchesStream reports whether the event passes the user, park and bounding
+// box parts of a filter, the ones supported by memo streams
+func (e *MemoEvent) MatchesStream(f *MemoFilter) bool {
+	if f.UserID != "" && (e.UserID == nil || *e.UserID != f.UserID) {
+		return false
+	}
+
+	if len(f.ParkNames) > 0 {
+		if e.ParkName == nil {
+			return false
+		}
+		found := false
+		for _, park := range f.ParkNames {
+			if park == *e.ParkName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if b := f.BBox; b != nil {
+		if e.Latitude == nil || e.Longitude == nil {
+			return false
+		}
+		if *e.Latitude < b.MinLatitude || *e.Latitude > b.MaxLatitude ||
+			*e.Longitude < b.MinLongitude || *e.Longitude > b.MaxLongitude {
+			return false
+		}
+	}
+
+	return true
+}
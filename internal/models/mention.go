@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mention records that a user was @mentioned in a memo
+type Mention struct {
+	MentionID       uuid.UUID `json:"mention_id" db:"mention_id"`
+	MemoID          uuid.UUID `json:"memo_id" db:"memo_id"`
+	UserID          string    `json:"user_id" db:"user_id"`
+	MentionedBy     string    `json:"mentioned_by" db:"mentioned_by"`
+	MentionedByName string    `json:"mentioned_by_name" db:"mentioned_by_name"`
+	Handle          string    `json:"handle" db:"handle"`   // As written, without the @
+	Context         string    `json:"context" db:"context"` // Text around the mention
+	MemoTitle       *string   `json:"memo_title" db:"memo_title"`
+	ParkName        *string   `json:"park_name" db:"park_name"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// MentionsListResponse represents a page of the current user's mentions
+type MentionsListResponse struct {
+	Mentions   []Mention          `json:"mentions"`
+	Pagination PaginationResponse `json:"pagination"`
+}
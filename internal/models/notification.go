@@ -0,0 +1,142 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Notification types
+const (
+	NotificationTypeSavedSearchMatch = "saved_search.match"
+	NotificationTypeMention          = "memo.mention"
+	NotificationTypeReportReady      = "report.ready"
+)
+
+// Notification is an in-app notification for a user
+type Notification struct {
+	NotificationID uuid.UUID  `json:"notification_id" db:"notification_id"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	Type           string     `json:"type" db:"type"`
+	Title          string     `json:"title" db:"title"`
+	Body           string     `json:"body" db:"body"`
+	MemoID         *uuid.UUID `json:"memo_id" db:"memo_id"`
+	SearchID       *uuid.UUID `json:"search_id" db:"search_id"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NotificationDelivery is an in-app notification and the jobs that deliver
+// it on other channels. It is stored in the same transaction as the record
+// of what it notifies about, so a retried job never notifies twice.
+type NotificationDelivery struct {
+	Notification *Notification // Nil to skip the in-app notification
+	Jobs         []QueuedJob
+}
+
+// NotificationsListResponse represents a page of a user's notifications
+type NotificationsListResponse struct {
+	Notifications []Notification     `json:"notifications"`
+	UnreadCount   int                `json:"unread_count"`
+	Pagination    PaginationResponse `json:"pagination"`
+}
+
+// Digest frequencies
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly" // Sent on Mondays
+)
+
+// NotificationPreferences are a user's email and push settings
+type NotificationPreferences struct {
+	UserID          string         `json:"-" db:"user_id"`
+	EmailImmediate  bool           `json:"email_immediate" db:"email_immediate"`
+	PushEnabled     bool           `json:"push_enabled" db:"push_enabled"`
+	DigestFrequency string         `json:"digest_frequency" db:"digest_frequency"`
+	DigestParks     pq.StringArray `json:"digest_parks" db:"digest_parks"` // Parks with recent memos when empty
+	DigestHour      int            `json:"digest_hour" db:"digest_hour"`   // Local hour, 0-23
+	Timezone        string         `json:"timezone" db:"timezone"`         // IANA name
+	NextDigestAt    *time.Time     `json:"next_digest_at" db:"next_digest_at"`
+	LastDigestAt    *time.Time     `json:"last_digest_at" db:"last_digest_at"`
+}
+
+// DefaultNotificationPreferences returns the settings of users who have not
+// changed them
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:          userID,
+		EmailImmediate:  true,
+		PushEnabled:     true,
+		DigestFrequency: DigestOff,
+		DigestParks:     pq.StringArray{},
+		DigestHour:      7,
+		Timezone:        "UTC",
+	}
+}
+
+// NextDigestAfter returns when the digest after t is due: the next digest
+// hour in the user's time zone, on a Monday for weekly digests. It returns
+// nil when digests are off.
+func (p *NotificationPreferences) NextDigestAfter(t time.Time) *time.Time {
+	if p.DigestFrequency != DigestDaily && p.DigestFrequency != DigestWeekly {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), p.DigestHour, 0, 0, 0, loc)
+
+	step := 1
+	if p.DigestFrequency == DigestWeekly {
+		step = 7
+		next = next.AddDate(0, 0, (8-int(next.Weekday()))%7)
+	}
+	if !next.After(t) {
+		next = next.AddDate(0, 0, step)
+	}
+
+	next = next.UTC()
+	return &next
+}
+
+// UpdateNotificationPreferencesRequest represents the request to change
+// notification preferences. Omitted fields are left unchanged.
+type UpdateNotificationPreferencesRequest struct {
+	EmailImmediate  *bool    `json:"email_immediate"`
+	PushEnabled     *bool    `json:"push_enabled"`
+	DigestFrequency *string  `json:"digest_frequency"`
+	DigestParks     []string `json:"digest_parks"`
+	DigestHour      *int     `json:"digest_hour"`
+	Timezone        *string  `json:"timezone"`
+}
+
+// EmailJobPayload is the payload of jobs that email a notification
+type EmailJobPayload struct {
+	UserID string `json:"user_id"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// PushJobPayload is the payload of jobs that push a notification to a user's
+// devices. Data is passed to the app as is, e.g. a memo_id to open.
+type PushJobPayload struct {
+	UserID string            `json:"user_id"`
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+// DigestJobPayload is the payload of jobs that email a digest of the memos
+// created between PeriodStart and PeriodEnd
+type DigestJobPayload struct {
+	UserID      string    `json:"user_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Presence message types
+const (
+	// Sent by clients
+	PresenceMessagePosition = "position" // Share the sender's position
+	PresenceMessageStop     = "stop"     // Stop sharing the sender's position
+
+	// Sent by the server
+	PresenceMessageSnapshot = "snapshot" // Members visible when connecting
+	PresenceMessageLeft     = "left"     // A member stopped sharing or moved out of view
+	PresenceMessageError    = "error"    // A client message was rejected
+)
+
+// CrewPosition is the latest position a crew member shared
+type CrewPosition struct {
+	UserID     string    `json:"user_id" db:"user_id"`
+	UserName   string    `json:"user_name" db:"user_name"`
+	UserColor  string    `json:"user_color" db:"user_color"` // Marker color
+	Department *string   `json:"department" db:"department"`
+	ParkName   *string   `json:"park_name" db:"park_name"`
+	Latitude   float64   `json:"latitude" db:"latitude"`
+	Longitude  float64   `json:"longitude" db:"longitude"`
+	Accuracy   *float64  `json:"accuracy,omitempty" db:"accuracy"` // Meters
+	Heading    *float64  `json:"heading,omitempty" db:"heading"`   // Degrees clockwise from north
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CrewPositionChange is a change to crew_positions announced by the database.
+// Position is nil when the member stopped sharing, Previous when they started.
+type CrewPositionChange struct {
+	Position *CrewPosition `json:"position"`
+	Previous *CrewPosition `json:"previous"`
+}
+
+// PresenceClientMessage is a message sent by a presence client
+type PresenceClientMessage struct {
+	Type      string   `json:"type"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Accuracy  *float64 `json:"accuracy"`
+	Heading   *float64 `json:"heading"`
+	ParkName  *string  `json:"park_name"`
+}
+
+// PresenceServerMessage is a position, left or error message sent to
+// presence clients
+type PresenceServerMessage struct {
+	Type     string        `json:"type"`
+	Position *CrewPosition `json:"position,omitempty"` // position
+	UserID   string        `json:"user_id,omitempty"`  // left
+	Message  string        `json:"message,omitempty"`  // error
+}
+
+// PresenceSnapshot lists the members visible to a client when it connects
+type PresenceSnapshot struct {
+	Type    string          `json:"type"`
+	Members []*CrewPosition `json:"members"`
+}
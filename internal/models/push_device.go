@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+// Push providers. iOS apps that use the Firebase SDK register FCM tokens;
+// others register raw APNs device tokens.
+const (
+	PushProviderAPNs = "apns"
+	PushProviderFCM  = "fcm"
+)
+
+// PushDevice is a device that receives a user's push notifications
+type PushDevice struct {
+	DeviceID   uuid.UUID `json:"device_id" db:"device_id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	Platform   string    `json:"platform" db:"platform"`
+	Provider   string    `json:"provider" db:"provider"`
+	Token      string    `json:"-" db:"token"`
+	AppVersion *string   `json:"app_version" db:"app_version"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RegisterDeviceRequest represents the request to register a device for push
+// notifications
+type RegisterDeviceRequest struct {
+	Platform   string  `json:"platform" binding:"required,oneof=ios android"`
+	Provider   string  `json:"provider" binding:"omitempty,oneof=apns fcm"` // apns on iOS, fcm on Android when omitted
+	Token      string  `json:"token" binding:"required,max=4096"`
+	AppVersion *string `json:"app_version" binding:"omitempty,max=50"`
+}
+
+// DevicesListResponse represents a user's registered devices
+type DevicesListResponse struct {
+	Devices []PushDevice `json:"devices"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report statuses
+const (
+	ReportStatusPending    = "pending"
+	ReportStatusProcessing = "processing"
+	ReportStatusCompleted  = "completed"
+	ReportStatusFailed     = "failed"
+)
+
+// Report is a PDF field report of the memos matching a filter
+type Report struct {
+	ReportID    uuid.UUID  `json:"report_id" db:"report_id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Title       string     `json:"title" db:"title"`
+	Filter      MemoFilter `json:"filter" db:"filter"`
+	Timezone    string     `json:"timezone" db:"timezone"` // IANA time zone times are shown in
+	Status      string     `json:"status" db:"status"`
+	StorageKey  *string    `json:"-" db:"storage_key"`
+	MemoCount   *int       `json:"memo_count" db:"memo_count"`
+	SizeBytes   *int64     `json:"size_bytes" db:"size_bytes"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+// CreateReportRequest represents the request to generate a report
+type CreateReportRequest struct {
+	Title    string     `json:"title" binding:"required,max=200"`
+	Filter   MemoFilter `json:"filter"`
+	Timezone string     `json:"timezone"` // Defaults to UTC
+}
+
+// ReportsListResponse represents a page of the current user's reports
+type ReportsListResponse struct {
+	Reports    []Report           `json:"reports"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
+// ReportJobPayload is the payload of report generation jobs
+type ReportJobPayload struct {
+	ReportID string `json:"report_id"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EditorTranscription is the editor of revisions made by applying a server
+// transcript
+const EditorTranscription = "system:transcription"
+
+// MemoRevision represents a snapshot of a memo's editable fields
+type MemoRevision struct {
+	RevisionID     uuid.UUID `json:"revision_id" db:"revision_id"`
+	MemoID         uuid.UUID `json:"memo_id" db:"memo_id"`
+	RevisionNumber int       `json:"revision_number" db:"revision_number"`
+	Title          *string   `json:"title" db:"title"`
+	Text           string    `json:"text" db:"text"`
+	ParkName       *string   `json:"park_name" db:"park_name"`
+	Latitude       *float64  `json:"latitude" db:"latitude"`
+	Longitude      *float64  `json:"longitude" db:"longitude"`
+	EditedBy       *string   `json:"edited_by" db:"edited_by"`
+	EditedByName   *string   `json:"edited_by_name" db:"edited_by_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// RevisionsListResponse represents the revision history of a memo
+type RevisionsListResponse struct {
+	MemoID    uuid.UUID      `json:"memo_id"`
+	Revisions []MemoRevision `json:"revisions"`
+}
+
+// FieldChange describes a single field that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffOp is one segment of a word-level text diff
+type DiffOp struct {
+	Op   string `json:"op"` // "equal", "insert" or "delete"
+	Text string `json:"text"`
+}
+
+// RevisionDiffResponse represents the differences between two revisions
+type RevisionDiffResponse struct {
+	MemoID   uuid.UUID     `json:"memo_id"`
+	From     MemoRevision  `json:"from"`
+	To       MemoRevision  `json:"to"`
+	Changes  []FieldChange `json:"changes"`
+	TextDiff []DiffOp      `json:"text_diff"`
+}
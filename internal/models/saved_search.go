@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearch is a stored memo filter whose owner is notified when a new memo
+// matches it
+type SavedSearch struct {
+	SearchID      uuid.UUID  `json:"search_id" db:"search_id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Name          string     `json:"name" db:"name"`
+	Filter        MemoFilter `json:"filter" db:"filter"`
+	NotifyInApp   bool       `json:"notify_in_app" db:"notify_in_app"`
+	NotifyEmail   bool       `json:"notify_email" db:"notify_email"`
+	NotifyPush    bool       `json:"notify_push" db:"notify_push"`
+	WebhookURL    *string    `json:"webhook_url" db:"webhook_url"`
+	WebhookSecret *string    `json:"webhook_secret,omitempty" db:"webhook_secret"` // Generated with the webhook
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateSavedSearchRequest represents the request to save a search
+type CreateSavedSearchRequest struct {
+	Name        string     `json:"name" binding:"required,max=200"`
+	Filter      MemoFilter `json:"filter"`
+	NotifyInApp *bool      `json:"notify_in_app"` // Defaults to true
+	NotifyEmail bool       `json:"notify_email"`
+	NotifyPush  bool       `json:"notify_push"`
+	WebhookURL  *string    `json:"webhook_url"`
+}
+
+// UpdateSavedSearchRequest represents the request to update a saved search.
+// Omitted fields are left unchanged; an empty webhook_url removes the webhook.
+type UpdateSavedSearchRequest struct {
+	Name        *string     `json:"name" binding:"omitempty,max=200"`
+	Filter      *MemoFilter `json:"filter"`
+	NotifyInApp *bool       `json:"notify_in_app"`
+	NotifyEmail *bool       `json:"notify_email"`
+	NotifyPush  *bool       `json:"notify_push"`
+	WebhookURL  *string     `json:"webhook_url"`
+}
+
+// SavedSearchesListResponse represents a user's saved searches
+type SavedSearchesListResponse struct {
+	SavedSearches []SavedSearch `json:"saved_searches"`
+}
+
+// SavedSearchMatchPayload is the payload of jobs that deliver a saved search
+// match to an external channel
+type SavedSearchMatchPayload struct {
+	SearchID string `json:"search_id"`
+	MemoID   string `json:"memo_id"`
+}
+
+// SavedSearchWebhookEvent is the body POSTed to a saved search's webhook
+type SavedSearchWebhookEvent struct {
+	Event       string          `json:"event"`
+	SavedSearch SavedSearchInfo `json:"saved_search"`
+	Memo        *Memo           `json:"memo"`
+	SentAt      time.Time       `json:"sent_at"`
+}
+
+// SavedSearchInfo identifies a saved search in notifications
+type SavedSearchInfo struct {
+	SearchID uuid.UUID `json:"search_id"`
+	Name     string    `json:"name"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+// Stats bucket intervals. Weeks start on Monday.
+const (
+	StatsIntervalDay   = "day"
+	StatsIntervalWeek  = "week"
+	StatsIntervalMonth = "month"
+)
+
+// Stats groupings
+const (
+	StatsGroupPark       = "park"
+	StatsGroupUser       = "user"
+	StatsGroupDepartment = "department"
+	StatsGroupTag        = "tag"
+	StatsGroupCategory   = "category"
+	StatsGroupStatus     = "status"
+)
+
+// StatsGroupings are the values accepted by group_by
+var StatsGroupings = []string{
+	StatsGroupPark,
+	StatsGroupUser,
+	StatsGroupDepartment,
+	StatsGroupTag,
+	StatsGroupCategory,
+	StatsGroupStatus,
+}
+
+// StatsSummary totals the memos matching a filter
+type StatsSummary struct {
+	TotalMemos   int        `json:"total_memos"`
+	AudioMemos   int        `json:"audio_memos"`
+	AudioMinutes float64    `json:"audio_minutes"`
+	Contributors int        `json:"contributors"`
+	Parks        int        `json:"parks"`
+	FirstMemoAt  *time.Time `json:"first_memo_at"`
+	LastMemoAt   *time.Time `json:"last_memo_at"`
+
+	// Memos currently resolved, and their average hours from being recorded
+	// to being resolved; null without any
+	ResolvedMemos      int      `json:"resolved_memos"`
+	AvgResolutionHours *float64 `json:"avg_resolution_hours"`
+}
+
+// StatsBucket counts the memos created in one interval, starting at Start in
+// the requested time zone
+type StatsBucket struct {
+	Start        time.Time `json:"start"`
+	Count        int       `json:"count"`
+	AudioMinutes float64   `json:"audio_minutes"`
+}
+
+// StatsSeries is the time series of one group
+type StatsSeries struct {
+	Key     string        `json:"key"`
+	Label   *string       `json:"label,omitempty"` // Display name for users
+	Count   int           `json:"count"`
+	Buckets []StatsBucket `json:"buckets"`
+}
+
+// StatsTimeSeriesResponse represents memo counts over time, in total and,
+// when grouped, for the largest groups
+type StatsTimeSeriesResponse struct {
+	Interval string        `json:"interval"`
+	Timezone string        `json:"timezone"`
+	GroupBy  string        `json:"group_by,omitempty"`
+	Buckets  []StatsBucket `json:"buckets"`
+	Series   []StatsSeries `json:"series,omitempty"`
+}
+
+// StatsGroup counts the memos of one group
+type StatsGroup struct {
+	Key          string  `json:"key" db:"key"`
+	Label        *string `json:"label,omitempty" db:"label"` // Display name for users
+	Count        int     `json:"count" db:"count"`
+	AudioMinutes float64 `json:"audio_minutes" db:"audio_minutes"`
+
+	// Average hours from being recorded to being resolved, over the group's
+	// resolved memos; null without any
+	AvgResolutionHours *float64 `json:"avg_resolution_hours" db:"avg_resolution_hours"`
+}
+
+// StatsBreakdownResponse represents memo counts per group, largest first
+type StatsBreakdownResponse struct {
+	GroupBy string       `json:"group_by"`
+	Groups  []StatsGroup `json:"groups"`
+}
+
+// StatsBucketStarts returns the start of every interval bucket from the one
+// containing start up to, but excluding, end, in loc
+func StatsBucketStarts(start, end time.Time, interval string, loc *time.Location) []time.Time {
+	starts := []time.Time{}
+	for bucket := statsBucketStart(start, interval, loc); bucket.Before(end); bucket = statsNextBucket(bucket, interval) {
+		starts = append(starts, bucket)
+	}
+	return starts
+}
+
+// statsBucketStart returns the start of the bucket containing t, matching
+// Postgres date_trunc
+func statsBucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	local := t.In(loc)
+	switch interval {
+	case StatsIntervalMonth:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+	case StatsIntervalWeek:
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// statsNextBucket returns the start of the bucket after the one at start
+func statsNextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case StatsIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case StatsIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TagSuggestion holds the tags and category suggested for a memo from its text
+type TagSuggestion struct {
+	MemoID     uuid.UUID      `json:"memo_id" db:"memo_id"`
+	Tags       pq.StringArray `json:"tags" db:"tags"`
+	Category   *string        `json:"category" db:"category"`
+	Keywords   pq.StringArray `json:"keywords" db:"keywords"` // Phrases found in the text
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	AcceptedAt *time.Time     `json:"accepted_at" db:"accepted_at"`
+}
+
+// AcceptSuggestionsRequest represents the request to apply suggested tags.
+// An empty body accepts every suggested tag and the category.
+type AcceptSuggestionsRequest struct {
+	Tags           []string `json:"tags"`            // Subset of the suggested tags to accept
+	AcceptCategory *bool    `json:"accept_category"` // Defaults to true
+}
+
+// MemoTagsResponse represents a memo's tags after accepting suggestions
+type MemoTagsResponse struct {
+	MemoID   uuid.UUID `json:"memo_id"`
+	Tags     []string  `json:"tags"`
+	Category *string   `json:"category"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transcription statuses
+const (
+	TranscriptionStatusPending   = "pending"
+	TranscriptionStatusCompleted = "completed"
+	TranscriptionStatusFailed    = "failed"
+	TranscriptionStatusSkipped   = "skipped"
+)
+
+// TranscriptWord is a single recognized word and where it occurs in the recording
+type TranscriptWord struct {
+	Word       string  `json:"word"`
+	Start      float64 `json:"start"` // Seconds from the start of the recording
+	End        float64 `json:"end"`
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// TranscriptWords is stored as a JSONB array
+type TranscriptWords []TranscriptWord
+
+// Value implements driver.Valuer
+func (w TranscriptWords) Value() (driver.Value, error) {
+	if w == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(w)
+}
+
+// Scan implements sql.Scanner
+func (w *TranscriptWords) Scan(src interface{}) error {
+	return scanJSON(src, w)
+}
+
+// TranscriptSegment is a sentence-like span of a transcript
+type TranscriptSegment struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"` // Seconds from the start of the recording
+	End   float64 `json:"end"`
+}
+
+// TranscriptSegments is stored as a JSONB array
+type TranscriptSegments []TranscriptSegment
+
+// Value implements driver.Valuer
+func (s TranscriptSegments) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner
+func (s *TranscriptSegments) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
+
+// TranscriptMatch locates a search hit in a memo's recording
+type TranscriptMatch struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"` // Seconds from the start of the recording
+	End   float64 `json:"end"`
+}
+
+// scanJSON decodes a JSONB column into dest. NULL leaves dest untouched.
+func scanJSON(src interface{}, dest interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported type for JSON column: %T", src)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Transcript is a server-generated transcript of a memo's recording
+type Transcript struct {
+	MemoID     uuid.UUID          `json:"memo_id" db:"memo_id"`
+	Engine     string             `json:"engine" db:"engine"`
+	Language   *string            `json:"language" db:"language"`
+	Text       string             `json:"text" db:"text"`
+	Words      TranscriptWords    `json:"words" db:"words"`
+	Segments   TranscriptSegments `json:"segments" db:"segments"`
+	Confidence *float64           `json:"confidence" db:"confidence"` // Mean word confidence
+	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
+}
+
+// TranscribeJobPayload is the payload of audio.transcribe jobs
+type TranscribeJobPayload struct {
+	MemoID string `json:"memo_id"`
+
+	// ReplaceText replaces the device transcript with the server one, as long
+	// as the memo has not been edited since it was created
+	ReplaceText bool `json:"replace_text"`
+
+	// Requested marks a transcription the author asked for after creating
+	// the memo. It replaces the text even if the memo was edited since.
+	Requested bool `json:"requested,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload represents a resumable (tus-style) audio upload
+type Upload struct {
+	UploadID     uuid.UUID  `json:"upload_id" db:"upload_id"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	ObjectKey    string     `json:"file_path" db:"object_key"`
+	Filename     *string    `json:"filename" db:"filename"`
+	ContentType  string     `json:"content_type" db:"content_type"`
+	UploadLength int64      `json:"upload_length" db:"upload_length"`
+	UploadOffset int64      `json:"upload_offset" db:"upload_offset"`
+	LockedUntil  *time.Time `json:"-" db:"locked_until"`
+	CompletedAt  *time.Time `json:"completed_at" db:"completed_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsComplete reports whether every byte of the upload has been received
+func (u *Upload) IsComplete() bool {
+	return u.UploadOffset >= u.UploadLength
+}
@@ -2,6 +2,14 @@ package models
 
 import "time"
 
+// User roles. Members record memos, supervisors oversee crews and admins
+// manage roles and read the audit log.
+const (
+	RoleMember     = "member"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
 // User represents a user in the system
 type User struct {
 	UserID      string    `json:"user_id" db:"user_id"`
@@ -9,11 +17,33 @@ type User struct {
 	DisplayName string    `json:"display_name" db:"display_name"`
 	Department  string    `json:"department" db:"department"`
 	Color       string    `json:"color" db:"color"` // Hex color code (e.g., #FF5733)
+	Role        string    `json:"role" db:"role"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// IsSupervisor reports whether the user oversees crews, as a supervisor or an admin
+func (u *User) IsSupervisor() bool {
+	return u.Role == RoleSupervisor || u.Role == RoleAdmin
+}
+
 // CreateUserRequest represents the request to create a user
 type CreateUserRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
 	Department  string `json:"department"`
 }
+
+// UserParks lists the parks a user is assigned to besides their department
+type UserParks struct {
+	UserID    string   `json:"user_id"`
+	ParkNames []string `json:"park_names"`
+}
+
+// UpdateParksRequest represents the request to replace a user's assigned parks
+type UpdateParksRequest struct {
+	ParkNames []string `json:"park_names" binding:"required"`
+}
+
+// UpdateRoleRequest represents the request to change a user's role
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=member supervisor admin"`
+}
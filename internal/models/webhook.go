@@ -0,0 +1,133 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+// JobTypeDeliverWebhook delivers one webhook event; jobs of this type are
+// queued by the database when memo events are recorded
+const JobTypeDeliverWebhook = "webhook.deliver"
+
+// WebhookMaxAttempts is how many times a delivery is tried before it fails,
+// about three hours with the queue's backoff
+const WebhookMaxAttempts = 10
+
+// WebhookEventTypes are the events a webhook can subscribe to
+var WebhookEventTypes = []string{
+	MemoEventCreated,
+	MemoEventUpdated,
+	MemoEventDeleted,
+	MemoEventStatusChanged,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends memo events to a URL
+type WebhookSubscription struct {
+	SubscriptionID uuid.UUID      `json:"subscription_id" db:"subscription_id"`
+	UserID         string         `json:"user_id" db:"user_id"`
+	URL            string         `json:"url" db:"url"`
+	Description    *string        `json:"description" db:"description"`
+	EventTypes     pq.StringArray `json:"events" db:"event_types"`
+	Secret         string         `json:"-" db:"secret"`
+	Active         bool           `json:"active" db:"active"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// CreateWebhookRequest represents the request to subscribe a webhook
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Events      []string `json:"events" binding:"required"`
+	Secret      *string  `json:"secret" binding:"omitempty,min=16,max=100"` // Generated when omitted
+	Active      *bool    `json:"active"`                                    // Defaults to true
+}
+
+// UpdateWebhookRequest represents the request to update a webhook. Omitted
+// fields are left unchanged.
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description" binding:"omitempty,max=200"`
+	Events      []string `json:"events"`
+	Active      *bool    `json:"active"`
+}
+
+// CreateWebhookResponse is a new webhook with its signing secret, which is
+// not returned again
+type CreateWebhookResponse struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhooksListResponse represents a user's webhooks
+type WebhooksListResponse struct {
+	Webhooks []WebhookSubscription `json:"webhooks"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	DeliveryID     uuid.UUID       `json:"delivery_id" db:"delivery_id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id" db:"subscription_id"`
+	EventID        int64           `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event" db:"event_type"`
+	MemoID         uuid.UUID       `json:"memo_id" db:"memo_id"`
+	Changes        *types.JSONText `json:"-" db:"changes"`
+	OccurredAt     time.Time       `json:"occurred_at" db:"occurred_at"`
+	Payload        *types.JSONText `json:"payload,omitempty" db:"payload"` // Set once attempted
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	LastStatusCode *int            `json:"last_status_code" db:"last_status_code"`
+	LastError      *string         `json:"last_error" db:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at" db:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+
+	AttemptLog []WebhookDeliveryAttempt `json:"attempt_log,omitempty" db:"-"`
+}
+
+// WebhookDeliveryAttempt is one request made for a delivery
+type WebhookDeliveryAttempt struct {
+	AttemptID   int64     `json:"-" db:"attempt_id"`
+	DeliveryID  uuid.UUID `json:"-" db:"delivery_id"`
+	StatusCode  *int      `json:"status_code" db:"status_code"`
+	Error       *string   `json:"error" db:"error"`
+	DurationMS  int       `json:"duration_ms" db:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// WebhookDeliveriesResponse represents a page of a webhook's delivery log
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery  `json:"deliveries"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
+// WebhookDeliveryPayload is the payload of webhook delivery jobs
+type WebhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// WebhookEvent is the body POSTed to a webhook
+type WebhookEvent struct {
+	DeliveryID uuid.UUID        `json:"delivery_id"`
+	Event      string           `json:"event"`
+	EventID    int64            `json:"event_id"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       WebhookEventData `json:"data"`
+}
+
+// WebhookEventData describes the memo a webhook event is about
+type WebhookEventData struct {
+	MemoID  uuid.UUID       `json:"memo_id"`
+	Memo    *Memo           `json:"memo,omitempty"`    // Absent for memo.deleted
+	Changes *types.JSONText `json:"changes,omitempty"` // For status.changed
+}
@@ -0,0 +1,255 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+const (
+	// memoEventsChannel is the NOTIFY channel the memo_events trigger uses
+	memoEventsChannel = "memo_events"
+
+	// memoEventRetention is how long events are kept for clients to resume
+	memoEventRetention = 24 * time.Hour
+
+	// subscriberBuffer is the number of events queued per subscriber before
+	// it is considered too slow and dropped
+	subscriberBuffer = 64
+
+	// catchUpLimit is how many events are fetched per batch when catching up
+	catchUpLimit = 1000
+)
+
+// MemoFeed fans memo change events out to subscribers. It LISTENs for the
+// NOTIFY sent by the memo_events trigger, so changes made through any API
+// instance, or by background jobs, reach the clients connected to this one.
+type MemoFeed struct {
+	databaseURL string
+	eventRepo   *repository.MemoEventRepository
+	memoRepo    *repository.MemoRepository
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	lastID      int64 // Newest event dispatched
+}
+
+// Subscription receives the events of a MemoFeed until it is closed
+type Subscription struct {
+	// Events delivers events in the order they were dispatched
+	Events <-chan *models.MemoEvent
+	// Dropped is closed if the subscriber fell too far behind; it should
+	// reconnect and resume from the last event it received
+	Dropped <-chan struct{}
+
+	feed    *MemoFeed
+	events  chan *models.MemoEvent
+	dropped chan struct{}
+}
+
+// NewMemoFeed creates a new memo feed
+func NewMemoFeed(databaseURL string, eventRepo *repository.MemoEventRepository, memoRepo *repository.MemoRepository) *MemoFeed {
+	return &MemoFeed{
+		databaseURL: databaseURL,
+		eventRepo:   eventRepo,
+		memoRepo:    memoRepo,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Start begins listening for events until ctx is cancelled
+func (f *MemoFeed) Start(ctx context.Context) error {
+	_, newest, err := f.eventRepo.Bounds(ctx)
+	if err != nil {
+		return err
+	}
+	f.lastID = newest
+
+	listener := pq.NewListener(f.databaseURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Memo feed listener: %v", err)
+		}
+	})
+	if err := listener.Listen(memoEventsChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("error listening for memo events: %v", err)
+	}
+
+	go f.run(ctx, listener)
+	return nil
+}
+
+// Subscribe registers a new subscriber for live events
+func (f *MemoFeed) Subscribe() *Subscription {
+	sub := &Subscription{
+		feed:    f,
+		events:  make(chan *models.MemoEvent, subscriberBuffer),
+		dropped: make(chan struct{}),
+	}
+	sub.Events = sub.events
+	sub.Dropped = sub.dropped
+
+	f.mu.Lock()
+	f.subscribers[sub] = struct{}{}
+	f.mu.Unlock()
+
+	return sub
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	delete(s.feed.subscribers, s)
+	s.feed.mu.Unlock()
+}
+
+// Replay returns up to limit retained events after afterID with their memos
+// attached. It returns false when events after afterID have already been
+// pruned or more than limit are pending, in which case the client should
+// reload instead of resuming.
+func (f *MemoFeed) Replay(ctx context.Context, afterID int64, limit int) ([]*models.MemoEvent, bool, error) {
+	oldest, _, err := f.eventRepo.Bounds(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if oldest > 0 && afterID < oldest-1 {
+		return nil, false, nil
+	}
+	if oldest == 0 {
+		// Everything was pruned; only a client that saw the latest event
+		// has nothing to miss
+		f.mu.Lock()
+		lastID := f.lastID
+		f.mu.Unlock()
+		return nil, afterID >= lastID, nil
+	}
+
+	events, err := f.eventRepo.ListAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(events) > limit {
+		return nil, false, nil
+	}
+
+	for _, event := range events {
+		if err := f.attachMemo(ctx, event); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return events, true, nil
+}
+
+// run dispatches notified events and prunes old ones until ctx is cancelled
+func (f *MemoFeed) run(ctx context.Context, listener *pq.Listener) {
+	defer listener.Close()
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-listener.Notify:
+			if n == nil {
+				// Reconnected; notifications sent meanwhile were lost
+				f.catchUp(ctx)
+				continue
+			}
+			eventID, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				log.Printf("Memo feed: invalid event ID %q", n.Extra)
+				continue
+			}
+			// Read everything after the last event dispatched rather than
+			// just this one, so any event missed in between goes out first
+			// and subscribers always see IDs in order
+			f.mu.Lock()
+			seen := eventID <= f.lastID
+			f.mu.Unlock()
+			if !seen {
+				f.catchUp(ctx)
+			}
+
+		case <-ping.C:
+			// Detect dead connections while the feed is quiet
+			go listener.Ping()
+
+		case <-prune.C:
+			if _, err := f.eventRepo.DeleteOlderThan(ctx, memoEventRetention); err != nil {
+				log.Printf("Memo feed: %v", err)
+			}
+		}
+	}
+}
+
+// catchUp dispatches the events written since the last one dispatched,
+// a batch at a time until a batch comes back short
+func (f *MemoFeed) catchUp(ctx context.Context) {
+	for {
+		f.mu.Lock()
+		lastID := f.lastID
+		f.mu.Unlock()
+
+		events, err := f.eventRepo.ListAfter(ctx, lastID, catchUpLimit)
+		if err != nil {
+			log.Printf("Memo feed: %v", err)
+			return
+		}
+		for _, event := range events {
+			f.dispatch(ctx, event)
+		}
+		if len(events) < catchUpLimit {
+			return
+		}
+	}
+}
+
+// dispatch attaches the memo to an event and queues it for every subscriber,
+// dropping those whose queue is full
+func (f *MemoFeed) dispatch(ctx context.Context, event *models.MemoEvent) {
+	if err := f.attachMemo(ctx, event); err != nil {
+		log.Printf("Memo feed: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if event.EventID > f.lastID {
+		f.lastID = event.EventID
+	}
+	for sub := range f.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(f.subscribers, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// attachMemo loads the current memo for created and updated events
+func (f *MemoFeed) attachMemo(ctx context.Context, event *models.MemoEvent) error {
+	if event.EventType == models.MemoEventDeleted {
+		return nil
+	}
+
+	memo, err := f.memoRepo.GetByID(ctx, event.MemoID)
+	if err != nil {
+		return err
+	}
+	event.Memo = memo
+	return nil
+}
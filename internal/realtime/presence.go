@@ -0,0 +1,231 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/repository"
+)
+
+const (
+	// crewPositionsChannel is the NOTIFY channel the crew_positions trigger uses
+	crewPositionsChannel = "crew_positions"
+
+	// PositionStaleAfter is how long a position is shown without an update.
+	// Positions left behind by dropped connections are removed after it.
+	PositionStaleAfter = 10 * time.Minute
+
+	// presenceBuffer is the number of messages queued per client before it
+	// is considered too slow and dropped
+	presenceBuffer = 64
+)
+
+// PresenceHub relays crew position changes to the members allowed to see
+// them. Positions are stored in crew_positions and each change is announced
+// with NOTIFY, so members connected to different API instances see each
+// other.
+type PresenceHub struct {
+	databaseURL  string
+	positionRepo *repository.CrewPositionRepository
+
+	mu      sync.Mutex
+	clients map[*PresenceClient]struct{}
+}
+
+// PresenceClient receives the position changes visible to one connection
+// until it is closed
+type PresenceClient struct {
+	UserID     string
+	Department string   // Members of this department are visible
+	ParkNames  []string // Members in these parks are visible
+
+	// Messages delivers PresenceServerMessage and PresenceSnapshot values
+	Messages <-chan interface{}
+	// Dropped is closed if the client fell too far behind
+	Dropped <-chan struct{}
+
+	hub      *PresenceHub
+	messages chan interface{}
+	dropped  chan struct{}
+}
+
+// NewPresenceHub creates a new presence hub
+func NewPresenceHub(databaseURL string, positionRepo *repository.CrewPositionRepository) *PresenceHub {
+	return &PresenceHub{
+		databaseURL:  databaseURL,
+		positionRepo: positionRepo,
+		clients:      map[*PresenceClient]struct{}{},
+	}
+}
+
+// Start begins listening for position changes until ctx is cancelled
+func (h *PresenceHub) Start(ctx context.Context) error {
+	listener := pq.NewListener(h.databaseURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Presence listener: %v", err)
+		}
+	})
+	if err := listener.Listen(crewPositionsChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("error listening for crew positions: %v", err)
+	}
+
+	go h.run(ctx, listener)
+	return nil
+}
+
+// Join registers a client that sees the members of department and of the
+// parks. Callers should send it a Snapshot after joining.
+func (h *PresenceHub) Join(userID, department string, parkNames []string) *PresenceClient {
+	client := &PresenceClient{
+		UserID:     userID,
+		Department: department,
+		ParkNames:  parkNames,
+		hub:        h,
+		messages:   make(chan interface{}, presenceBuffer),
+		dropped:    make(chan struct{}),
+	}
+	client.Messages = client.messages
+	client.Dropped = client.dropped
+
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+
+	return client
+}
+
+// Close unregisters the client
+func (c *PresenceClient) Close() {
+	c.hub.mu.Lock()
+	delete(c.hub.clients, c)
+	c.hub.mu.Unlock()
+}
+
+// Snapshot returns the positions currently visible to the client
+func (h *PresenceHub) Snapshot(ctx context.Context, client *PresenceClient) (*models.PresenceSnapshot, error) {
+	members, err := h.positionRepo.ListVisible(ctx, client.Department, client.ParkNames, PositionStaleAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PresenceSnapshot{Type: models.PresenceMessageSnapshot, Members: members}, nil
+}
+
+// CanSee reports whether a position is visible to the client
+func (c *PresenceClient) CanSee(position *models.CrewPosition) bool {
+	if c.Department != "" && position.Department != nil && *position.Department == c.Department {
+		return true
+	}
+	if position.ParkName != nil {
+		for _, park := range c.ParkNames {
+			if park == *position.ParkName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// run dispatches announced changes and prunes stale positions until ctx is
+// cancelled
+func (h *PresenceHub) run(ctx context.Context, listener *pq.Listener) {
+	defer listener.Close()
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	prune := time.NewTicker(time.Minute)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case n := <-listener.Notify:
+			if n == nil {
+				// Reconnected; changes announced meanwhile were lost
+				h.resync(ctx)
+				continue
+			}
+			var change models.CrewPositionChange
+			if err := json.Unmarshal([]byte(n.Extra), &change); err != nil {
+				log.Printf("Presence: invalid notification: %v", err)
+				continue
+			}
+			h.dispatch(&change)
+
+		case <-ping.C:
+			// Detect dead connections while nobody is moving
+			go listener.Ping()
+
+		case <-prune.C:
+			if _, err := h.positionRepo.DeleteOlderThan(ctx, PositionStaleAfter); err != nil {
+				log.Printf("Presence: %v", err)
+			}
+		}
+	}
+}
+
+// dispatch sends a change to every client that could see the member before
+// or after it
+func (h *PresenceHub) dispatch(change *models.CrewPositionChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		switch {
+		case change.Position != nil && client.CanSee(change.Position):
+			h.send(client, &models.PresenceServerMessage{
+				Type:     models.PresenceMessagePosition,
+				Position: change.Position,
+			})
+		case change.Previous != nil && client.CanSee(change.Previous):
+			h.send(client, &models.PresenceServerMessage{
+				Type:   models.PresenceMessageLeft,
+				UserID: change.Previous.UserID,
+			})
+		}
+	}
+}
+
+// resync sends every client a fresh snapshot
+func (h *PresenceHub) resync(ctx context.Context) {
+	h.mu.Lock()
+	clients := make([]*PresenceClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		snapshot, err := h.Snapshot(ctx, client)
+		if err != nil {
+			log.Printf("Presence: %v", err)
+			return
+		}
+
+		h.mu.Lock()
+		if _, ok := h.clients[client]; ok {
+			h.send(client, snapshot)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// send queues a message for a client, dropping the client if its queue is
+// full. h.mu must be held.
+func (h *PresenceHub) send(client *PresenceClient, message interface{}) {
+	select {
+	case client.messages <- message:
+	default:
+		delete(h.clients, client)
+		close(client.dropped)
+	}
+}
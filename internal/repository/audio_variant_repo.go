@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// AudioVariantRepository handles transcoded audio variant database operations
+type AudioVariantRepository struct {
+	db *sqlx.DB
+}
+
+// NewAudioVariantRepository creates a new audio variant repository
+func NewAudioVariantRepository(db *sqlx.DB) *AudioVariantRepository {
+	return &AudioVariantRepository{db: db}
+}
+
+// Upsert creates or replaces the variant of a memo in the given format
+func (r *AudioVariantRepository) Upsert(ctx context.Context, variant *models.AudioVariant) error {
+	query := `
+		INSERT INTO memo_audio_variants (
+			memo_id, format, content_type, audio_key, bitrate_kbps, size_bytes, loudness_normalized
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (memo_id, format) DO UPDATE SET
+			content_type = EXCLUDED.content_type,
+			audio_key = EXCLUDED.audio_key,
+			bitrate_kbps = EXCLUDED.bitrate_kbps,
+			size_bytes = EXCLUDED.size_bytes,
+			loudness_normalized = EXCLUDED.loudness_normalized,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING variant_id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		variant.MemoID,
+		variant.Format,
+		variant.ContentType,
+		variant.AudioKey,
+		variant.BitrateKbps,
+		variant.SizeBytes,
+		variant.LoudnessNormalized,
+	).Scan(&variant.VariantID, &variant.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving audio variant: %v", err)
+	}
+
+	return nil
+}
+
+// ListByMemo retrieves all variants of a memo
+func (r *AudioVariantRepository) ListByMemo(ctx context.Context, memoID uuid.UUID) ([]models.AudioVariant, error) {
+	return listAudioVariants(ctx, r.db, memoID)
+}
+
+// listAudioVariants is shared with MemoRepository, which embeds variants in memo details
+func listAudioVariants(ctx context.Context, q sqlx.QueryerContext, memoID uuid.UUID) ([]models.AudioVariant, error) {
+	query := `
+		SELECT
+			variant_id, memo_id, format, content_type, audio_key, bitrate_kbps, size_bytes,
+			loudness_normalized, created_at
+		FROM memo_audio_variants
+		WHERE memo_id = $1
+		ORDER BY format ASC
+	`
+
+	variants := []models.AudioVariant{}
+	if err := sqlx.SelectContext(ctx, q, &variants, query, memoID); err != nil {
+		return nil, fmt.Errorf("error listing audio variants: %v", err)
+	}
+
+	return variants, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// AuditRepository handles audit log database operations. The log is
+// append-only, so there is no way to change or delete an entry.
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record appends an entry to the audit log
+func (r *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	return recordAudit(ctx, r.db, entry)
+}
+
+// recordAudit appends an entry with q, so other repositories can audit a
+// change in the same transaction as the change itself
+func recordAudit(ctx context.Context, q sqlx.QueryerContext, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (
+			actor_id, action, target_type, target_id, before, after, metadata,
+			request_id, ip_address, user_agent, method, path
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING audit_id, occurred_at
+	`
+
+	if len(entry.Metadata) == 0 {
+		entry.Metadata = []byte("{}")
+	}
+
+	err := q.QueryRowxContext(
+		ctx,
+		query,
+		entry.ActorID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		entry.Before,
+		entry.After,
+		entry.Metadata,
+		entry.RequestID,
+		entry.IPAddress,
+		entry.UserAgent,
+		entry.Method,
+		entry.Path,
+	).Scan(&entry.AuditID, &entry.OccurredAt)
+
+	if err != nil {
+		return fmt.Errorf("error recording audit entry: %v", err)
+	}
+
+	return nil
+}
+
+// List retrieves a page of the audit log matching a filter, newest first,
+// and the total number of matching entries
+func (r *AuditRepository) List(ctx context.Context, filter *models.AuditFilter, page, limit int) ([]models.AuditEntry, int, error) {
+	args := &sqlArgs{}
+	conds := []string{}
+
+	if filter.ActorID != "" {
+		conds = append(conds, "a.actor_id = "+args.add(filter.ActorID))
+	}
+	if filter.Action != "" {
+		conds = append(conds, "a.action = "+args.add(filter.Action))
+	}
+	if filter.TargetType != "" {
+		conds = append(conds, "a.target_type = "+args.add(filter.TargetType))
+	}
+	if filter.TargetID != "" {
+		conds = append(conds, "a.target_id = "+args.add(filter.TargetID))
+	}
+	if filter.StartDate != nil {
+		conds = append(conds, "a.occurred_at >= "+args.add(*filter.StartDate))
+	}
+	if filter.EndDate != nil {
+		conds = append(conds, "a.occurred_at < "+args.add(*filter.EndDate))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM audit_log a ` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args.values...); err != nil {
+		return nil, 0, fmt.Errorf("error counting audit entries: %v", err)
+	}
+
+	query := `
+		SELECT
+			a.audit_id, a.occurred_at, a.actor_id, u.display_name AS actor_name, a.action,
+			a.target_type, a.target_id, a.before, a.after, a.metadata, a.request_id,
+			a.ip_address, a.user_agent, a.method, a.path
+		FROM audit_log a
+		LEFT JOIN users u ON u.user_id = a.actor_id
+		` + where + `
+		ORDER BY a.occurred_at DESC, a.audit_id DESC
+		LIMIT ` + args.add(limit) + ` OFFSET ` + args.add((page-1)*limit)
+
+	entries := []models.AuditEntry{}
+	if err := r.db.SelectContext(ctx, &entries, query, args.values...); err != nil {
+		return nil, 0, fmt.Errorf("error listing audit entries: %v", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// CrewPositionRepository handles crew position database operations
+type CrewPositionRepository struct {
+	db *sqlx.DB
+}
+
+// NewCrewPositionRepository creates a new crew position repository
+func NewCrewPositionRepository(db *sqlx.DB) *CrewPositionRepository {
+	return &CrewPositionRepository{db: db}
+}
+
+// Upsert saves a member's latest position
+func (r *CrewPositionRepository) Upsert(ctx context.Context, position *models.CrewPosition) error {
+	query := `
+		INSERT INTO crew_positions (user_id, user_name, user_color, department, park_name, latitude, longitude, accuracy, heading)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			user_name = EXCLUDED.user_name,
+			user_color = EXCLUDED.user_color,
+			department = EXCLUDED.department,
+			park_name = EXCLUDED.park_name,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			accuracy = EXCLUDED.accuracy,
+			heading = EXCLUDED.heading,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		position.UserID,
+		position.UserName,
+		position.UserColor,
+		position.Department,
+		position.ParkName,
+		position.Latitude,
+		position.Longitude,
+		position.Accuracy,
+		position.Heading,
+	).Scan(&position.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving crew position: %v", err)
+	}
+
+	return nil
+}
+
+// Delete removes a member's position
+func (r *CrewPositionRepository) Delete(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM crew_positions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting crew position: %v", err)
+	}
+	return nil
+}
+
+// ListVisible retrieves the positions updated within maxAge of members in the
+// department or in any of the parks
+func (r *CrewPositionRepository) ListVisible(ctx context.Context, department string, parkNames []string, maxAge time.Duration) ([]*models.CrewPosition, error) {
+	positions := []*models.CrewPosition{}
+	query := `
+		SELECT user_id, user_name, user_color, department, park_name, latitude, longitude, accuracy, heading, updated_at
+		FROM crew_positions
+		WHERE updated_at >= CURRENT_TIMESTAMP - make_interval(secs => $1)
+		  AND ((department = $2 AND $2 <> '') OR park_name = ANY($3))
+		ORDER BY user_name
+	`
+
+	if err := r.db.SelectContext(ctx, &positions, query, maxAge.Seconds(), department, pq.Array(parkNames)); err != nil {
+		return nil, fmt.Errorf("error listing crew positions: %v", err)
+	}
+
+	return positions, nil
+}
+
+// DeleteOlderThan removes positions not updated within age and returns how
+// many it removed
+func (r *CrewPositionRepository) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	query := `DELETE FROM crew_positions WHERE updated_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`
+
+	result, err := r.db.ExecContext(ctx, query, age.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning crew positions: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// JobRepository handles background job database operations
+type JobRepository struct {
+	db *sqlx.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *sqlx.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Enqueue adds a job to the queue to run as soon as a worker is free
+func (r *JobRepository) Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error) {
+	return r.EnqueueAt(ctx, jobType, payload, time.Now().UTC())
+}
+
+// EnqueueAt adds a job to the queue to run no earlier than runAt.
+// run_at has no time zone, so runAt is stored in UTC.
+func (r *JobRepository) EnqueueAt(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding job payload: %v", err)
+	}
+
+	var job models.Job
+	query := `
+		INSERT INTO jobs (job_type, payload, run_at)
+		VALUES ($1, $2, $3)
+		RETURNING job_id, job_type, payload, status, attempts, max_attempts, run_at,
+			locked_until, last_error, created_at, updated_at
+	`
+
+	if err := r.db.GetContext(ctx, &job, query, jobType, data, runAt.UTC()); err != nil {
+		return nil, fmt.Errorf("error enqueuing job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// enqueueJob adds a job with q, so other repositories can queue work in the
+// same transaction as the change that calls for it
+func enqueueJob(ctx context.Context, q sqlx.ExecerContext, jobType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding job payload: %v", err)
+	}
+
+	if _, err := q.ExecContext(ctx, `INSERT INTO jobs (job_type, payload) VALUES ($1, $2)`, jobType, data); err != nil {
+		return fmt.Errorf("error enqueuing job: %v", err)
+	}
+
+	return nil
+}
+
+// enqueueJobWithMaxAttempts is enqueueJob for a job tried up to maxAttempts
+// times instead of the default
+func enqueueJobWithMaxAttempts(ctx context.Context, q sqlx.ExecerContext, jobType string, payload interface{}, maxAttempts int) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding job payload: %v", err)
+	}
+
+	query := `INSERT INTO jobs (job_type, payload, max_attempts) VALUES ($1, $2, $3)`
+	if _, err := q.ExecContext(ctx, query, jobType, data, maxAttempts); err != nil {
+		return fmt.Errorf("error enqueuing job: %v", err)
+	}
+
+	return nil
+}
+
+// ClaimNext locks the oldest runnable job of one of the given types for the
+// lease of its type. Jobs whose lease expired (a worker died mid-run) are
+// claimed again. It returns nil if there is nothing to do.
+func (r *JobRepository) ClaimNext(ctx context.Context, leases map[string]time.Duration) (*models.Job, error) {
+	jobTypes := make([]string, 0, len(leases))
+	seconds := make([]int64, 0, len(leases))
+	for jobType, lease := range leases {
+		jobTypes = append(jobTypes, jobType)
+		seconds = append(seconds, int64(lease.Seconds()))
+	}
+
+	var job models.Job
+	query := `
+		UPDATE jobs
+		SET
+			status = 'running',
+			attempts = attempts + 1,
+			locked_until = NOW() + (
+				SELECT lease.seconds
+				FROM unnest($1::text[], $2::bigint[]) AS lease(job_type, seconds)
+				WHERE lease.job_type = jobs.job_type
+			) * INTERVAL '1 second'
+		WHERE job_id = (
+			SELECT job_id
+			FROM jobs
+			WHERE job_type = ANY($1)
+			AND attempts < max_attempts
+			AND (
+				(status = 'pending' AND run_at <= NOW())
+				OR (status = 'running' AND locked_until < NOW())
+			)
+			ORDER BY run_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING job_id, job_type, payload, status, attempts, max_attempts, run_at,
+			locked_until, last_error, created_at, updated_at
+	`
+
+	err := r.db.GetContext(ctx, &job, query, pq.Array(jobTypes), pq.Array(seconds))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error claiming job: %v", err)
+	}
+
+	return &job, nil
+}
+
+// Complete marks a job as done
+func (r *JobRepository) Complete(ctx context.Context, jobID int64) error {
+	query := `
+		UPDATE jobs
+		SET status = 'completed', locked_until = NULL
+		WHERE job_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, jobID); err != nil {
+		return fmt.Errorf("error completing job: %v", err)
+	}
+
+	return nil
+}
+
+// Retry puts a failed job back in the queue to run again at runAt
+func (r *JobRepository) Retry(ctx context.Context, jobID int64, lastError string, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', locked_until = NULL, last_error = $2, run_at = $3
+		WHERE job_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, jobID, lastError, runAt.UTC()); err != nil {
+		return fmt.Errorf("error rescheduling job: %v", err)
+	}
+
+	return nil
+}
+
+// Fail marks a job as permanently failed
+func (r *JobRepository) Fail(ctx context.Context, jobID int64, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', locked_until = NULL, last_error = $2
+		WHERE job_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, jobID, lastError); err != nil {
+		return fmt.Errorf("error failing job: %v", err)
+	}
+
+	return nil
+}
+
+// FailExpired marks running jobs whose lease expired on their last attempt
+// as failed. ClaimNext won't claim them again, so without this they would
+// stay running forever. It returns the number of jobs failed.
+func (r *JobRepository) FailExpired(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', locked_until = NULL,
+			last_error = COALESCE(last_error || '; ', '') || 'worker stopped before the last attempt finished'
+		WHERE status = 'running'
+		AND locked_until < NOW()
+		AND attempts >= max_attempts
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("error failing expired jobs: %v", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return count, nil
+}
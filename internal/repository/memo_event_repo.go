@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// MemoEventRepository handles memo change feed database operations. Events
+// are written by triggers on the memos table.
+type MemoEventRepository struct {
+	db *sqlx.DB
+}
+
+// NewMemoEventRepository creates a new memo event repository
+func NewMemoEventRepository(db *sqlx.DB) *MemoEventRepository {
+	return &MemoEventRepository{db: db}
+}
+
+// GetByID retrieves an event by its ID
+func (r *MemoEventRepository) GetByID(ctx context.Context, eventID int64) (*models.MemoEvent, error) {
+	var event models.MemoEvent
+	query := `
+		SELECT event_id, event_type, memo_id, user_id, park_name, latitude, longitude, created_at, changes
+		FROM memo_events
+		WHERE event_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &event, query, eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting memo event: %v", err)
+	}
+
+	return &event, nil
+}
+
+// ListAfter retrieves up to limit events following afterID, oldest first
+func (r *MemoEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*models.MemoEvent, error) {
+	events := []*models.MemoEvent{}
+	query := `
+		SELECT event_id, event_type, memo_id, user_id, park_name, latitude, longitude, created_at, changes
+		FROM memo_events
+		WHERE event_id > $1
+		ORDER BY event_id
+		LIMIT $2
+	`
+
+	if err := r.db.SelectContext(ctx, &events, query, afterID, limit); err != nil {
+		return nil, fmt.Errorf("error listing memo events: %v", err)
+	}
+
+	return events, nil
+}
+
+// Bounds returns the IDs of the oldest and newest retained events, both zero
+// when there are none
+func (r *MemoEventRepository) Bounds(ctx context.Context) (int64, int64, error) {
+	var oldest, newest sql.NullInt64
+	query := `SELECT MIN(event_id), MAX(event_id) FROM memo_events`
+
+	if err := r.db.QueryRowContext(ctx, query).Scan(&oldest, &newest); err != nil {
+		return 0, 0, fmt.Errorf("error getting memo event bounds: %v", err)
+	}
+
+	return oldest.Int64, newest.Int64, nil
+}
+
+// DeleteOlderThan deletes events older than age and returns how many it removed
+func (r *MemoEventRepository) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	query := `DELETE FROM memo_events WHERE created_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`
+
+	result, err := r.db.ExecContext(ctx, query, age.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning memo events: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rows, nil
+}
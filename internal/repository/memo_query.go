@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// Filter dimensions, used to leave a dimension's own filter out when counting
+// its facet
+const (
+	dimensionText     = "text"
+	dimensionUser     = "user"
+	dimensionPark     = "park"
+	dimensionCategory = "category"
+	dimensionTag      = "tag"
+	dimensionDate     = "date"
+	dimensionLocation = "location"
+	dimensionLanguage = "language"
+	dimensionStatus   = "status"
+)
+
+// metersPerDegree is the length of one degree of latitude
+const metersPerDegree = 111320.0
+
+// sqlArgs collects positional query arguments
+type sqlArgs struct {
+	values []interface{}
+}
+
+// add appends an argument and returns its placeholder
+func (a *sqlArgs) add(value interface{}) string {
+	a.values = append(a.values, value)
+	return fmt.Sprintf("$%d", len(a.values))
+}
+
+// memoCondition is one WHERE condition on the memos table. Conditions are
+// built against a fresh sqlArgs for every statement so that placeholders are
+// numbered without gaps when some conditions are left out.
+type memoCondition struct {
+	dimension string
+	build     func(args *sqlArgs) string
+}
+
+// memoConditions turns a filter into WHERE conditions
+func memoConditions(f *models.MemoFilter) []memoCondition {
+	conds := []memoCondition{}
+
+	if f.Query != "" {
+		conds = append(conds, memoCondition{dimensionText, func(args *sqlArgs) string {
+			return textCondition(f.Query, args)
+		}})
+	}
+
+	if f.UserID != "" {
+		conds = append(conds, memoCondition{dimensionUser, func(args *sqlArgs) string {
+			return "user_id = " + args.add(f.UserID)
+		}})
+	}
+
+	if len(f.ParkNames) > 0 {
+		conds = append(conds, memoCondition{dimensionPark, func(args *sqlArgs) string {
+			return "park_name = ANY(" + args.add(pq.Array(f.ParkNames)) + "::text[])"
+		}})
+	}
+
+	if f.Category != "" {
+		conds = append(conds, memoCondition{dimensionCategory, func(args *sqlArgs) string {
+			return "category = " + args.add(f.Category)
+		}})
+	}
+
+	if f.Language != "" {
+		conds = append(conds, memoCondition{dimensionLanguage, func(args *sqlArgs) string {
+			return "language = " + args.add(f.Language)
+		}})
+	}
+
+	if f.Status != "" {
+		conds = append(conds, memoCondition{dimensionStatus, func(args *sqlArgs) string {
+			return "status = " + args.add(f.Status)
+		}})
+	}
+
+	if len(f.Tags) > 0 {
+		conds = append(conds, memoCondition{dimensionTag, func(args *sqlArgs) string {
+			return "tags @> " + args.add(pq.Array(f.Tags)) + "::text[]"
+		}})
+	}
+
+	if f.StartDate != nil {
+		conds = append(conds, memoCondition{dimensionDate, func(args *sqlArgs) string {
+			return "created_at >= " + args.add(*f.StartDate)
+		}})
+	}
+
+	if f.EndDate != nil {
+		conds = append(conds, memoCondition{dimensionDate, func(args *sqlArgs) string {
+			return "created_at < " + args.add(*f.EndDate)
+		}})
+	}
+
+	if f.BBox != nil {
+		conds = append(conds, memoCondition{dimensionLocation, func(args *sqlArgs) string {
+			return fmt.Sprintf("latitude BETWEEN %s AND %s AND longitude BETWEEN %s AND %s",
+				args.add(f.BBox.MinLatitude), args.add(f.BBox.MaxLatitude),
+				args.add(f.BBox.MinLongitude), args.add(f.BBox.MaxLongitude))
+		}})
+	}
+
+	if f.Near != nil {
+		conds = append(conds, memoCondition{dimensionLocation, func(args *sqlArgs) string {
+			return radiusCondition(args, f.Near)
+		}})
+	}
+
+	return conds
+}
+
+// whereClause renders the conditions not belonging to the excluded dimension,
+// plus any extra fixed conditions
+func whereClause(conds []memoCondition, args *sqlArgs, exclude string, extra ...string) string {
+	clauses := []string{}
+	for _, cond := range conds {
+		if cond.dimension != exclude {
+			clauses = append(clauses, cond.build(args))
+		}
+	}
+	clauses = append(clauses, extra...)
+
+	if len(clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(clauses, " AND ")
+}
+
+// distanceExpr is the Haversine distance in meters from a point to a memo.
+// The cosine is clamped because rounding can push it just past 1 for a memo
+// at the center, which acos rejects.
+func distanceExpr(args *sqlArgs, lat, lon float64) string {
+	latArg := args.add(lat)
+	lonArg := args.add(lon)
+	return fmt.Sprintf(`(6371000 * acos(LEAST(1.0, GREATEST(-1.0,
+		cos(radians(%[1]s)) * cos(radians(latitude)) *
+		cos(radians(longitude) - radians(%[2]s)) +
+		sin(radians(%[1]s)) * sin(radians(latitude))
+	))))`, latArg, lonArg)
+}
+
+// radiusCondition selects memos within a radius. A bounding box around the
+// circle is checked first so the location index can narrow the rows before
+// computing distances.
+func radiusCondition(args *sqlArgs, near *models.GeoRadius) string {
+	clauses := []string{"latitude IS NOT NULL", "longitude IS NOT NULL"}
+
+	latDelta := float64(near.RadiusMeters) / metersPerDegree
+	clauses = append(clauses, fmt.Sprintf("latitude BETWEEN %s AND %s",
+		args.add(near.Latitude-latDelta), args.add(near.Latitude+latDelta)))
+
+	// Skip the longitude bound near the poles and across the antimeridian,
+	// where a simple range would exclude memos inside the circle
+	cosLat := math.Cos(near.Latitude * math.Pi / 180)
+	if cosLat > 0.01 {
+		lonDelta := latDelta / cosLat
+		if near.Longitude-lonDelta >= -180 && near.Longitude+lonDelta <= 180 {
+			clauses = append(clauses, fmt.Sprintf("longitude BETWEEN %s AND %s",
+				args.add(near.Longitude-lonDelta), args.add(near.Longitude+lonDelta)))
+		}
+	}
+
+	clauses = append(clauses, fmt.Sprintf("%s <= %s",
+		distanceExpr(args, near.Latitude, near.Longitude), args.add(near.RadiusMeters)))
+
+	return strings.Join(clauses, " AND ")
+}
+
+// memoOrderBy returns the ORDER BY terms for a sort, falling back to newest
+// first when the sort needs a text query or location the filter lacks
+func memoOrderBy(f *models.MemoFilter) string {
+	switch {
+	case f.Sort == models.MemoSortRelevance && f.Query != "":
+		return "rank DESC, created_at DESC, memo_id"
+	case f.Sort == models.MemoSortDistance && f.Near != nil:
+		return "distance_meters ASC, created_at DESC, memo_id"
+	default:
+		return "created_at DESC, memo_id"
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -22,25 +23,34 @@ func NewMemoRepository(db *sqlx.DB) *MemoRepository {
 	return &MemoRepository{db: db}
 }
 
-// Create creates a new memo
-func (r *MemoRepository) Create(ctx context.Context, memo *models.Memo) error {
+// Create creates a new memo. If audit is not nil it is completed with the new
+// memo and recorded in the same transaction, so the memo is never created
+// without its audit entry.
+func (r *MemoRepository) Create(ctx context.Context, memo *models.Memo, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO memos (
-			user_id, user_name, user_color, title, audio_url, text, duration_seconds,
-			latitude, longitude, location_accuracy, address, park_name
+			user_id, user_name, user_color, title, audio_key, text, duration_seconds,
+			latitude, longitude, location_accuracy, address, park_name, transcode_status,
+			transcription_status, language
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING memo_id, created_at, updated_at
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING memo_id, tags, status, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		memo.UserID,
 		memo.UserName,
 		memo.UserColor,
 		memo.Title,
-		memo.AudioURL,
+		memo.AudioKey,
 		memo.Text,
 		memo.DurationSeconds,
 		memo.Latitude,
@@ -48,28 +58,60 @@ func (r *MemoRepository) Create(ctx context.Context, memo *models.Memo) error {
 		memo.LocationAccuracy,
 		memo.Address,
 		memo.ParkName,
-	).Scan(&memo.MemoID, &memo.CreatedAt, &memo.UpdatedAt)
+		memo.TranscodeStatus,
+		memo.TranscriptionStatus,
+		memo.Language,
+	).Scan(&memo.MemoID, &memo.Tags, &memo.Status, &memo.CreatedAt, &memo.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("error creating memo: %v", err)
 	}
 
+	if memo.Latitude != nil && memo.Longitude != nil {
+		memo.Location = &models.Location{
+			Latitude:  *memo.Latitude,
+			Longitude: *memo.Longitude,
+			Accuracy:  memo.LocationAccuracy,
+			Address:   memo.Address,
+		}
+	}
+
+	if audit != nil {
+		targetID := memo.MemoID.String()
+		audit.TargetID = &targetID
+		audit.After = models.AuditSnapshot(memo)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing memo: %v", err)
+	}
+
 	return nil
 }
 
 // GetByID retrieves a memo by its ID
 func (r *MemoRepository) GetByID(ctx context.Context, memoID uuid.UUID) (*models.Memo, error) {
+	return getMemo(ctx, r.db, memoID)
+}
+
+// getMemo retrieves a memo with q, so a transaction can read back a memo it
+// changed
+func getMemo(ctx context.Context, q sqlx.QueryerContext, memoID uuid.UUID) (*models.Memo, error) {
 	var memo models.Memo
 	query := `
 		SELECT 
-			memo_id, user_id, user_name, user_color, title, audio_url, text, duration_seconds,
-			latitude, longitude, location_accuracy, address, park_name,
+			memo_id, user_id, user_name, user_color, title, audio_key, text, duration_seconds,
+			latitude, longitude, location_accuracy, address, park_name, tags, category,
+			language, status, resolved_at, transcode_status, transcription_status,
 			created_at, updated_at
 		FROM memos
 		WHERE memo_id = $1
 	`
 
-	err := r.db.GetContext(ctx, &memo, query, memoID)
+	err := sqlx.GetContext(ctx, q, &memo, query, memoID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -87,71 +129,149 @@ func (r *MemoRepository) GetByID(ctx context.Context, memoID uuid.UUID) (*models
 		}
 	}
 
+	// Attach transcoded variants
+	memo.AudioVariants, err = listAudioVariants(ctx, q, memoID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &memo, nil
 }
 
-// List retrieves all memos with pagination and optional filters
-func (r *MemoRepository) List(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.MemoListItem, int, error) {
-	// Build WHERE clause
-	whereClauses := []string{}
-	args := []interface{}{}
-	argPos := 1
+// SetTranscodeStatus records the progress of a memo's audio transcoding
+func (r *MemoRepository) SetTranscodeStatus(ctx context.Context, memoID uuid.UUID, status string) error {
+	query := `UPDATE memos SET transcode_status = $1 WHERE memo_id = $2`
 
-	if parkName, ok := filters["park_name"].(string); ok && parkName != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("park_name = $%d", argPos))
-		args = append(args, parkName)
-		argPos++
+	if _, err := r.db.ExecContext(ctx, query, status, memoID); err != nil {
+		return fmt.Errorf("error updating transcode status: %v", err)
 	}
 
-	if userID, ok := filters["user_id"].(string); ok && userID != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("user_id = $%d", argPos))
-		args = append(args, userID)
-		argPos++
+	return nil
+}
+
+// SetTranscriptionStatus records the progress of a memo's server-side transcription
+func (r *MemoRepository) SetTranscriptionStatus(ctx context.Context, memoID uuid.UUID, status string) error {
+	query := `UPDATE memos SET transcription_status = $1 WHERE memo_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, status, memoID); err != nil {
+		return fmt.Errorf("error updating transcription status: %v", err)
 	}
 
-	if startDate, ok := filters["start_date"].(string); ok && startDate != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("created_at >= $%d", argPos))
-		args = append(args, startDate)
-		argPos++
+	return nil
+}
+
+// FillEmptyText sets the text of a memo that was created without one.
+// It reports whether the memo still had no text.
+func (r *MemoRepository) FillEmptyText(ctx context.Context, memoID uuid.UUID, text string) (bool, error) {
+	query := `UPDATE memos SET text = $1 WHERE memo_id = $2 AND text = ''`
+
+	result, err := r.db.ExecContext(ctx, query, text, memoID)
+	if err != nil {
+		return false, fmt.Errorf("error updating memo text: %v", err)
 	}
 
-	if endDate, ok := filters["end_date"].(string); ok && endDate != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("created_at <= $%d", argPos))
-		args = append(args, endDate)
-		argPos++
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error updating memo text: %v", err)
+	}
+
+	return rows > 0, nil
+}
+
+// SetLanguage sets the language a memo's text is searched in
+func (r *MemoRepository) SetLanguage(ctx context.Context, memoID uuid.UUID, language string) error {
+	query := `UPDATE memos SET language = $1 WHERE memo_id = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, language, memoID); err != nil {
+		return fmt.Errorf("error updating memo language: %v", err)
 	}
 
-	whereClause := ""
-	if len(whereClauses) > 0 {
-		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
+	return nil
+}
+
+// ExistsByAudioKey reports whether any memo already references an audio file
+func (r *MemoRepository) ExistsByAudioKey(ctx context.Context, audioKey string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM memos WHERE audio_key = $1)`
+
+	if err := r.db.GetContext(ctx, &exists, query, audioKey); err != nil {
+		return false, fmt.Errorf("error checking audio reference: %v", err)
 	}
 
-	// Count total items
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM memos %s", whereClause)
+	return exists, nil
+}
+
+// Query retrieves one page of memos matching the filter and the total number
+// of matches. Text queries match by full text or trigram similarity and add a
+// rank and snippet; radius filters add the distance from the center.
+func (r *MemoRepository) Query(ctx context.Context, filter *models.MemoFilter, page, limit int) ([]models.MemoListItem, int, error) {
+	conds := memoConditions(filter)
+
+	// Count total matches
+	countArgs := &sqlArgs{}
+	countQuery := "SELECT COUNT(*) FROM memos " + whereClause(conds, countArgs, "")
 	var total int
-	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	err := r.db.GetContext(ctx, &total, countQuery, countArgs.values...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting memos: %v", err)
 	}
 
+	args := &sqlArgs{}
+	from := "memos"
+	queryColumn := ""
+	rankExpr := "NULL::real"
+	snippetExpr := "NULL::text"
+	wordsExpr := "NULL::jsonb"
+	if filter.Query != "" {
+		// Each row's query is parsed with its own language for ranking and
+		// snippets
+		from = fmt.Sprintf("memos CROSS JOIN LATERAL (SELECT %s AS query, %s::text AS fuzzy) q",
+			bindSearchQuery(filter.Query, args).tsquery(memoSearchConfig), args.add(fuzzyText(filter.Query)))
+		queryColumn = "q.query,"
+		// Blend full-text rank with trigram similarity so near misses from
+		// the fuzzy match still rank by how close they are
+		rankExpr = fmt.Sprintf(`ts_rank(search_vector, q.query) + %g * GREATEST(
+				word_similarity(q.fuzzy, text),
+				word_similarity(q.fuzzy, coalesce(title, '')),
+				word_similarity(q.fuzzy, coalesce(park_name, ''))
+			)`, fuzzyRankWeight)
+		snippetExpr = fmt.Sprintf("ts_headline(%s, text, query, '%s')", memoSearchConfig, headlineOptions)
+		wordsExpr = "(SELECT words FROM memo_transcripts t WHERE t.memo_id = hits.memo_id)"
+	}
+	distanceColumn := "NULL::double precision"
+	if filter.Near != nil {
+		distanceColumn = distanceExpr(args, filter.Near.Latitude, filter.Near.Longitude)
+	}
+	where := whereClause(conds, args, "")
+	orderBy := memoOrderBy(filter)
+
 	// Calculate offset
 	offset := (page - 1) * limit
 
-	// Query memos
+	// Snippets and transcripts are only loaded for the rows on this page
 	query := fmt.Sprintf(`
-		SELECT 
-			memo_id, user_id, user_name, user_color, title, audio_url, text, duration_seconds,
-			latitude, longitude, location_accuracy, address, park_name,
-			created_at, updated_at
-		FROM memos
-		%s
-		ORDER BY created_at DESC
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argPos, argPos+1)
-
-	args = append(args, limit, offset)
-
-	rows, err := r.db.QueryxContext(ctx, query, args...)
+		SELECT
+			memo_id, user_id, user_name, user_color, title, audio_key, text, duration_seconds,
+			latitude, longitude, location_accuracy, address, park_name, tags, category,
+			language, status, resolved_at, created_at, updated_at, rank, distance_meters,
+			%s AS snippet,
+			%s AS transcript_words
+		FROM (
+			SELECT
+				memos.*,
+				%s
+				%s AS rank,
+				%s AS distance_meters
+			FROM %s
+			%s
+			ORDER BY %s
+			LIMIT %s OFFSET %s
+		) AS hits
+		ORDER BY %s
+	`, snippetExpr, wordsExpr, queryColumn, rankExpr, distanceColumn, from, where, orderBy,
+		args.add(limit), args.add(offset), orderBy)
+
+	rows, err := r.db.QueryxContext(ctx, query, args.values...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error querying memos: %v", err)
 	}
@@ -160,7 +280,17 @@ func (r *MemoRepository) List(ctx context.Context, page, limit int, filters map[
 	memos := []models.MemoListItem{}
 	for rows.Next() {
 		var m models.Memo
-		if err := rows.StructScan(&m); err != nil {
+		var rank, distance *float64
+		var snippet *string
+		var words models.TranscriptWords
+
+		if err := rows.Scan(
+			&m.MemoID, &m.UserID, &m.UserName, &m.UserColor, &m.Title, &m.AudioKey, &m.Text,
+			&m.DurationSeconds, &m.Latitude, &m.Longitude, &m.LocationAccuracy,
+			&m.Address, &m.ParkName, &m.Tags, &m.Category, &m.Language, &m.Status, &m.ResolvedAt,
+			&m.CreatedAt, &m.UpdatedAt,
+			&rank, &distance, &snippet, &words,
+		); err != nil {
 			return nil, 0, fmt.Errorf("error scanning memo: %v", err)
 		}
 
@@ -175,27 +305,237 @@ func (r *MemoRepository) List(ctx context.Context, page, limit int, filters map[
 			}
 		}
 
+		// Round distance to 2 decimal places
+		if distance != nil {
+			rounded := math.Round(*distance*100) / 100
+			distance = &rounded
+		}
+
 		memos = append(memos, models.MemoListItem{
 			MemoID:          m.MemoID,
 			UserID:          m.UserID,
 			UserName:        m.UserName,
 			UserColor:       m.UserColor,
 			Title:           m.Title,
-			AudioURL:        m.AudioURL,
+			AudioKey:        m.AudioKey,
 			Text:            m.Text,
 			DurationSeconds: m.DurationSeconds,
 			Location:        location,
 			ParkName:        m.ParkName,
+			Tags:            m.Tags,
+			Category:        m.Category,
+			Language:        m.Language,
+			Status:          m.Status,
+			ResolvedAt:      m.ResolvedAt,
 			CreatedAt:       m.CreatedAt,
 			UpdatedAt:       m.UpdatedAt,
+			Snippet:         snippet,
+			RelevanceScore:  rank,
+			DistanceMeters:  distance,
+			TranscriptWords: words,
 		})
 	}
 
 	return memos, total, nil
 }
 
-// Update updates a memo
-func (r *MemoRepository) Update(ctx context.Context, memoID uuid.UUID, updates map[string]interface{}) (*models.Memo, error) {
+// Export streams the memos matching the filter to fn, newest first, without
+// loading them all into memory. limit caps the number of rows; 0 means no
+// limit. An error from fn stops the export and is returned as is.
+func (r *MemoRepository) Export(ctx context.Context, filter *models.MemoFilter, limit int, fn func(*models.MemoExportRow) error) error {
+	args := &sqlArgs{}
+	query := fmt.Sprintf(`
+		SELECT
+			memo_id, user_id, user_name, department, title, text, park_name,
+			latitude, longitude, location_accuracy, address, category, tags, language,
+			duration_seconds, status, transcription_status, audio_key IS NOT NULL AS has_audio,
+			created_at, updated_at
+		FROM memos
+		LEFT JOIN LATERAL (
+			SELECT NULLIF(users.department, '') AS department FROM users WHERE users.user_id = memos.user_id
+		) AS author ON TRUE
+		%s
+		ORDER BY created_at DESC, memo_id
+	`, whereClause(memoConditions(filter), args, ""))
+	if limit > 0 {
+		query += " LIMIT " + args.add(limit)
+	}
+
+	rows, err := r.db.QueryxContext(ctx, query, args.values...)
+	if err != nil {
+		return fmt.Errorf("error exporting memos: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.MemoExportRow
+		if err := rows.StructScan(&row); err != nil {
+			return fmt.Errorf("error scanning memo: %v", err)
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error exporting memos: %v", err)
+	}
+
+	return nil
+}
+
+// Autocomplete suggests park names and titles for partial input. Values that
+// start with the input come first, then close trigram matches, so a typo still
+// finds the park. types limits the suggestions to parks or titles; empty means
+// both.
+func (r *MemoRepository) Autocomplete(ctx context.Context, input string, types []string, limit int) ([]models.AutocompleteSuggestion, error) {
+	args := &sqlArgs{}
+	inputArg := args.add(input)
+	containsArg := args.add("%" + escapeLike(input) + "%")
+	prefixArg := args.add(escapeLike(input) + "%")
+
+	columns := map[string]string{
+		models.AutocompleteTypePark:  "park_name",
+		models.AutocompleteTypeTitle: "title",
+	}
+	if len(types) == 0 {
+		types = []string{models.AutocompleteTypePark, models.AutocompleteTypeTitle}
+	}
+
+	branches := []string{}
+	for _, t := range types {
+		column, ok := columns[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown autocomplete type: %s", t)
+		}
+		branches = append(branches, fmt.Sprintf(`
+			SELECT %[1]s AS value, '%[2]s' AS type
+			FROM memos
+			WHERE %[1]s IS NOT NULL AND (%[1]s ILIKE %[3]s OR %[4]s <%% %[1]s)`,
+			column, t, containsArg, inputArg))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT value, type, COUNT(*) AS count
+		FROM (%s) AS candidates
+		GROUP BY value, type
+		ORDER BY value ILIKE %s DESC, word_similarity(%s, value) DESC, count DESC, value
+		LIMIT %s
+	`, strings.Join(branches, "\n\t\t\tUNION ALL"), prefixArg, inputArg, args.add(limit))
+
+	suggestions := []models.AutocompleteSuggestion{}
+	err := r.db.SelectContext(ctx, &suggestions, query, args.values...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying autocomplete suggestions: %v", err)
+	}
+
+	return suggestions, nil
+}
+
+// Matches reports whether a memo matches a filter
+func (r *MemoRepository) Matches(ctx context.Context, memoID uuid.UUID, filter *models.MemoFilter) (bool, error) {
+	args := &sqlArgs{}
+	idArg := args.add(memoID)
+	query := fmt.Sprintf(
+		"SELECT EXISTS(SELECT 1 FROM memos %s)",
+		whereClause(memoConditions(filter), args, "", "memo_id = "+idArg),
+	)
+
+	var matches bool
+	if err := r.db.GetContext(ctx, &matches, query, args.values...); err != nil {
+		return false, fmt.Errorf("error matching memo: %v", err)
+	}
+
+	return matches, nil
+}
+
+// ListUserParks retrieves the parks a user has recorded memos in since the
+// given time, most used first
+func (r *MemoRepository) ListUserParks(ctx context.Context, userID string, since time.Time) ([]string, error) {
+	parks := []string{}
+	query := `
+		SELECT park_name
+		FROM memos
+		WHERE user_id = $1 AND park_name IS NOT NULL AND park_name <> '' AND created_at >= $2
+		GROUP BY park_name
+		ORDER BY COUNT(*) DESC, park_name
+	`
+
+	if err := r.db.SelectContext(ctx, &parks, query, userID, since); err != nil {
+		return nil, fmt.Errorf("error listing user parks: %v", err)
+	}
+
+	return parks, nil
+}
+
+// facetLimit caps the number of values returned per facet
+const facetLimit = 20
+
+// Facets counts the memos matching the filter per user, park, tag, category,
+// language, status and month. Each dimension ignores its own filter.
+func (r *MemoRepository) Facets(ctx context.Context, filter *models.MemoFilter) (*models.MemoFacets, error) {
+	conds := memoConditions(filter)
+	facets := &models.MemoFacets{}
+
+	specs := []struct {
+		dimension string
+		value     string
+		label     string
+		from      string
+		extra     []string
+		orderBy   string
+		dest      *[]models.FacetCount
+	}{
+		{dimensionUser, "user_id", "MAX(user_name)", "memos", nil, "count DESC, value", &facets.Users},
+		{dimensionPark, "park_name", "NULL::text", "memos", []string{"park_name IS NOT NULL"}, "count DESC, value", &facets.Parks},
+		{dimensionTag, "tag", "NULL::text", "memos, unnest(memos.tags) AS tag", nil, "count DESC, value", &facets.Tags},
+		{dimensionCategory, "category", "NULL::text", "memos", []string{"category IS NOT NULL"}, "count DESC, value", &facets.Categories},
+		{dimensionLanguage, "language", "NULL::text", "memos", nil, "count DESC, value", &facets.Languages},
+		{dimensionStatus, "status", "NULL::text", "memos", nil, "count DESC, value", &facets.Statuses},
+		{dimensionDate, "to_char(date_trunc('month', created_at), 'YYYY-MM')", "NULL::text", "memos", nil, "value DESC", &facets.Months},
+	}
+
+	for _, spec := range specs {
+		args := &sqlArgs{}
+		query := fmt.Sprintf(`
+			SELECT %s AS value, %s AS label, COUNT(*) AS count
+			FROM %s
+			%s
+			GROUP BY 1
+			ORDER BY %s
+			LIMIT %d
+		`, spec.value, spec.label, spec.from, whereClause(conds, args, spec.dimension, spec.extra...), spec.orderBy, facetLimit)
+
+		rows, err := r.db.QueryxContext(ctx, query, args.values...)
+		if err != nil {
+			return nil, fmt.Errorf("error counting %s facet: %v", spec.dimension, err)
+		}
+
+		counts := []models.FacetCount{}
+		for rows.Next() {
+			var fc models.FacetCount
+			if err := rows.Scan(&fc.Value, &fc.Label, &fc.Count); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("error scanning %s facet: %v", spec.dimension, err)
+			}
+			counts = append(counts, fc)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error reading %s facet: %v", spec.dimension, err)
+		}
+
+		*spec.dest = counts
+	}
+
+	return facets, nil
+}
+
+// Update updates a memo and records the result as a new revision, unless
+// only the workflow status changed, which revisions do not track. Setting the
+// status to resolved stamps resolved_at; any other status clears it.
+// editedBy is the user making the change. If audit is not nil it is completed
+// with the updated memo and recorded in the same transaction.
+func (r *MemoRepository) Update(ctx context.Context, memoID uuid.UUID, updates map[string]interface{}, editedBy string, audit *models.AuditEntry) (*models.Memo, error) {
 	setClauses := []string{}
 	args := []interface{}{}
 	argPos := 1
@@ -218,6 +558,12 @@ func (r *MemoRepository) Update(ctx context.Context, memoID uuid.UUID, updates m
 		argPos++
 	}
 
+	if language, ok := updates["language"]; ok {
+		setClauses = append(setClauses, fmt.Sprintf("language = $%d", argPos))
+		args = append(args, language)
+		argPos++
+	}
+
 	if latitude, ok := updates["latitude"]; ok {
 		setClauses = append(setClauses, fmt.Sprintf("latitude = $%d", argPos))
 		args = append(args, latitude)
@@ -230,6 +576,18 @@ func (r *MemoRepository) Update(ctx context.Context, memoID uuid.UUID, updates m
 		argPos++
 	}
 
+	// Everything above is part of the field report and gets a revision
+	revised := len(setClauses) > 0
+
+	if status, ok := updates["status"]; ok {
+		statusArg := fmt.Sprintf("$%d", argPos)
+		setClauses = append(setClauses,
+			"status = "+statusArg,
+			fmt.Sprintf("resolved_at = CASE WHEN %s = '%s' THEN COALESCE(resolved_at, NOW()) END", statusArg, models.MemoStatusResolved))
+		args = append(args, status)
+		argPos++
+	}
+
 	if len(setClauses) == 0 {
 		return nil, fmt.Errorf("no fields to update")
 	}
@@ -242,176 +600,87 @@ func (r *MemoRepository) Update(ctx context.Context, memoID uuid.UUID, updates m
 
 	args = append(args, memoID)
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error updating memo: %v", err)
+		return nil, fmt.Errorf("error starting transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// Fetch and return updated memo
-	return r.GetByID(ctx, memoID)
-}
-
-// Delete deletes a memo
-func (r *MemoRepository) Delete(ctx context.Context, memoID uuid.UUID) error {
-	query := `DELETE FROM memos WHERE memo_id = $1`
-
-	result, err := r.db.ExecContext(ctx, query, memoID)
-	if err != nil {
-		return fmt.Errorf("error deleting memo: %v", err)
+	// Lock the memo so concurrent edits get consecutive revision numbers
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM memos WHERE memo_id = $1 FOR UPDATE`, memoID); err != nil {
+		return nil, fmt.Errorf("error locking memo: %v", err)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("error getting rows affected: %v", err)
+	// Preserve the original field report before the first edit
+	if revised {
+		if err := recordOriginalRevision(ctx, tx, memoID); err != nil {
+			return nil, err
+		}
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("memo not found")
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("error updating memo: %v", err)
 	}
 
-	return nil
-}
-
-// SearchByText performs full-text search on memos
-func (r *MemoRepository) SearchByText(ctx context.Context, query string, page, limit int) ([]models.MemoListItem, int, error) {
-	// Count total matches
-	countQuery := `
-		SELECT COUNT(*)
-		FROM memos
-		WHERE to_tsvector('english', text) @@ plainto_tsquery('english', $1)
-	`
-	var total int
-	err := r.db.GetContext(ctx, &total, countQuery, query)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error counting search results: %v", err)
+	if revised {
+		if err := recordRevision(ctx, tx, memoID, editedBy); err != nil {
+			return nil, err
+		}
 	}
 
-	// Calculate offset
-	offset := (page - 1) * limit
-
-	// Search query
-	searchQuery := `
-		SELECT 
-			memo_id, user_id, user_name, user_color, title, audio_url, text, duration_seconds,
-			latitude, longitude, location_accuracy, address, park_name,
-			created_at, updated_at,
-			ts_rank(to_tsvector('english', text), plainto_tsquery('english', $1)) as rank
-		FROM memos
-		WHERE to_tsvector('english', text) @@ plainto_tsquery('english', $1)
-		ORDER BY rank DESC, created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := r.db.QueryxContext(ctx, searchQuery, query, limit, offset)
+	memo, err := getMemo(ctx, tx, memoID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error searching memos: %v", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	memos := []models.MemoListItem{}
-	for rows.Next() {
-		var m models.Memo
-		var rank float64
 
-		if err := rows.Scan(
-			&m.MemoID, &m.UserID, &m.UserName, &m.UserColor, &m.Title, &m.AudioURL, &m.Text,
-			&m.DurationSeconds, &m.Latitude, &m.Longitude, &m.LocationAccuracy,
-			&m.Address, &m.ParkName, &m.CreatedAt, &m.UpdatedAt, &rank,
-		); err != nil {
-			return nil, 0, fmt.Errorf("error scanning memo: %v", err)
-		}
-
-		// Build location if coordinates exist
-		var location *models.Location
-		if m.Latitude != nil && m.Longitude != nil {
-			location = &models.Location{
-				Latitude:  *m.Latitude,
-				Longitude: *m.Longitude,
-				Accuracy:  m.LocationAccuracy,
-				Address:   m.Address,
-			}
+	if audit != nil {
+		audit.After = models.AuditSnapshot(memo)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return nil, err
 		}
+	}
 
-		memos = append(memos, models.MemoListItem{
-			MemoID:          m.MemoID,
-			UserID:          m.UserID,
-			UserName:        m.UserName,
-			UserColor:       m.UserColor,
-			Title:           m.Title,
-			AudioURL:        m.AudioURL,
-			Text:            m.Text,
-			DurationSeconds: m.DurationSeconds,
-			Location:        location,
-			ParkName:        m.ParkName,
-			CreatedAt:       m.CreatedAt,
-			UpdatedAt:       m.UpdatedAt,
-		})
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing memo update: %v", err)
 	}
 
-	return memos, total, nil
+	return memo, nil
 }
 
-// GetNearby finds memos near a location using Haversine formula
-func (r *MemoRepository) GetNearby(ctx context.Context, lat, lon float64, radiusMeters, limit int) ([]models.NearbyMemo, error) {
-	// Haversine formula in SQL - use subquery to filter by distance
-	query := `
-		SELECT 
-			memo_id, user_name, user_color, title, park_name,
-			latitude, longitude, location_accuracy, address,
-			created_at, distance_meters
-		FROM (
-			SELECT 
-				memo_id, user_name, user_color, title, park_name,
-				latitude, longitude, location_accuracy, address,
-				created_at,
-				(
-					6371000 * acos(
-						cos(radians($1)) * cos(radians(latitude)) *
-						cos(radians(longitude) - radians($2)) +
-						sin(radians($1)) * sin(radians(latitude))
-					)
-				) AS distance_meters
-			FROM memos
-			WHERE latitude IS NOT NULL AND longitude IS NOT NULL
-		) AS nearby
-		WHERE distance_meters <= $3
-		ORDER BY distance_meters ASC
-		LIMIT $4
-	`
+// Delete deletes a memo. If audit is not nil it is recorded in the same
+// transaction.
+func (r *MemoRepository) Delete(ctx context.Context, memoID uuid.UUID, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM memos WHERE memo_id = $1`
 
-	rows, err := r.db.QueryxContext(ctx, query, lat, lon, radiusMeters, limit)
+	result, err := tx.ExecContext(ctx, query, memoID)
 	if err != nil {
-		return nil, fmt.Errorf("error querying nearby memos: %v", err)
+		return fmt.Errorf("error deleting memo: %v", err)
 	}
-	defer rows.Close()
 
-	nearbyMemos := []models.NearbyMemo{}
-	for rows.Next() {
-		var nm models.NearbyMemo
-		var lat, lon float64
-		var accuracy *float64
-		var address *string
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
 
-		if err := rows.Scan(
-			&nm.MemoID, &nm.UserName, &nm.UserColor, &nm.Title, &nm.ParkName,
-			&lat, &lon, &accuracy, &address,
-			&nm.CreatedAt, &nm.DistanceMeters,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning nearby memo: %v", err)
-		}
+	if rows == 0 {
+		return fmt.Errorf("memo not found")
+	}
 
-		nm.Location = &models.Location{
-			Latitude:  lat,
-			Longitude: lon,
-			Accuracy:  accuracy,
-			Address:   address,
+	if audit != nil {
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
 		}
+	}
 
-		// Round distance to 2 decimal places
-		nm.DistanceMeters = math.Round(nm.DistanceMeters*100) / 100
-
-		nearbyMemos = append(nearbyMemos, nm)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing memo deletion: %v", err)
 	}
 
-	return nearbyMemos, nil
+	return nil
 }
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// MentionRepository handles @mention database operations
+type MentionRepository struct {
+	db *sqlx.DB
+}
+
+// NewMentionRepository creates a new mention repository
+func NewMentionRepository(db *sqlx.DB) *MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+// Create records a mention and delivers its notification in one
+// transaction. It returns false, without notifying, if the user is already
+// mentioned in the memo; a mention an edit removed is restored.
+func (r *MentionRepository) Create(ctx context.Context, mention *models.Mention, delivery *models.NotificationDelivery) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	restoreQuery := `
+		UPDATE mentions
+		SET removed_at = NULL, handle = $3, context = $4
+		WHERE memo_id = $1 AND user_id = $2 AND removed_at IS NOT NULL
+	`
+
+	result, err := tx.ExecContext(ctx, restoreQuery, mention.MemoID, mention.UserID, mention.Handle, mention.Context)
+	if err != nil {
+		return false, fmt.Errorf("error restoring mention: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error restoring mention: %v", err)
+	}
+	if rows > 0 {
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("error committing mention: %v", err)
+		}
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO mentions (memo_id, user_id, mentioned_by, handle, context)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (memo_id, user_id) DO NOTHING
+	`
+
+	result, err = tx.ExecContext(
+		ctx,
+		query,
+		mention.MemoID,
+		mention.UserID,
+		mention.MentionedBy,
+		mention.Handle,
+		mention.Context,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error creating mention: %v", err)
+	}
+	rows, err = result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error creating mention: %v", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := deliverNotification(ctx, tx, delivery); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing mention: %v", err)
+	}
+
+	return true, nil
+}
+
+// ListUserIDsByMemo retrieves the IDs of the users mentioned in a memo,
+// leaving out mentions an edit removed
+func (r *MentionRepository) ListUserIDsByMemo(ctx context.Context, memoID uuid.UUID) ([]string, error) {
+	userIDs := []string{}
+	query := `SELECT user_id FROM mentions WHERE memo_id = $1 AND removed_at IS NULL`
+
+	if err := r.db.SelectContext(ctx, &userIDs, query, memoID); err != nil {
+		return nil, fmt.Errorf("error listing memo mentions: %v", err)
+	}
+
+	return userIDs, nil
+}
+
+// RemoveExcept marks a memo's mentions of users not in userIDs as removed,
+// after an edit took them out. They are kept so that adding them back
+// doesn't notify again.
+func (r *MentionRepository) RemoveExcept(ctx context.Context, memoID uuid.UUID, userIDs []string) error {
+	query := `
+		UPDATE mentions
+		SET removed_at = NOW()
+		WHERE memo_id = $1 AND removed_at IS NULL AND NOT (user_id = ANY($2))
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, memoID, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("error removing mentions: %v", err)
+	}
+
+	return nil
+}
+
+// ListByUser retrieves a page of the mentions of a user, newest first, and
+// the total number of them
+func (r *MentionRepository) ListByUser(ctx context.Context, userID string, page, limit int) ([]models.Mention, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM mentions WHERE user_id = $1 AND removed_at IS NULL`
+	if err := r.db.GetContext(ctx, &total, countQuery, userID); err != nil {
+		return nil, 0, fmt.Errorf("error counting mentions: %v", err)
+	}
+
+	mentions := []models.Mention{}
+	query := `
+		SELECT
+			mn.mention_id, mn.memo_id, mn.user_id, mn.mentioned_by, u.display_name AS mentioned_by_name,
+			mn.handle, mn.context, m.title AS memo_title, m.park_name, mn.created_at
+		FROM mentions mn
+		JOIN memos m ON m.memo_id = mn.memo_id
+		JOIN users u ON u.user_id = mn.mentioned_by
+		WHERE mn.user_id = $1 AND mn.removed_at IS NULL
+		ORDER BY mn.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	offset := (page - 1) * limit
+	if err := r.db.SelectContext(ctx, &mentions, query, userID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("error listing mentions: %v", err)
+	}
+
+	return mentions, total, nil
+}
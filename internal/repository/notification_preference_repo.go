@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// NotificationPreferenceRepository handles notification preference database operations
+type NotificationPreferenceRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationPreferenceRepository creates a new notification preference repository
+func NewNotificationPreferenceRepository(db *sqlx.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// Get retrieves a user's preferences, or the defaults if they have none
+func (r *NotificationPreferenceRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	query := `
+		SELECT user_id, email_immediate, push_enabled, digest_frequency, digest_parks, digest_hour, timezone,
+			next_digest_at, last_digest_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &prefs, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.DefaultNotificationPreferences(userID), nil
+		}
+		return nil, fmt.Errorf("error getting notification preferences: %v", err)
+	}
+
+	return &prefs, nil
+}
+
+// Save creates or replaces a user's preferences
+func (r *NotificationPreferenceRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences
+			(user_id, email_immediate, push_enabled, digest_frequency, digest_parks, digest_hour, timezone, next_digest_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_immediate = EXCLUDED.email_immediate,
+			push_enabled = EXCLUDED.push_enabled,
+			digest_frequency = EXCLUDED.digest_frequency,
+			digest_parks = EXCLUDED.digest_parks,
+			digest_hour = EXCLUDED.digest_hour,
+			timezone = EXCLUDED.timezone,
+			next_digest_at = EXCLUDED.next_digest_at
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		prefs.UserID,
+		prefs.EmailImmediate,
+		prefs.PushEnabled,
+		prefs.DigestFrequency,
+		prefs.DigestParks,
+		prefs.DigestHour,
+		prefs.Timezone,
+		prefs.NextDigestAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving notification preferences: %v", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDigests queues a digest job for up to limit users whose digest is
+// due at now, and schedules their next one. Each digest covers the time since
+// the last one that was sent, or one period for the first. Rows are claimed with SKIP
+// LOCKED so API instances don't queue the same digest twice.
+func (r *NotificationPreferenceRepository) ClaimDueDigests(ctx context.Context, now time.Time, limit int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	due := []models.NotificationPreferences{}
+	query := `
+		SELECT user_id, email_immediate, push_enabled, digest_frequency, digest_parks, digest_hour, timezone,
+			next_digest_at, last_digest_at
+		FROM notification_preferences
+		WHERE digest_frequency <> 'off' AND next_digest_at <= $1
+		ORDER BY next_digest_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+
+	if err := tx.SelectContext(ctx, &due, query, now, limit); err != nil {
+		return 0, fmt.Errorf("error claiming digests: %v", err)
+	}
+
+	for i := range due {
+		prefs := &due[i]
+
+		start := now.AddDate(0, 0, -1)
+		if prefs.DigestFrequency == models.DigestWeekly {
+			start = now.AddDate(0, 0, -7)
+		}
+		if prefs.LastDigestAt != nil && prefs.LastDigestAt.After(start.AddDate(0, 0, -7)) {
+			// Continue from the last digest, unless digests were paused
+			// long enough that it would be mostly stale
+			start = *prefs.LastDigestAt
+		}
+
+		payload := models.DigestJobPayload{
+			UserID:      prefs.UserID,
+			PeriodStart: start,
+			PeriodEnd:   now,
+		}
+		if err := enqueueJob(ctx, tx, models.JobTypeDigest, payload); err != nil {
+			return 0, err
+		}
+
+		query := `UPDATE notification_preferences SET next_digest_at = $2 WHERE user_id = $1`
+		if _, err := tx.ExecContext(ctx, query, prefs.UserID, prefs.NextDigestAfter(now)); err != nil {
+			return 0, fmt.Errorf("error scheduling digest: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return len(due), nil
+}
+
+// SetLastDigest records that a user's digest covered everything up to
+// periodEnd, so their next one starts there. A digest that failed leaves it
+// unchanged and the next one covers its period too. It never moves back, in
+// case digests finish out of order.
+func (r *NotificationPreferenceRepository) SetLastDigest(ctx context.Context, userID string, periodEnd time.Time) error {
+	query := `
+		UPDATE notification_preferences
+		SET last_digest_at = GREATEST(COALESCE(last_digest_at, $2), $2)
+		WHERE user_id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, periodEnd); err != nil {
+		return fmt.Errorf("error recording digest: %v", err)
+	}
+
+	return nil
+}
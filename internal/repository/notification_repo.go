@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// NotificationRepository handles in-app notification database operations
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create creates a new notification
+func (r *NotificationRepository) Create(ctx context.Context, n *models.Notification) error {
+	return createNotification(ctx, r.db, n)
+}
+
+// createNotification inserts a notification with q, so other repositories
+// can notify in the same transaction as the change that calls for it
+func createNotification(ctx context.Context, q sqlx.QueryerContext, n *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, memo_id, search_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING notification_id, created_at
+	`
+
+	err := q.QueryRowxContext(ctx, query, n.UserID, n.Type, n.Title, n.Body, n.MemoID, n.SearchID).
+		Scan(&n.NotificationID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating notification: %v", err)
+	}
+
+	return nil
+}
+
+// deliverNotification stores a notification and queues its delivery jobs in tx
+func deliverNotification(ctx context.Context, tx *sqlx.Tx, delivery *models.NotificationDelivery) error {
+	if delivery.Notification != nil {
+		if err := createNotification(ctx, tx, delivery.Notification); err != nil {
+			return err
+		}
+	}
+
+	for _, job := range delivery.Jobs {
+		if err := enqueueJob(ctx, tx, job.JobType, job.Payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListByUser retrieves a page of a user's notifications, newest first, and
+// the total number of them
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID string, unreadOnly bool, page, limit int) ([]models.Notification, int, error) {
+	whereClause := "WHERE user_id = $1"
+	if unreadOnly {
+		whereClause += " AND read_at IS NULL"
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM notifications " + whereClause
+	if err := r.db.GetContext(ctx, &total, countQuery, userID); err != nil {
+		return nil, 0, fmt.Errorf("error counting notifications: %v", err)
+	}
+
+	notifications := []models.Notification{}
+	query := fmt.Sprintf(`
+		SELECT notification_id, user_id, type, title, body, memo_id, search_id, read_at, created_at
+		FROM notifications
+		%s
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, whereClause)
+
+	err := r.db.SelectContext(ctx, &notifications, query, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing notifications: %v", err)
+	}
+
+	return notifications, total, nil
+}
+
+// CountUnread counts a user's unread notifications
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %v", err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marks one of a user's notifications as read. It returns false if
+// the user has no such notification.
+func (r *NotificationRepository) MarkRead(ctx context.Context, notificationID uuid.UUID, userID string) (bool, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE notification_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, notificationID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error marking notification read: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rows > 0, nil
+}
+
+// MarkAllRead marks all of a user's notifications as read and returns how
+// many were unread
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications read: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// PushDeviceRepository handles push device registry database operations
+type PushDeviceRepository struct {
+	db *sqlx.DB
+}
+
+// NewPushDeviceRepository creates a new push device repository
+func NewPushDeviceRepository(db *sqlx.DB) *PushDeviceRepository {
+	return &PushDeviceRepository{db: db}
+}
+
+// Register adds a device, or updates it if its token is already registered.
+// A token registered by another user moves to this one. It reports whether
+// the device is new.
+func (r *PushDeviceRepository) Register(ctx context.Context, device *models.PushDevice) (bool, error) {
+	var created bool
+	query := `
+		INSERT INTO push_devices (user_id, platform, provider, token, app_version)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (provider, token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform,
+			app_version = EXCLUDED.app_version,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING device_id, created_at, updated_at, (xmax = 0)
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		device.UserID,
+		device.Platform,
+		device.Provider,
+		device.Token,
+		device.AppVersion,
+	).Scan(&device.DeviceID, &device.CreatedAt, &device.UpdatedAt, &created)
+
+	if err != nil {
+		return false, fmt.Errorf("error registering device: %v", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a device by ID
+func (r *PushDeviceRepository) GetByID(ctx context.Context, deviceID uuid.UUID) (*models.PushDevice, error) {
+	var device models.PushDevice
+	query := `
+		SELECT device_id, user_id, platform, provider, token, app_version, created_at, updated_at
+		FROM push_devices
+		WHERE device_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &device, query, deviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting device: %v", err)
+	}
+
+	return &device, nil
+}
+
+// ListByUser retrieves a user's devices, most recently registered first
+func (r *PushDeviceRepository) ListByUser(ctx context.Context, userID string) ([]models.PushDevice, error) {
+	devices := []models.PushDevice{}
+	query := `
+		SELECT device_id, user_id, platform, provider, token, app_version, created_at, updated_at
+		FROM push_devices
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+	`
+
+	if err := r.db.SelectContext(ctx, &devices, query, userID); err != nil {
+		return nil, fmt.Errorf("error listing devices: %v", err)
+	}
+
+	return devices, nil
+}
+
+// Delete removes a device
+func (r *PushDeviceRepository) Delete(ctx context.Context, deviceID uuid.UUID) error {
+	query := `DELETE FROM push_devices WHERE device_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, deviceID)
+	if err != nil {
+		return fmt.Errorf("error deleting device: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("device not found")
+	}
+
+	return nil
+}
+
+// DeleteByToken removes the device with a token the provider no longer
+// accepts. It is not an error if the device is already gone.
+func (r *PushDeviceRepository) DeleteByToken(ctx context.Context, provider, token string) error {
+	query := `DELETE FROM push_devices WHERE provider = $1 AND token = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, provider, token); err != nil {
+		return fmt.Errorf("error deleting device: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// reportColumns are the columns selected into models.Report
+const reportColumns = `
+	report_id, user_id, title, filter, timezone, status, storage_key, memo_count,
+	size_bytes, error, created_at, updated_at, completed_at
+`
+
+// ReportRepository handles PDF report database operations
+type ReportRepository struct {
+	db *sqlx.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *sqlx.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Create inserts a pending report
+func (r *ReportRepository) Create(ctx context.Context, report *models.Report) error {
+	query := `
+		INSERT INTO reports (user_id, title, filter, timezone, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING report_id, created_at, updated_at
+	`
+
+	report.Status = models.ReportStatusPending
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		report.UserID,
+		report.Title,
+		report.Filter,
+		report.Timezone,
+		report.Status,
+	).Scan(&report.ReportID, &report.CreatedAt, &report.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating report: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a report by ID
+func (r *ReportRepository) GetByID(ctx context.Context, reportID uuid.UUID) (*models.Report, error) {
+	var report models.Report
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE report_id = $1`
+
+	err := r.db.GetContext(ctx, &report, query, reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting report: %v", err)
+	}
+
+	return &report, nil
+}
+
+// ListByUser retrieves a page of a user's reports, newest first, and the
+// total number of them
+func (r *ReportRepository) ListByUser(ctx context.Context, userID string, page, limit int) ([]models.Report, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM reports WHERE user_id = $1`
+	if err := r.db.GetContext(ctx, &total, countQuery, userID); err != nil {
+		return nil, 0, fmt.Errorf("error counting reports: %v", err)
+	}
+
+	reports := []models.Report{}
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	if err := r.db.SelectContext(ctx, &reports, query, userID, limit, (page-1)*limit); err != nil {
+		return nil, 0, fmt.Errorf("error listing reports: %v", err)
+	}
+
+	return reports, total, nil
+}
+
+// SetProcessing marks a report as being generated
+func (r *ReportRepository) SetProcessing(ctx context.Context, reportID uuid.UUID) error {
+	query := `UPDATE reports SET status = $2, error = NULL WHERE report_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, reportID, models.ReportStatusProcessing); err != nil {
+		return fmt.Errorf("error updating report status: %v", err)
+	}
+
+	return nil
+}
+
+// Complete records a generated report's file
+func (r *ReportRepository) Complete(ctx context.Context, reportID uuid.UUID, storageKey string, memoCount int, sizeBytes int64) error {
+	query := `
+		UPDATE reports
+		SET status = $2, storage_key = $3, memo_count = $4, size_bytes = $5, error = NULL,
+			completed_at = CURRENT_TIMESTAMP
+		WHERE report_id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, reportID, models.ReportStatusCompleted, storageKey, memoCount, sizeBytes)
+	if err != nil {
+		return fmt.Errorf("error completing report: %v", err)
+	}
+
+	return nil
+}
+
+// Fail marks a report as failed with the reason
+func (r *ReportRepository) Fail(ctx context.Context, reportID uuid.UUID, reason string) error {
+	query := `UPDATE reports SET status = $2, error = $3 WHERE report_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, reportID, models.ReportStatusFailed, reason); err != nil {
+		return fmt.Errorf("error failing report: %v", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a report
+func (r *ReportRepository) Delete(ctx context.Context, reportID uuid.UUID) error {
+	query := `DELETE FROM reports WHERE report_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, reportID); err != nil {
+		return fmt.Errorf("error deleting report: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// RevisionRepository handles memo revision database operations
+type RevisionRepository struct {
+	db *sqlx.DB
+}
+
+// NewRevisionRepository creates a new revision repository
+func NewRevisionRepository(db *sqlx.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+// ListByMemo retrieves all revisions of a memo, oldest first
+func (r *RevisionRepository) ListByMemo(ctx context.Context, memoID uuid.UUID) ([]models.MemoRevision, error) {
+	query := `
+		SELECT
+			r.revision_id, r.memo_id, r.revision_number, r.title, r.text, r.park_name,
+			r.latitude, r.longitude, r.edited_by, u.display_name AS edited_by_name, r.created_at
+		FROM memo_revisions r
+		LEFT JOIN users u ON u.user_id = r.edited_by
+		WHERE r.memo_id = $1
+		ORDER BY r.revision_number ASC
+	`
+
+	revisions := []models.MemoRevision{}
+	if err := r.db.SelectContext(ctx, &revisions, query, memoID); err != nil {
+		return nil, fmt.Errorf("error listing revisions: %v", err)
+	}
+
+	return revisions, nil
+}
+
+// GetByNumber retrieves a single revision of a memo
+func (r *RevisionRepository) GetByNumber(ctx context.Context, memoID uuid.UUID, number int) (*models.MemoRevision, error) {
+	var revision models.MemoRevision
+	query := `
+		SELECT
+			r.revision_id, r.memo_id, r.revision_number, r.title, r.text, r.park_name,
+			r.latitude, r.longitude, r.edited_by, u.display_name AS edited_by_name, r.created_at
+		FROM memo_revisions r
+		LEFT JOIN users u ON u.user_id = r.edited_by
+		WHERE r.memo_id = $1 AND r.revision_number = $2
+	`
+
+	err := r.db.GetContext(ctx, &revision, query, memoID, number)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting revision: %v", err)
+	}
+
+	return &revision, nil
+}
+
+// GetLatestNumber returns the highest revision number of a memo (0 if it was never edited)
+func (r *RevisionRepository) GetLatestNumber(ctx context.Context, memoID uuid.UUID) (int, error) {
+	var number int
+	query := `SELECT COALESCE(MAX(revision_number), 0) FROM memo_revisions WHERE memo_id = $1`
+
+	if err := r.db.GetContext(ctx, &number, query, memoID); err != nil {
+		return 0, fmt.Errorf("error getting latest revision: %v", err)
+	}
+
+	return number, nil
+}
+
+// recordOriginalRevision snapshots a memo as revision 1, attributed to its
+// author at creation time, unless the memo already has revision history.
+func recordOriginalRevision(ctx context.Context, tx *sqlx.Tx, memoID uuid.UUID) error {
+	query := `
+		INSERT INTO memo_revisions (
+			memo_id, revision_number, title, text, park_name, latitude, longitude, edited_by, created_at
+		)
+		SELECT memo_id, 1, title, text, park_name, latitude, longitude, user_id, created_at
+		FROM memos
+		WHERE memo_id = $1
+		AND NOT EXISTS (SELECT 1 FROM memo_revisions WHERE memo_id = $1)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, memoID); err != nil {
+		return fmt.Errorf("error recording original revision: %v", err)
+	}
+
+	return nil
+}
+
+// recordRevision snapshots the current state of a memo as its next revision
+func recordRevision(ctx context.Context, tx *sqlx.Tx, memoID uuid.UUID, editedBy string) error {
+	query := `
+		INSERT INTO memo_revisions (
+			memo_id, revision_number, title, text, park_name, latitude, longitude, edited_by
+		)
+		SELECT
+			memo_id,
+			(SELECT COALESCE(MAX(revision_number), 0) + 1 FROM memo_revisions WHERE memo_id = $1),
+			title, text, park_name, latitude, longitude, $2
+		FROM memos
+		WHERE memo_id = $1
+	`
+
+	if _, err := tx.ExecContext(ctx, query, memoID, editedBy); err != nil {
+		return fmt.Errorf("error recording revision: %v", err)
+	}
+
+	return nil
+}
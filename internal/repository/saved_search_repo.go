@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// SavedSearchRepository handles saved search database operations
+type SavedSearchRepository struct {
+	db *sqlx.DB
+}
+
+// NewSavedSearchRepository creates a new saved search repository
+func NewSavedSearchRepository(db *sqlx.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+// Create creates a new saved search
+func (r *SavedSearchRepository) Create(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		INSERT INTO saved_searches (user_id, name, filter, notify_in_app, notify_email, notify_push, webhook_url, webhook_secret)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING search_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		search.UserID,
+		search.Name,
+		search.Filter,
+		search.NotifyInApp,
+		search.NotifyEmail,
+		search.NotifyPush,
+		search.WebhookURL,
+		search.WebhookSecret,
+	).Scan(&search.SearchID, &search.CreatedAt, &search.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating saved search: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a saved search by ID
+func (r *SavedSearchRepository) GetByID(ctx context.Context, searchID uuid.UUID) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	query := `
+		SELECT search_id, user_id, name, filter, notify_in_app, notify_email, notify_push, webhook_url, webhook_secret, created_at, updated_at
+		FROM saved_searches
+		WHERE search_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &search, query, searchID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting saved search: %v", err)
+	}
+
+	return &search, nil
+}
+
+// ListByUser retrieves a user's saved searches, oldest first
+func (r *SavedSearchRepository) ListByUser(ctx context.Context, userID string) ([]models.SavedSearch, error) {
+	searches := []models.SavedSearch{}
+	query := `
+		SELECT search_id, user_id, name, filter, notify_in_app, notify_email, notify_push, webhook_url, webhook_secret, created_at, updated_at
+		FROM saved_searches
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &searches, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing saved searches: %v", err)
+	}
+
+	return searches, nil
+}
+
+// ListOthers retrieves the saved searches of every user except userID, so a
+// memo is only matched against other people's searches
+func (r *SavedSearchRepository) ListOthers(ctx context.Context, userID string) ([]models.SavedSearch, error) {
+	searches := []models.SavedSearch{}
+	query := `
+		SELECT search_id, user_id, name, filter, notify_in_app, notify_email, notify_push, webhook_url, webhook_secret, created_at, updated_at
+		FROM saved_searches
+		WHERE user_id <> $1
+	`
+
+	err := r.db.SelectContext(ctx, &searches, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing saved searches: %v", err)
+	}
+
+	return searches, nil
+}
+
+// Update saves a saved search's name, filter and notification settings
+func (r *SavedSearchRepository) Update(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		UPDATE saved_searches
+		SET name = $2, filter = $3, notify_in_app = $4, notify_email = $5, notify_push = $6, webhook_url = $7,
+			webhook_secret = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE search_id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		search.SearchID,
+		search.Name,
+		search.Filter,
+		search.NotifyInApp,
+		search.NotifyEmail,
+		search.NotifyPush,
+		search.WebhookURL,
+		search.WebhookSecret,
+	).Scan(&search.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("saved search not found")
+		}
+		return fmt.Errorf("error updating saved search: %v", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a saved search
+func (r *SavedSearchRepository) Delete(ctx context.Context, searchID uuid.UUID) error {
+	query := `DELETE FROM saved_searches WHERE search_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, searchID)
+	if err != nil {
+		return fmt.Errorf("error deleting saved search: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("saved search not found")
+	}
+
+	return nil
+}
+
+// HasMatch reports whether a saved search has already notified about a memo
+func (r *SavedSearchRepository) HasMatch(ctx context.Context, searchID, memoID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM saved_search_matches WHERE search_id = $1 AND memo_id = $2)`
+
+	err := r.db.GetContext(ctx, &exists, query, searchID, memoID)
+	if err != nil {
+		return false, fmt.Errorf("error checking saved search match: %v", err)
+	}
+
+	return exists, nil
+}
+
+// RecordMatch records that a saved search matched a memo and, in the same
+// transaction, stores the notification about it. It does nothing and returns
+// false if the search has already notified about the memo.
+func (r *SavedSearchRepository) RecordMatch(ctx context.Context, searchID, memoID uuid.UUID, delivery *models.NotificationDelivery) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO saved_search_matches (search_id, memo_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	result, err := tx.ExecContext(ctx, query, searchID, memoID)
+	if err != nil {
+		return false, fmt.Errorf("error recording saved search match: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error recording saved search match: %v", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := deliverNotification(ctx, tx, delivery); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing saved search match: %v", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// prefixTermPattern matches search terms ending in * such as "wash*"
+var prefixTermPattern = regexp.MustCompile(`^[\p{L}\p{N}]+\*$`)
+
+// fuzzyRankWeight scales trigram word similarity (0-1) when it is added to
+// ts_rank, so exact matches still rank above near misses
+const fuzzyRankWeight = 0.5
+
+// headlineOptions marks matched terms in search snippets
+const headlineOptions = `StartSel=**, StopSel=**, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "`
+
+// boundSearchQuery holds the placeholders of a user search split into its
+// websearch part and its prefix terms
+type boundSearchQuery struct {
+	websearch string
+	prefixes  string
+}
+
+// bindSearchQuery splits a user search into arguments. It accepts websearch
+// syntax ("quoted phrases", OR, -negation) plus a trailing * on a word for
+// prefix matching, which websearch_to_tsquery does not support.
+func bindSearchQuery(q string, args *sqlArgs) boundSearchQuery {
+	websearch := []string{}
+	prefixes := []string{}
+	for _, field := range strings.Fields(q) {
+		if prefixTermPattern.MatchString(field) {
+			prefixes = append(prefixes, strings.TrimSuffix(field, "*")+":*")
+		} else {
+			websearch = append(websearch, field)
+		}
+	}
+
+	bound := boundSearchQuery{}
+	if len(websearch) > 0 {
+		bound.websearch = args.add(strings.Join(websearch, " "))
+	}
+	if len(prefixes) > 0 {
+		bound.prefixes = args.add(strings.Join(prefixes, " & "))
+	}
+	return bound
+}
+
+// tsquery builds the tsquery expression for the search parsed with config,
+// a SQL expression yielding a text search configuration
+func (b boundSearchQuery) tsquery(config string) string {
+	parts := []string{}
+	if b.websearch != "" {
+		parts = append(parts, fmt.Sprintf("websearch_to_tsquery(%s, %s)", config, b.websearch))
+	}
+	if b.prefixes != "" {
+		parts = append(parts, fmt.Sprintf("to_tsquery(%s, %s)", config, b.prefixes))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("websearch_to_tsquery(%s, '')", config)
+	}
+
+	return "(" + strings.Join(parts, " && ") + ")"
+}
+
+// memoSearchConfig is the text search configuration of a memo row, matching
+// the one its search vector was built with
+const memoSearchConfig = "memo_search_config(language)"
+
+// searchTerms is a user search split the way websearch_to_tsquery reads it
+type searchTerms struct {
+	words   []string // Bare words, without any trailing *
+	phrases []string // Quoted phrases, which must match exactly
+	negated []string // Words and phrases after -, which must not match
+}
+
+// parseSearchTerms splits a user search into bare words, quoted phrases and
+// negated terms. OR is dropped.
+func parseSearchTerms(q string) searchTerms {
+	terms := searchTerms{}
+	var phrase []string
+	inPhrase, negatedPhrase := false, false
+
+	for _, field := range strings.Fields(q) {
+		if inPhrase {
+			closed := strings.HasSuffix(field, `"`)
+			if word := strings.Trim(field, `"`); word != "" {
+				phrase = append(phrase, word)
+			}
+			if closed {
+				terms.addPhrase(strings.Join(phrase, " "), negatedPhrase)
+				inPhrase = false
+			}
+			continue
+		}
+
+		negated := strings.HasPrefix(field, "-")
+		rest := strings.TrimPrefix(field, "-")
+		if strings.HasPrefix(rest, `"`) {
+			body := rest[1:]
+			if strings.HasSuffix(body, `"`) {
+				terms.addPhrase(strings.TrimSuffix(body, `"`), negated)
+				continue
+			}
+			phrase = phrase[:0]
+			if body != "" {
+				phrase = append(phrase, body)
+			}
+			inPhrase, negatedPhrase = true, negated
+			continue
+		}
+
+		word := strings.Trim(rest, `"*`)
+		switch {
+		case word == "":
+		case negated:
+			terms.negated = append(terms.negated, word)
+		case strings.EqualFold(word, "or"):
+		default:
+			terms.words = append(terms.words, word)
+		}
+	}
+
+	// websearch_to_tsquery treats an unclosed quote as closed at the end
+	if inPhrase {
+		terms.addPhrase(strings.Join(phrase, " "), negatedPhrase)
+	}
+	return terms
+}
+
+// addPhrase records a quoted phrase, ignoring empty ones
+func (t *searchTerms) addPhrase(phrase string, negated bool) {
+	if phrase = strings.TrimSpace(phrase); phrase == "" {
+		return
+	}
+	if negated {
+		t.negated = append(t.negated, phrase)
+	} else {
+		t.phrases = append(t.phrases, phrase)
+	}
+}
+
+// fuzzyText strips search syntax from a query, leaving the words to compare
+// by trigram similarity. Negated terms and phrases are dropped.
+func fuzzyText(q string) string {
+	return strings.Join(parseSearchTerms(q).words, " ")
+}
+
+// textCondition matches memos by full text or, to tolerate typos and
+// transcription errors, by trigram word similarity to the title, park name
+// or text (pg_trgm's <% operator, using word_similarity_threshold). The query
+// is parsed once per language and each memo is matched against the parse for
+// its own language, so stemming agrees with its search vector.
+//
+// Only bare words are matched loosely: a memo found by similarity must still
+// contain every quoted phrase and none of the negated terms, so "trail
+// -washout" never returns a memo about a washout.
+func textCondition(q string, args *sqlArgs) string {
+	bound := bindSearchQuery(q, args)
+	branches := []string{}
+	for _, lang := range models.Languages {
+		branches = append(branches, fmt.Sprintf("(language = '%s' AND search_vector @@ %s)",
+			lang.Code, bound.tsquery("'"+lang.SearchConfig+"'")))
+	}
+	cond := "(" + strings.Join(branches, " OR ") + ")"
+
+	terms := parseSearchTerms(q)
+	if len(terms.words) == 0 {
+		return cond
+	}
+
+	arg := args.add(strings.Join(terms.words, " "))
+	fuzzy := []string{fmt.Sprintf("(%[1]s <%% text OR %[1]s <%% title OR %[1]s <%% park_name)", arg)}
+	for _, phrase := range terms.phrases {
+		fuzzy = append(fuzzy, fmt.Sprintf("search_vector @@ phraseto_tsquery(%s, %s)", memoSearchConfig, args.add(phrase)))
+	}
+	for _, negated := range terms.negated {
+		fuzzy = append(fuzzy, fmt.Sprintf("NOT (search_vector @@ phraseto_tsquery(%s, %s))", memoSearchConfig, args.add(negated)))
+	}
+
+	return fmt.Sprintf("(%s OR (%s))", cond, strings.Join(fuzzy, " AND "))
+}
+
+// likeEscaper escapes LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// statsBucketFormat is how bucket starts are passed from Postgres, as local
+// dates in the requested time zone
+const statsBucketFormat = "2006-01-02"
+
+// statsResolutionHours averages the hours resolved memos took from being
+// recorded to being resolved
+const statsResolutionHours = "AVG(EXTRACT(EPOCH FROM resolved_at - created_at) / 3600) FILTER (WHERE resolved_at IS NOT NULL)"
+
+// statsGrouping describes how memos are grouped for one group_by value
+type statsGrouping struct {
+	value string
+	label string
+	from  string
+	extra []string
+}
+
+// statsGroupings maps group_by values to the grouped expression. Memos
+// without a value for the grouping are left out.
+var statsGroupings = map[string]statsGrouping{
+	models.StatsGroupPark:     {"park_name", "NULL::text", "memos", []string{"park_name IS NOT NULL"}},
+	models.StatsGroupUser:     {"user_id", "MAX(user_name)", "memos", nil},
+	models.StatsGroupTag:      {"tag", "NULL::text", "memos, unnest(memos.tags) AS tag", nil},
+	models.StatsGroupCategory: {"category", "NULL::text", "memos", []string{"category IS NOT NULL"}},
+	models.StatsGroupStatus:   {"status", "NULL::text", "memos", nil},
+	// The author's current department; memos don't record one
+	models.StatsGroupDepartment: {"department", "NULL::text",
+		"memos JOIN LATERAL (SELECT NULLIF(users.department, '') AS department FROM users WHERE users.user_id = memos.user_id) AS author ON TRUE",
+		[]string{"department IS NOT NULL"}},
+}
+
+// StatsRepository computes memo statistics
+type StatsRepository struct {
+	db *sqlx.DB
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *sqlx.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// Summary totals the memos matching the filter
+func (r *StatsRepository) Summary(ctx context.Context, filter *models.MemoFilter) (*models.StatsSummary, error) {
+	args := &sqlArgs{}
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE audio_key IS NOT NULL),
+			COALESCE(SUM(duration_seconds), 0) / 60.0,
+			COUNT(DISTINCT user_id),
+			COUNT(DISTINCT park_name),
+			MIN(created_at),
+			MAX(created_at),
+			COUNT(*) FILTER (WHERE resolved_at IS NOT NULL),
+			%s
+		FROM memos
+		%s
+	`, statsResolutionHours, whereClause(memoConditions(filter), args, ""))
+
+	var summary models.StatsSummary
+	var first, last sql.NullTime
+	var resolution sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, query, args.values...).Scan(
+		&summary.TotalMemos,
+		&summary.AudioMemos,
+		&summary.AudioMinutes,
+		&summary.Contributors,
+		&summary.Parks,
+		&first,
+		&last,
+		&summary.ResolvedMemos,
+		&resolution,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error computing memo summary: %v", err)
+	}
+
+	if first.Valid {
+		summary.FirstMemoAt = &first.Time
+	}
+	if last.Valid {
+		summary.LastMemoAt = &last.Time
+	}
+	if resolution.Valid {
+		summary.AvgResolutionHours = &resolution.Float64
+	}
+
+	return &summary, nil
+}
+
+// Breakdown counts the memos matching the filter per group, largest first,
+// up to limit groups
+func (r *StatsRepository) Breakdown(ctx context.Context, filter *models.MemoFilter, groupBy string, limit int) ([]models.StatsGroup, error) {
+	grouping, ok := statsGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown stats grouping %q", groupBy)
+	}
+
+	args := &sqlArgs{}
+	query := fmt.Sprintf(`
+		SELECT %s AS key, %s AS label, COUNT(*) AS count, COALESCE(SUM(duration_seconds), 0) / 60.0 AS audio_minutes,
+			%s AS avg_resolution_hours
+		FROM %s
+		%s
+		GROUP BY 1
+		ORDER BY count DESC, key
+		LIMIT %d
+	`, grouping.value, grouping.label, statsResolutionHours, grouping.from, whereClause(memoConditions(filter), args, "", grouping.extra...), limit)
+
+	groups := []models.StatsGroup{}
+	if err := r.db.SelectContext(ctx, &groups, query, args.values...); err != nil {
+		return nil, fmt.Errorf("error computing memo breakdown: %v", err)
+	}
+
+	return groups, nil
+}
+
+// statsBucketRow is one bucket of a time series query
+type statsBucketRow struct {
+	Key          string  `db:"key"`
+	Label        *string `db:"label"`
+	Bucket       string  `db:"bucket"`
+	Count        int     `db:"count"`
+	AudioMinutes float64 `db:"audio_minutes"`
+}
+
+// TimeSeries counts the memos matching the filter per interval bucket in
+// loc, between the filter's start and end dates, which must be set. Buckets
+// without memos are included with zero counts. With a grouping, it also
+// returns the series of the limit largest groups.
+func (r *StatsRepository) TimeSeries(ctx context.Context, filter *models.MemoFilter, interval string, loc *time.Location, groupBy string, limit int) ([]models.StatsBucket, []models.StatsSeries, error) {
+	if filter.StartDate == nil || filter.EndDate == nil {
+		return nil, nil, fmt.Errorf("time series needs a start and end date")
+	}
+	starts := models.StatsBucketStarts(*filter.StartDate, *filter.EndDate, interval, loc)
+
+	args := &sqlArgs{}
+	bucketExpr := fmt.Sprintf("to_char(date_trunc(%s, (created_at AT TIME ZONE 'UTC') AT TIME ZONE %s), 'YYYY-MM-DD')",
+		args.add(interval), args.add(loc.String()))
+	query := fmt.Sprintf(`
+		SELECT '' AS key, NULL::text AS label, %s AS bucket, COUNT(*) AS count,
+			COALESCE(SUM(duration_seconds), 0) / 60.0 AS audio_minutes
+		FROM memos
+		%s
+		GROUP BY 3
+	`, bucketExpr, whereClause(memoConditions(filter), args, ""))
+
+	rows := []statsBucketRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args.values...); err != nil {
+		return nil, nil, fmt.Errorf("error computing memo time series: %v", err)
+	}
+	buckets := fillStatsBuckets(starts, rows)
+
+	if groupBy == "" {
+		return buckets, nil, nil
+	}
+
+	grouping, ok := statsGroupings[groupBy]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown stats grouping %q", groupBy)
+	}
+
+	args = &sqlArgs{}
+	bucketExpr = fmt.Sprintf("to_char(date_trunc(%s, (created_at AT TIME ZONE 'UTC') AT TIME ZONE %s), 'YYYY-MM-DD')",
+		args.add(interval), args.add(loc.String()))
+	query = fmt.Sprintf(`
+		WITH grouped AS (
+			SELECT %s AS key, %s AS label, %s AS bucket, COUNT(*) AS count,
+				COALESCE(SUM(duration_seconds), 0) / 60.0 AS audio_minutes
+			FROM %s
+			%s
+			GROUP BY 1, 3
+		),
+		top AS (
+			SELECT key FROM grouped GROUP BY key ORDER BY SUM(count) DESC, key LIMIT %d
+		)
+		SELECT grouped.* FROM grouped JOIN top USING (key)
+	`, grouping.value, grouping.label, bucketExpr, grouping.from,
+		whereClause(memoConditions(filter), args, "", grouping.extra...), limit)
+
+	rows = []statsBucketRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args.values...); err != nil {
+		return nil, nil, fmt.Errorf("error computing memo time series: %v", err)
+	}
+
+	byKey := map[string][]statsBucketRow{}
+	order := []string{}
+	for _, row := range rows {
+		if _, ok := byKey[row.Key]; !ok {
+			order = append(order, row.Key)
+		}
+		byKey[row.Key] = append(byKey[row.Key], row)
+	}
+
+	series := []models.StatsSeries{}
+	for _, key := range order {
+		s := models.StatsSeries{Key: key, Buckets: fillStatsBuckets(starts, byKey[key])}
+		for _, row := range byKey[key] {
+			s.Count += row.Count
+			if row.Label != nil {
+				s.Label = row.Label
+			}
+		}
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Count != series[j].Count {
+			return series[i].Count > series[j].Count
+		}
+		return series[i].Key < series[j].Key
+	})
+
+	return buckets, series, nil
+}
+
+// fillStatsBuckets lays rows out over the bucket starts, with zero counts for
+// buckets that have no row
+func fillStatsBuckets(starts []time.Time, rows []statsBucketRow) []models.StatsBucket {
+	byBucket := map[string]statsBucketRow{}
+	for _, row := range rows {
+		byBucket[row.Bucket] = row
+	}
+
+	buckets := make([]models.StatsBucket, 0, len(starts))
+	for _, start := range starts {
+		row := byBucket[start.Format(statsBucketFormat)]
+		buckets = append(buckets, models.StatsBucket{
+			Start:        start,
+			Count:        row.Count,
+			AudioMinutes: row.AudioMinutes,
+		})
+	}
+	return buckets
+}
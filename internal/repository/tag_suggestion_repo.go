@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// TagSuggestionRepository handles suggested tag database operations
+type TagSuggestionRepository struct {
+	db *sqlx.DB
+}
+
+// NewTagSuggestionRepository creates a new tag suggestion repository
+func NewTagSuggestionRepository(db *sqlx.DB) *TagSuggestionRepository {
+	return &TagSuggestionRepository{db: db}
+}
+
+// Upsert creates or replaces the suggestions for a memo
+func (r *TagSuggestionRepository) Upsert(ctx context.Context, suggestion *models.TagSuggestion) error {
+	query := `
+		INSERT INTO memo_tag_suggestions (memo_id, tags, category, keywords)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (memo_id) DO UPDATE SET
+			tags = EXCLUDED.tags,
+			category = EXCLUDED.category,
+			keywords = EXCLUDED.keywords,
+			created_at = CURRENT_TIMESTAMP,
+			accepted_at = NULL
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		suggestion.MemoID,
+		suggestion.Tags,
+		suggestion.Category,
+		suggestion.Keywords,
+	).Scan(&suggestion.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving tag suggestions: %v", err)
+	}
+
+	return nil
+}
+
+// GetByMemo retrieves the suggestions for a memo
+func (r *TagSuggestionRepository) GetByMemo(ctx context.Context, memoID uuid.UUID) (*models.TagSuggestion, error) {
+	var suggestion models.TagSuggestion
+	query := `
+		SELECT memo_id, tags, category, keywords, created_at, accepted_at
+		FROM memo_tag_suggestions
+		WHERE memo_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &suggestion, query, memoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting tag suggestions: %v", err)
+	}
+
+	return &suggestion, nil
+}
+
+// Accept adds tags to a memo, keeping its existing tags, sets its category
+// when category is not nil, and marks the suggestions as accepted. If audit
+// is not nil it is completed with the memo's previous and new tags and
+// category and recorded in the same transaction.
+func (r *TagSuggestionRepository) Accept(ctx context.Context, memoID uuid.UUID, tags []string, category *string, audit *models.AuditEntry) (*models.MemoTagsResponse, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var previousTags pq.StringArray
+	var previousCategory *string
+	query := `SELECT tags, category FROM memos WHERE memo_id = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, memoID).Scan(&previousTags, &previousCategory); err != nil {
+		return nil, fmt.Errorf("error locking memo: %v", err)
+	}
+
+	var memoTags pq.StringArray
+	response := &models.MemoTagsResponse{MemoID: memoID}
+	query = `
+		UPDATE memos
+		SET
+			tags = ARRAY(
+				SELECT tag
+				FROM unnest(tags || $2::text[]) WITH ORDINALITY AS t(tag, position)
+				GROUP BY tag
+				ORDER BY MIN(position)
+			),
+			category = COALESCE($3, category)
+		WHERE memo_id = $1
+		RETURNING tags, category
+	`
+
+	err = tx.QueryRowContext(ctx, query, memoID, pq.Array(tags), category).Scan(&memoTags, &response.Category)
+	if err != nil {
+		return nil, fmt.Errorf("error updating memo tags: %v", err)
+	}
+	response.Tags = memoTags
+
+	_, err = tx.ExecContext(ctx, `UPDATE memo_tag_suggestions SET accepted_at = CURRENT_TIMESTAMP WHERE memo_id = $1`, memoID)
+	if err != nil {
+		return nil, fmt.Errorf("error accepting tag suggestions: %v", err)
+	}
+
+	if audit != nil {
+		audit.Before = models.AuditSnapshot(map[string]interface{}{"tags": []string(previousTags), "category": previousCategory})
+		audit.After = models.AuditSnapshot(map[string]interface{}{"tags": response.Tags, "category": response.Category})
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return response, nil
+}
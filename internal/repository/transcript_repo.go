@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// TranscriptRepository handles server-generated transcript database operations
+type TranscriptRepository struct {
+	db *sqlx.DB
+}
+
+// NewTranscriptRepository creates a new transcript repository
+func NewTranscriptRepository(db *sqlx.DB) *TranscriptRepository {
+	return &TranscriptRepository{db: db}
+}
+
+// Upsert creates or replaces the transcript of a memo
+func (r *TranscriptRepository) Upsert(ctx context.Context, transcript *models.Transcript) error {
+	query := `
+		INSERT INTO memo_transcripts (memo_id, engine, language, text, words, segments, confidence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (memo_id) DO UPDATE SET
+			engine = EXCLUDED.engine,
+			language = EXCLUDED.language,
+			text = EXCLUDED.text,
+			words = EXCLUDED.words,
+			segments = EXCLUDED.segments,
+			confidence = EXCLUDED.confidence,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		transcript.MemoID,
+		transcript.Engine,
+		transcript.Language,
+		transcript.Text,
+		transcript.Words,
+		transcript.Segments,
+		transcript.Confidence,
+	).Scan(&transcript.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving transcript: %v", err)
+	}
+
+	return nil
+}
+
+// GetByMemo retrieves the transcript of a memo
+func (r *TranscriptRepository) GetByMemo(ctx context.Context, memoID uuid.UUID) (*models.Transcript, error) {
+	var transcript models.Transcript
+	query := `
+		SELECT memo_id, engine, language, text, words, segments, confidence, created_at
+		FROM memo_transcripts
+		WHERE memo_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &transcript, query, memoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting transcript: %v", err)
+	}
+
+	return &transcript, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// UploadRepository handles resumable upload database operations
+type UploadRepository struct {
+	db *sqlx.DB
+}
+
+// NewUploadRepository creates a new upload repository
+func NewUploadRepository(db *sqlx.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Create creates a new resumable upload
+func (r *UploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	query := `
+		INSERT INTO uploads (user_id, object_key, filename, content_type, upload_length, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING upload_id, upload_offset, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		upload.UserID,
+		upload.ObjectKey,
+		upload.Filename,
+		upload.ContentType,
+		upload.UploadLength,
+		upload.ExpiresAt,
+	).Scan(&upload.UploadID, &upload.UploadOffset, &upload.CreatedAt, &upload.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating upload: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an upload by its ID
+func (r *UploadRepository) GetByID(ctx context.Context, uploadID uuid.UUID) (*models.Upload, error) {
+	var upload models.Upload
+	query := `
+		SELECT
+			upload_id, user_id, object_key, filename, content_type, upload_length, upload_offset,
+			locked_until, completed_at, expires_at, created_at, updated_at
+		FROM uploads
+		WHERE upload_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &upload, query, uploadID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting upload: %v", err)
+	}
+
+	return &upload, nil
+}
+
+// Claim takes a short-lived write lease on an upload at the given offset and
+// returns the lease expiry, which Release uses as the lease token. It returns
+// nil if the offset no longer matches, the upload has expired, or another
+// request holds the lease, so concurrent PATCH requests cannot interleave chunks.
+func (r *UploadRepository) Claim(ctx context.Context, uploadID uuid.UUID, offset int64, lease time.Duration) (*time.Time, error) {
+	var lockedUntil time.Time
+	query := `
+		UPDATE uploads
+		SET locked_until = NOW() + $3 * INTERVAL '1 second'
+		WHERE upload_id = $1
+		AND upload_offset = $2
+		AND completed_at IS NULL
+		AND expires_at > NOW()
+		AND (locked_until IS NULL OR locked_until < NOW())
+		RETURNING locked_until
+	`
+
+	err := r.db.QueryRowContext(ctx, query, uploadID, offset, int(lease.Seconds())).Scan(&lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error claiming upload: %v", err)
+	}
+
+	return &lockedUntil, nil
+}
+
+// Release advances the upload offset by the number of bytes written and
+// drops the write lease, marking the upload complete once all bytes arrived.
+// It only applies while the upload is still at the claimed offset under the
+// claimed lease, and returns nil if the lease was lost in the meantime.
+func (r *UploadRepository) Release(ctx context.Context, uploadID uuid.UUID, offset int64, lockedUntil time.Time, written int64) (*models.Upload, error) {
+	query := `
+		UPDATE uploads
+		SET
+			upload_offset = upload_offset + $4,
+			completed_at = CASE WHEN upload_offset + $4 >= upload_length THEN NOW() ELSE NULL END,
+			locked_until = NULL
+		WHERE upload_id = $1
+		AND upload_offset = $2
+		AND locked_until = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, uploadID, offset, lockedUntil, written)
+	if err != nil {
+		return nil, fmt.Errorf("error updating upload offset: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %v", err)
+	}
+
+	if rows == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(ctx, uploadID)
+}
+
+// ListExpired returns incomplete uploads that expired and are not being
+// written, oldest first
+func (r *UploadRepository) ListExpired(ctx context.Context, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	query := `
+		SELECT
+			upload_id, user_id, object_key, filename, content_type, upload_length, upload_offset,
+			locked_until, completed_at, expires_at, created_at, updated_at
+		FROM uploads
+		WHERE completed_at IS NULL
+		AND expires_at < NOW()
+		AND (locked_until IS NULL OR locked_until < NOW())
+		ORDER BY expires_at
+		LIMIT $1
+	`
+
+	if err := r.db.SelectContext(ctx, &uploads, query, limit); err != nil {
+		return nil, fmt.Errorf("error listing expired uploads: %v", err)
+	}
+
+	return uploads, nil
+}
+
+// Delete deletes an upload
+func (r *UploadRepository) Delete(ctx context.Context, uploadID uuid.UUID) error {
+	query := `DELETE FROM uploads WHERE upload_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, uploadID); err != nil {
+		return fmt.Errorf("error deleting upload: %v", err)
+	}
+
+	return nil
+}
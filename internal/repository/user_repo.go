@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/tom-fitz/trailmemo-api/internal/models"
 )
 
@@ -19,15 +21,22 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create creates a new user
-func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+// Create creates a new user. If audit is not nil it is completed with the
+// new user and recorded in the same transaction.
+func (r *UserRepository) Create(ctx context.Context, user *models.User, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO users (user_id, email, display_name, department, color)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING created_at
+		RETURNING role, created_at
 	`
 
-	err := r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		user.UserID,
@@ -35,12 +44,23 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.DisplayName,
 		user.Department,
 		user.Color,
-	).Scan(&user.CreatedAt)
+	).Scan(&user.Role, &user.CreatedAt)
 
 	if err != nil {
 		return fmt.Errorf("error creating user: %v", err)
 	}
 
+	if audit != nil {
+		audit.After = models.AuditSnapshot(user)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing user: %v", err)
+	}
+
 	return nil
 }
 
@@ -48,7 +68,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 func (r *UserRepository) GetByID(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
 	query := `
-		SELECT user_id, email, display_name, department, color, created_at
+		SELECT user_id, email, display_name, department, color, role, created_at
 		FROM users
 		WHERE user_id = $1
 	`
@@ -68,7 +88,7 @@ func (r *UserRepository) GetByID(ctx context.Context, userID string) (*models.Us
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	query := `
-		SELECT user_id, email, display_name, department, color, created_at
+		SELECT user_id, email, display_name, department, color, role, created_at
 		FROM users
 		WHERE email = $1
 	`
@@ -84,6 +104,24 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
+// ListByMentionKeys retrieves the users whose display name or first name
+// has one of the given keys (see services.MentionKey)
+func (r *UserRepository) ListByMentionKeys(ctx context.Context, keys []string) ([]models.User, error) {
+	users := []models.User{}
+	query := `
+		SELECT user_id, email, display_name, department, color, role, created_at
+		FROM users
+		WHERE lower(regexp_replace(display_name, '[^[:alnum:]]', '', 'g')) = ANY($1)
+		OR lower(regexp_replace(split_part(trim(display_name), ' ', 1), '[^[:alnum:]]', '', 'g')) = ANY($1)
+	`
+
+	if err := r.db.SelectContext(ctx, &users, query, pq.Array(keys)); err != nil {
+		return nil, fmt.Errorf("error listing users by name: %v", err)
+	}
+
+	return users, nil
+}
+
 // Update updates user information
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
@@ -100,6 +138,116 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+// SetRole changes a user's role. It returns false, changing nothing, if that
+// would demote the last admin. The admins are locked while checking, so two
+// admins demoting each other at once can't both succeed. If audit is not nil
+// it is completed with the previous and new role and recorded in the same
+// transaction.
+func (r *UserRepository) SetRole(ctx context.Context, userID, role string, audit *models.AuditEntry) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if role != models.RoleAdmin {
+		admins := []string{}
+		query := `SELECT user_id FROM users WHERE role = $1 FOR UPDATE`
+		if err := tx.SelectContext(ctx, &admins, query, models.RoleAdmin); err != nil {
+			return false, fmt.Errorf("error locking admins: %v", err)
+		}
+
+		isAdmin := false
+		for _, admin := range admins {
+			if admin == userID {
+				isAdmin = true
+				break
+			}
+		}
+		if isAdmin && len(admins) <= 1 {
+			return false, nil
+		}
+	}
+
+	var previous string
+	query := `SELECT role FROM users WHERE user_id = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &previous, query, userID); err != nil {
+		return false, fmt.Errorf("error locking user: %v", err)
+	}
+
+	query = `UPDATE users SET role = $1 WHERE user_id = $2`
+	if _, err := tx.ExecContext(ctx, query, role, userID); err != nil {
+		return false, fmt.Errorf("error updating user role: %v", err)
+	}
+
+	if audit != nil {
+		audit.Before = models.AuditSnapshot(map[string]string{"role": previous})
+		audit.After = models.AuditSnapshot(map[string]string{"role": role})
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing user role: %v", err)
+	}
+
+	return true, nil
+}
+
+// ListParks retrieves the parks a user is assigned to, alphabetically
+func (r *UserRepository) ListParks(ctx context.Context, userID string) ([]string, error) {
+	parks := []string{}
+	query := `SELECT park_name FROM user_parks WHERE user_id = $1 ORDER BY park_name`
+
+	if err := r.db.SelectContext(ctx, &parks, query, userID); err != nil {
+		return nil, fmt.Errorf("error listing user parks: %v", err)
+	}
+
+	return parks, nil
+}
+
+// SetParks replaces the parks a user is assigned to. If audit is not nil it
+// is completed with the previous and new parks and recorded in the same
+// transaction.
+func (r *UserRepository) SetParks(ctx context.Context, userID string, parkNames []string, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	previous := []string{}
+	query := `DELETE FROM user_parks WHERE user_id = $1 RETURNING park_name`
+	if err := tx.SelectContext(ctx, &previous, query, userID); err != nil {
+		return fmt.Errorf("error clearing user parks: %v", err)
+	}
+
+	query = `
+		INSERT INTO user_parks (user_id, park_name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, pq.Array(parkNames)); err != nil {
+		return fmt.Errorf("error assigning user parks: %v", err)
+	}
+
+	if audit != nil {
+		sort.Strings(previous)
+		audit.Before = models.AuditSnapshot(map[string][]string{"park_names": previous})
+		audit.After = models.AuditSnapshot(map[string][]string{"park_names": parkNames})
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing user parks: %v", err)
+	}
+
+	return nil
+}
+
 // Delete deletes a user
 func (r *UserRepository) Delete(ctx context.Context, userID string) error {
 	query := `DELETE FROM users WHERE user_id = $1`
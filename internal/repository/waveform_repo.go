@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// WaveformRepository handles waveform peak database operations
+type WaveformRepository struct {
+	db *sqlx.DB
+}
+
+// NewWaveformRepository creates a new waveform repository
+func NewWaveformRepository(db *sqlx.DB) *WaveformRepository {
+	return &WaveformRepository{db: db}
+}
+
+// Upsert creates or replaces the waveform of a memo
+func (r *WaveformRepository) Upsert(ctx context.Context, waveform *models.Waveform) error {
+	query := `
+		INSERT INTO memo_waveforms (memo_id, peaks, peaks_per_second, sample_rate, duration_seconds)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (memo_id) DO UPDATE SET
+			peaks = EXCLUDED.peaks,
+			peaks_per_second = EXCLUDED.peaks_per_second,
+			sample_rate = EXCLUDED.sample_rate,
+			duration_seconds = EXCLUDED.duration_seconds,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		waveform.MemoID,
+		waveform.Peaks,
+		waveform.PeaksPerSecond,
+		waveform.SampleRate,
+		waveform.DurationSeconds,
+	).Scan(&waveform.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error saving waveform: %v", err)
+	}
+
+	return nil
+}
+
+// GetByMemo retrieves the waveform of a memo
+func (r *WaveformRepository) GetByMemo(ctx context.Context, memoID uuid.UUID) (*models.Waveform, error) {
+	var waveform models.Waveform
+	query := `
+		SELECT memo_id, peaks, peaks_per_second, sample_rate, duration_seconds, created_at
+		FROM memo_waveforms
+		WHERE memo_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &waveform, query, memoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting waveform: %v", err)
+	}
+
+	return &waveform, nil
+}
@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// WebhookRepository handles webhook subscription and delivery database operations
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create creates a new webhook subscription. If audit is not nil it is
+// completed with the new subscription and recorded in the same transaction.
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.WebhookSubscription, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhook_subscriptions (user_id, url, description, event_types, secret, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING subscription_id, created_at, updated_at
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Description,
+		webhook.EventTypes,
+		webhook.Secret,
+		webhook.Active,
+	).Scan(&webhook.SubscriptionID, &webhook.CreatedAt, &webhook.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating webhook: %v", err)
+	}
+
+	if audit != nil {
+		targetID := webhook.SubscriptionID.String()
+		audit.TargetID = &targetID
+		audit.After = models.AuditSnapshot(webhook)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing webhook: %v", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a webhook subscription by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, subscriptionID uuid.UUID) (*models.WebhookSubscription, error) {
+	var webhook models.WebhookSubscription
+	query := `
+		SELECT subscription_id, user_id, url, description, event_types, secret, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE subscription_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &webhook, query, subscriptionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting webhook: %v", err)
+	}
+
+	return &webhook, nil
+}
+
+// ListByUser retrieves a user's webhook subscriptions, oldest first
+func (r *WebhookRepository) ListByUser(ctx context.Context, userID string) ([]models.WebhookSubscription, error) {
+	webhooks := []models.WebhookSubscription{}
+	query := `
+		SELECT subscription_id, user_id, url, description, event_types, secret, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	err := r.db.SelectContext(ctx, &webhooks, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %v", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update saves a webhook subscription's URL, description, events and state.
+// If audit is not nil it is completed with the subscription as it was and as
+// it is now and recorded in the same transaction.
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.WebhookSubscription, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var previous models.WebhookSubscription
+	query := `
+		SELECT subscription_id, user_id, url, description, event_types, secret, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE subscription_id = $1
+		FOR UPDATE
+	`
+	if err := tx.GetContext(ctx, &previous, query, webhook.SubscriptionID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("webhook not found")
+		}
+		return fmt.Errorf("error locking webhook: %v", err)
+	}
+
+	query = `
+		UPDATE webhook_subscriptions
+		SET url = $2, description = $3, event_types = $4, active = $5
+		WHERE subscription_id = $1
+		RETURNING updated_at
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		webhook.SubscriptionID,
+		webhook.URL,
+		webhook.Description,
+		webhook.EventTypes,
+		webhook.Active,
+	).Scan(&webhook.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error updating webhook: %v", err)
+	}
+
+	if audit != nil {
+		audit.Before = models.AuditSnapshot(previous)
+		audit.After = models.AuditSnapshot(webhook)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing webhook: %v", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a webhook subscription and its delivery log. If audit is
+// not nil it is completed with the deleted subscription and recorded in the
+// same transaction.
+func (r *WebhookRepository) Delete(ctx context.Context, subscriptionID uuid.UUID, audit *models.AuditEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var previous models.WebhookSubscription
+	query := `
+		DELETE FROM webhook_subscriptions
+		WHERE subscription_id = $1
+		RETURNING subscription_id, user_id, url, description, event_types, secret, active, created_at, updated_at
+	`
+	if err := tx.GetContext(ctx, &previous, query, subscriptionID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("webhook not found")
+		}
+		return fmt.Errorf("error deleting webhook: %v", err)
+	}
+
+	if audit != nil {
+		audit.Before = models.AuditSnapshot(previous)
+		if err := recordAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing webhook deletion: %v", err)
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	query := `
+		SELECT delivery_id, subscription_id, event_id, event_type, memo_id, changes, occurred_at, payload,
+			status, attempts, last_status_code, last_error, delivered_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE delivery_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &delivery, query, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting webhook delivery: %v", err)
+	}
+
+	return &delivery, nil
+}
+
+// ListDeliveries retrieves a page of a subscription's deliveries, newest
+// first, optionally only those with the given status, and the total number
+// of them. Payloads are left out.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, page, limit int) ([]models.WebhookDelivery, int, error) {
+	whereClause := "WHERE subscription_id = $1 AND ($2 = '' OR status = $2)"
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM webhook_deliveries " + whereClause
+	if err := r.db.GetContext(ctx, &total, countQuery, subscriptionID, status); err != nil {
+		return nil, 0, fmt.Errorf("error counting webhook deliveries: %v", err)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	query := fmt.Sprintf(`
+		SELECT delivery_id, subscription_id, event_id, event_type, memo_id, changes, occurred_at, NULL AS payload,
+			status, attempts, last_status_code, last_error, delivered_at, created_at, updated_at
+		FROM webhook_deliveries
+		%s
+		ORDER BY created_at DESC, event_id DESC
+		LIMIT $3 OFFSET $4
+	`, whereClause)
+
+	err := r.db.SelectContext(ctx, &deliveries, query, subscriptionID, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing webhook deliveries: %v", err)
+	}
+
+	return deliveries, total, nil
+}
+
+// ListAttempts retrieves the requests made for a delivery, oldest first
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookDeliveryAttempt, error) {
+	attempts := []models.WebhookDeliveryAttempt{}
+	query := `
+		SELECT attempt_id, delivery_id, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt_id ASC
+	`
+
+	err := r.db.SelectContext(ctx, &attempts, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook delivery attempts: %v", err)
+	}
+
+	return attempts, nil
+}
+
+// SetPayload stores the body built for a delivery
+func (r *WebhookRepository) SetPayload(ctx context.Context, deliveryID uuid.UUID, payload types.JSONText) error {
+	query := `UPDATE webhook_deliveries SET payload = $2 WHERE delivery_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, deliveryID, payload); err != nil {
+		return fmt.Errorf("error saving webhook payload: %v", err)
+	}
+
+	return nil
+}
+
+// RecordAttempt logs a request made for a delivery and updates the delivery
+// with its outcome and new status
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt, status string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4)
+		RETURNING attempt_id, attempted_at
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		attempt.DeliveryID,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMS,
+	).Scan(&attempt.AttemptID, &attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("error recording webhook attempt: %v", err)
+	}
+
+	query = `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4,
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN $5 ELSE delivered_at END
+		WHERE delivery_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, attempt.DeliveryID, status, attempt.StatusCode, attempt.Error, attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Redeliver puts a finished delivery back to pending and queues a job to send
+// it again with a fresh set of retries. It returns false without queuing
+// anything if the delivery is still pending, since a job for it is already
+// queued or running.
+func (r *WebhookRepository) Redeliver(ctx context.Context, deliveryID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending'
+		WHERE delivery_id = $1
+		AND status <> 'pending'
+	`
+
+	result, err := tx.ExecContext(ctx, query, deliveryID)
+	if err != nil {
+		return false, fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %v", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	payload := models.WebhookDeliveryPayload{DeliveryID: deliveryID.String()}
+	if err := enqueueJobWithMaxAttempts(ctx, tx, models.JobTypeDeliverWebhook, payload, models.WebhookMaxAttempts); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return true, nil
+}
+
+// SetDeliveryStatus changes a delivery's status without recording an attempt
+func (r *WebhookRepository) SetDeliveryStatus(ctx context.Context, deliveryID uuid.UUID, status string, lastError *string) error {
+	query := `UPDATE webhook_deliveries SET status = $2, last_error = $3 WHERE delivery_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, deliveryID, status, lastError); err != nil {
+		return fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// APNs hosts
+const (
+	apnsProductionHost = "https://api.push.apple.com"
+	apnsSandboxHost    = "https://api.sandbox.push.apple.com"
+)
+
+// apnsTokenLifetime is how long a provider token is reused. Apple rejects
+// tokens older than an hour and refreshes more often than every 20 minutes.
+const apnsTokenLifetime = 50 * time.Minute
+
+// APNsConfig configures token-based authentication with Apple
+type APNsConfig struct {
+	KeyPath     string // .p8 signing key from the Apple developer account
+	KeyID       string
+	TeamID      string
+	Topic       string // The app's bundle ID
+	Environment string // "production" or "sandbox"
+}
+
+// APNsSender sends push notifications directly to Apple, for iOS apps that
+// register raw APNs device tokens
+type APNsSender struct {
+	host   string
+	keyID  string
+	teamID string
+	topic  string
+	key    *ecdsa.PrivateKey
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsSender creates a new APNs sender from config
+func NewAPNsSender(config APNsConfig) (*APNsSender, error) {
+	if config.KeyID == "" || config.TeamID == "" || config.Topic == "" {
+		return nil, fmt.Errorf("APNs key ID, team ID and topic are required")
+	}
+
+	host := apnsProductionHost
+	switch config.Environment {
+	case "production", "":
+	case "sandbox":
+		host = apnsSandboxHost
+	default:
+		return nil, fmt.Errorf("unknown APNs environment %q", config.Environment)
+	}
+
+	data, err := os.ReadFile(config.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading APNs key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("APNs key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing APNs key: %v", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("APNs key is not an ECDSA key")
+	}
+
+	return &APNsSender{
+		host:   host,
+		keyID:  config.KeyID,
+		teamID: config.TeamID,
+		topic:  config.Topic,
+		key:    key,
+		// APNs only speaks HTTP/2, which the default transport negotiates
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// apnsResponse is the body of a rejected request
+type apnsResponse struct {
+	Reason string `json:"reason"`
+}
+
+// Send delivers a message to one APNs device token
+func (s *APNsSender) Send(ctx context.Context, token string, msg *PushMessage) error {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range pushData(msg) {
+		payload[key] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding APNs payload: %v", err)
+	}
+
+	providerToken, err := s.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.host+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating APNs request: %v", err)
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending APNs request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result apnsResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = json.Unmarshal(data, &result)
+
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		result.Reason == "BadDeviceToken",
+		result.Reason == "DeviceTokenNotForTopic":
+		return ErrPushTokenInvalid
+	case result.Reason == "ExpiredProviderToken":
+		s.resetProviderToken()
+	}
+
+	return fmt.Errorf("APNs rejected the notification: %d %s", resp.StatusCode, result.Reason)
+}
+
+// providerToken returns the signed JWT that authenticates requests, creating
+// a new one when the current one is due to expire
+func (s *APNsSender) providerToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Since(s.issuedAt) < apnsTokenLifetime {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": s.keyID})
+	claims, _ := json.Marshal(map[string]interface{}{"iss": s.teamID, "iat": now.Unix()})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing APNs token: %v", err)
+	}
+
+	// JWS wants the raw 32 byte r and s values, not ASN.1
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+
+	s.token = unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	s.issuedAt = now
+	return s.token, nil
+}
+
+// resetProviderToken forces a new provider token on the next request
+func (s *APNsSender) resetProviderToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// sniffLength is the number of leading bytes inspected to detect an audio format
+const sniffLength = 512
+
+// AudioFormat describes an accepted audio container/codec combination
+type AudioFormat struct {
+	Name      string   // Short name, e.g. "m4a"
+	MIMEType  string   // Canonical MIME type stored with the object
+	Extension string   // File extension including the dot
+	Codecs    []string // ffprobe codec names allowed in this container
+}
+
+// Allowed audio formats
+var (
+	FormatM4A  = AudioFormat{Name: "m4a", MIMEType: "audio/mp4", Extension: ".m4a", Codecs: []string{"aac", "alac"}}
+	FormatAAC  = AudioFormat{Name: "aac", MIMEType: "audio/aac", Extension: ".aac", Codecs: []string{"aac"}}
+	FormatMP3  = AudioFormat{Name: "mp3", MIMEType: "audio/mpeg", Extension: ".mp3", Codecs: []string{"mp3"}}
+	FormatWAV  = AudioFormat{Name: "wav", MIMEType: "audio/wav", Extension: ".wav", Codecs: []string{"pcm_s16le", "pcm_s24le", "pcm_s32le", "pcm_f32le", "pcm_u8"}}
+	FormatOpus = AudioFormat{Name: "opus", MIMEType: "audio/ogg", Extension: ".opus", Codecs: []string{"opus"}}
+)
+
+// AllowedAudioFormats lists every accepted format
+var AllowedAudioFormats = []AudioFormat{FormatM4A, FormatAAC, FormatMP3, FormatWAV, FormatOpus}
+
+// allowedAudioMIMETypes are the client-declared MIME types accepted before
+// the content itself can be inspected (presigned and resumable uploads)
+var allowedAudioMIMETypes = map[string]bool{
+	"audio/mp4":    true,
+	"audio/m4a":    true,
+	"audio/x-m4a":  true,
+	"audio/aac":    true,
+	"audio/x-aac":  true,
+	"audio/mpeg":   true,
+	"audio/mp3":    true,
+	"audio/wav":    true,
+	"audio/x-wav":  true,
+	"audio/wave":   true,
+	"audio/ogg":    true,
+	"audio/opus":   true,
+	"audio/x-opus": true,
+}
+
+// m4aBrands are the ISO base media file brands used for audio-only MP4 files
+var m4aBrands = map[string]bool{
+	"M4A ": true,
+	"M4B ": true,
+}
+
+// mp4Brands are generic MP4 brands, used for video as well as audio. Files
+// with only these are accepted once ffprobe has shown they have no video.
+var mp4Brands = map[string]bool{
+	"mp41": true,
+	"mp42": true,
+	"isom": true,
+	"iso2": true,
+}
+
+// IsAllowedAudioMIME reports whether a client-declared MIME type is on the allow-list
+func IsAllowedAudioMIME(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return allowedAudioMIMETypes[mediaType]
+}
+
+// AllowedAudioFormatNames returns the names of the accepted formats
+func AllowedAudioFormatNames() []string {
+	names := make([]string, len(AllowedAudioFormats))
+	for i, f := range AllowedAudioFormats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// SniffAudioFormat detects the audio format from the leading bytes of a file.
+// It returns nil if the content is not one of the allowed formats.
+func SniffAudioFormat(header []byte) *AudioFormat {
+	switch {
+	case hasFtypBrand(header, m4aBrands):
+		return &FormatM4A
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return &FormatWAV
+	case len(header) >= 36 && string(header[0:4]) == "OggS" && bytes.Contains(header[28:36], []byte("OpusHead")):
+		return &FormatOpus
+	case len(header) >= 3 && string(header[0:3]) == "ID3":
+		return &FormatMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF6 == 0xF0:
+		// ADTS sync word with layer bits 00
+		return &FormatAAC
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// MPEG audio frame sync with a valid layer
+		return &FormatMP3
+	}
+	return nil
+}
+
+// hasFtypBrand reports whether header starts with an MP4 ftyp box whose
+// major or compatible brands include one of brands
+func hasFtypBrand(header []byte, brands map[string]bool) bool {
+	if len(header) < 12 || string(header[4:8]) != "ftyp" {
+		return false
+	}
+	if brands[string(header[8:12])] {
+		return true
+	}
+
+	// Compatible brands follow the major brand and minor version
+	end := int(binary.BigEndian.Uint32(header[0:4]))
+	if end > len(header) {
+		end = len(header)
+	}
+	for i := 16; i+4 <= end; i += 4 {
+		if brands[string(header[i:i+4])] {
+			return true
+		}
+	}
+	return false
+}
+
+// AudioInfo is the result of validating an audio file
+type AudioInfo struct {
+	Format          *AudioFormat
+	Codec           string  // Empty if the file could not be probed
+	DurationSeconds float64 // Zero if the file could not be probed
+}
+
+// AudioValidationError describes why an uploaded file was rejected.
+// It is safe to show to API clients.
+type AudioValidationError struct {
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *AudioValidationError) Error() string {
+	return e.Message
+}
+
+// AudioValidator checks uploaded files against the audio allow-list and
+// probes them with ffprobe to verify codec and duration
+type AudioValidator struct {
+	ffprobePath string
+}
+
+// NewAudioValidator creates a new audio validator. Probing is disabled
+// (only content sniffing is done) if ffprobe cannot be found.
+func NewAudioValidator(ffprobePath string) *AudioValidator {
+	path, err := exec.LookPath(ffprobePath)
+	if err != nil {
+		log.Printf("⚠️  ffprobe not found (%s); audio duration probing disabled", ffprobePath)
+		path = ""
+	}
+	return &AudioValidator{ffprobePath: path}
+}
+
+// ProbingEnabled reports whether files are probed with ffprobe
+func (v *AudioValidator) ProbingEnabled() bool {
+	return v.ffprobePath != ""
+}
+
+// Sniff reads the leading bytes of r and checks them against the allow-list
+func (v *AudioValidator) Sniff(r io.Reader) (*AudioFormat, error) {
+	header := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("error reading file header: %v", err)
+	}
+	header = header[:n]
+
+	format := SniffAudioFormat(header)
+	if format == nil && v.ProbingEnabled() && hasFtypBrand(header, mp4Brands) {
+		// Validate rejects the file if the probe finds a video track
+		format = &FormatM4A
+	}
+	if format == nil {
+		return nil, &AudioValidationError{
+			Message: "Unsupported audio format",
+			Details: map[string]interface{}{
+				"detected_type": http.DetectContentType(header),
+				"allowed":       AllowedAudioFormatNames(),
+			},
+		}
+	}
+
+	return format, nil
+}
+
+// Validate sniffs and probes the audio file at path. reportedDuration is the
+// client-provided duration in seconds; it must roughly match the probed one.
+func (v *AudioValidator) Validate(ctx context.Context, path string, reportedDuration int) (*AudioInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	format, err := v.Sniff(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AudioInfo{Format: format}
+	if !v.ProbingEnabled() {
+		return info, nil
+	}
+
+	probe, err := v.probe(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if probe.hasVideo {
+		return nil, &AudioValidationError{
+			Message: "File contains video; only audio recordings are accepted",
+		}
+	}
+
+	if !utils.ContainsString(format.Codecs, probe.codec) {
+		return nil, &AudioValidationError{
+			Message: "Unsupported audio codec",
+			Details: map[string]interface{}{
+				"format":  format.Name,
+				"codec":   probe.codec,
+				"allowed": format.Codecs,
+			},
+		}
+	}
+
+	// Allow for rounding on the client and encoder padding
+	tolerance := math.Max(2, 0.05*probe.duration)
+	if reportedDuration > 0 && math.Abs(probe.duration-float64(reportedDuration)) > tolerance {
+		return nil, &AudioValidationError{
+			Message: "duration_seconds does not match the audio duration",
+			Details: map[string]interface{}{
+				"duration_seconds":        reportedDuration,
+				"probed_duration_seconds": math.Round(probe.duration*10) / 10,
+			},
+		}
+	}
+
+	info.Codec = probe.codec
+	info.DurationSeconds = probe.duration
+	return info, nil
+}
+
+// probeResult holds the fields read from ffprobe output
+type probeResult struct {
+	codec    string
+	duration float64
+	hasVideo bool
+}
+
+// probe runs ffprobe on a file and extracts the audio codec and duration
+func (v *AudioValidator) probe(ctx context.Context, path string) (*probeResult, error) {
+	cmd := exec.CommandContext(ctx, v.ffprobePath,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, &AudioValidationError{
+			Message: "Audio file could not be decoded",
+		}
+	}
+
+	var parsed struct {
+		Streams []struct {
+			CodecType   string `json:"codec_type"`
+			CodecName   string `json:"codec_name"`
+			Disposition struct {
+				AttachedPic int `json:"attached_pic"`
+			} `json:"disposition"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("error parsing ffprobe output: %v", err)
+	}
+
+	result := &probeResult{}
+	for _, stream := range parsed.Streams {
+		switch stream.CodecType {
+		case "audio":
+			if result.codec == "" {
+				result.codec = stream.CodecName
+			}
+		case "video":
+			// MP3 cover art shows up as a video stream
+			if stream.Disposition.AttachedPic == 0 {
+				result.hasVideo = true
+			}
+		}
+	}
+
+	if result.codec == "" {
+		return nil, &AudioValidationError{
+			Message: "File contains no audio stream",
+		}
+	}
+
+	result.duration, _ = strconv.ParseFloat(parsed.Format.Duration, 64)
+	return result, nil
+}
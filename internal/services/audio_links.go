@@ -0,0 +1,66 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AudioLinkSigner creates and checks long-lived links to a memo's recording
+// for exports. Storage URLs expire within the hour, which is too soon for a
+// spreadsheet that is passed around for a day or two, so exports link to the
+// API, which checks the signature and redirects to a fresh storage URL.
+type AudioLinkSigner struct {
+	key    []byte
+	expiry time.Duration
+}
+
+// NewAudioLinkSigner creates a signer keyed by secret, whose links are valid
+// for expiry. The secret is kept apart from JWT_SECRET so that links can be
+// revoked by rotating it. Without a secret a random key is used, so links
+// stop working when the server restarts.
+func NewAudioLinkSigner(secret string, expiry time.Duration) *AudioLinkSigner {
+	key := []byte(secret)
+	if secret == "" {
+		log.Println("EXPORT_LINK_SECRET is not set; exported audio links will expire on restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("Failed to generate audio link key: %v", err)
+		}
+	}
+	return &AudioLinkSigner{key: key, expiry: expiry}
+}
+
+// Link returns the signed link to a memo's recording under baseURL, such as
+// https://api.example.com
+func (s *AudioLinkSigner) Link(baseURL string, memoID uuid.UUID) string {
+	expires := time.Now().Add(s.expiry).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(memoID, expires))
+	return fmt.Sprintf("%s/api/v1/export/audio/%s?%s", strings.TrimRight(baseURL, "/"), memoID, query.Encode())
+}
+
+// Verify reports whether sig is a valid, unexpired signature for the memo
+func (s *AudioLinkSigner) Verify(memoID uuid.UUID, expires, sig string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.signature(memoID, exp)))
+}
+
+func (s *AudioLinkSigner) signature(memoID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("audio:" + memoID.String() + "." + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/email/*.tmpl
+var emailTemplateFiles embed.FS
+
+// DigestEmailData fills the digest template
+type DigestEmailData struct {
+	UserName string
+	Summary  string // e.g. "12 new memos in your parks, 3 high priority"
+	Period   string // e.g. "Since yesterday"
+	Parks    []string
+	Memos    []DigestEmailMemo
+	More     int // Memos not listed
+}
+
+// DigestEmailMemo is a memo listed in a digest
+type DigestEmailMemo struct {
+	Title        string
+	UserName     string
+	ParkName     string
+	Excerpt      string
+	HighPriority bool
+	CreatedAt    time.Time
+}
+
+// NotificationEmailData fills the notification template
+type NotificationEmailData struct {
+	UserName string
+	Title    string
+	Body     string
+}
+
+// EmailTemplates renders the plain text and HTML bodies of emails from the
+// templates in templates/email. Each email has a <name>.txt.tmpl and a
+// <name>.html.tmpl; HTML templates share the header and footer in layout.
+type EmailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// NewEmailTemplates parses the embedded email templates
+func NewEmailTemplates() (*EmailTemplates, error) {
+	funcs := map[string]interface{}{"join": strings.Join}
+
+	text, err := texttemplate.New("").Funcs(funcs).ParseFS(emailTemplateFiles, "templates/email/*.txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing text email templates: %v", err)
+	}
+
+	html, err := htmltemplate.New("").Funcs(funcs).ParseFS(emailTemplateFiles, "templates/email/*.html.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML email templates: %v", err)
+	}
+
+	return &EmailTemplates{text: text, html: html}, nil
+}
+
+// Render builds a message from the named templates
+func (t *EmailTemplates) Render(name, to, subject string, data interface{}) (*EmailMessage, error) {
+	var text, html bytes.Buffer
+
+	if err := t.text.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return nil, fmt.Errorf("error rendering %s email: %v", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, name+".html.tmpl", data); err != nil {
+		return nil, fmt.Errorf("error rendering %s email: %v", name, err)
+	}
+
+	return &EmailMessage{
+		To:      to,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
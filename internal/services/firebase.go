@@ -2,16 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
+	gcs "cloud.google.com/go/storage"
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
+	"firebase.google.com/go/v4/messaging"
 	"firebase.google.com/go/v4/storage"
 	"github.com/google/uuid"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -67,6 +72,15 @@ func NewFirebaseService(projectID, bucketName, serviceAccountPath, serviceAccoun
 	}, nil
 }
 
+// Messaging creates a Firebase Cloud Messaging client for the app
+func (fs *FirebaseService) Messaging(ctx context.Context) (*messaging.Client, error) {
+	client, err := fs.app.Messaging(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firebase messaging: %v", err)
+	}
+	return client, nil
+}
+
 // VerifyIDToken verifies a Firebase ID token and returns the user ID
 func (fs *FirebaseService) VerifyIDToken(ctx context.Context, idToken string) (string, error) {
 	token, err := fs.auth.VerifyIDToken(ctx, idToken)
@@ -85,8 +99,259 @@ func (fs *FirebaseService) GetUserByUID(ctx context.Context, uid string) (*auth.
 	return user, nil
 }
 
-// UploadAudioFile uploads an audio file to Firebase Storage
-func (fs *FirebaseService) UploadAudioFile(ctx context.Context, file *multipart.FileHeader, userID string) (string, error) {
+// PresignedUpload describes a signed URL the client can PUT an audio file to
+type PresignedUpload struct {
+	UploadURL string            `json:"upload_url"`
+	FilePath  string            `json:"file_path"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// UploadedObject describes an object that already exists in storage
+type UploadedObject struct {
+	Key         string
+	Size        int64
+	ContentType string
+	UploadedBy  string
+}
+
+// AudioObjectKey generates a unique storage key for a user's audio file
+func AudioObjectKey(userID, filename string) string {
+	return fmt.Sprintf("memos/%s/%s%s", userID, uuid.New().String(), filepath.Ext(filename))
+}
+
+// IsUserObjectKey reports whether a storage key lies in the user's upload prefix
+func IsUserObjectKey(key, userID string) bool {
+	return strings.HasPrefix(key, fmt.Sprintf("memos/%s/", userID)) && !strings.Contains(key, "..")
+}
+
+// SignedDownloadURL creates a short-lived URL for reading an object.
+// Audio is never public; clients only ever receive these URLs.
+func (fs *FirebaseService) SignedDownloadURL(key string, expiry time.Duration) (string, time.Time, error) {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	expiresAt := time.Now().Add(expiry).UTC()
+	url, err := bucket.SignedURL(key, &gcs.SignedURLOptions{
+		Scheme:  gcs.SigningSchemeV4,
+		Method:  "GET",
+		Expires: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing download URL: %v", err)
+	}
+
+	return url, expiresAt, nil
+}
+
+// GeneratePresignedUploadURL creates a signed URL for uploading an audio file
+// directly to storage. The client must send the returned headers with the PUT
+// request; they are part of the signature and record who uploaded the object.
+func (fs *FirebaseService) GeneratePresignedUploadURL(ctx context.Context, userID, filename, contentType string, expiry time.Duration) (*PresignedUpload, error) {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	key := AudioObjectKey(userID, filename)
+	expiresAt := time.Now().Add(expiry).UTC()
+	headers := map[string]string{
+		"Content-Type":            contentType,
+		"x-goog-meta-uploaded_by": userID,
+	}
+
+	url, err := bucket.SignedURL(key, &gcs.SignedURLOptions{
+		Scheme:      gcs.SigningSchemeV4,
+		Method:      "PUT",
+		ContentType: contentType,
+		Headers:     []string{"x-goog-meta-uploaded_by:" + userID},
+		Expires:     expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing upload URL: %v", err)
+	}
+
+	return &PresignedUpload{
+		UploadURL: url,
+		FilePath:  key,
+		Headers:   headers,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// GetUploadedObject looks up an object in storage.
+// It returns nil if the object does not exist.
+func (fs *FirebaseService) GetUploadedObject(ctx context.Context, key string) (*UploadedObject, error) {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	attrs, err := bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting object: %v", err)
+	}
+
+	return &UploadedObject{
+		Key:         attrs.Name,
+		Size:        attrs.Size,
+		ContentType: attrs.ContentType,
+		UploadedBy:  attrs.Metadata["uploaded_by"],
+	}, nil
+}
+
+// MaxObjectComponents is the most components Cloud Storage allows in a
+// composed object, which bounds the number of chunks in a resumable upload
+const MaxObjectComponents = 1024
+
+// ErrChunkOffsetMismatch is returned when the stored object is not the size
+// the chunk expects to append to
+var ErrChunkOffsetMismatch = errors.New("stored upload does not match the chunk offset")
+
+// ErrTooManyChunks is returned when another chunk would exceed MaxObjectComponents
+var ErrTooManyChunks = errors.New("upload has too many chunks")
+
+// AppendObjectChunk appends data to an object that is being uploaded in chunks.
+// offset is the current size of the object; the first chunk creates it and
+// later chunks are written to a temporary object and composed onto the end.
+// The compose only applies to the generation whose size was checked, so a
+// chunk is never appended to an object another request has changed.
+// If the reader fails part way, the bytes received so far are still committed,
+// and the number of bytes written is returned alongside the read error.
+func (fs *FirebaseService) AppendObjectChunk(ctx context.Context, key string, offset int64, contentType, userID string, r io.Reader) (int64, error) {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return 0, fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	target := key
+	var current *gcs.ObjectAttrs
+	if offset > 0 {
+		current, err = bucket.Object(key).Attrs(ctx)
+		if err != nil {
+			if errors.Is(err, gcs.ErrObjectNotExist) {
+				return 0, ErrChunkOffsetMismatch
+			}
+			return 0, fmt.Errorf("error getting upload object: %v", err)
+		}
+		if current.Size != offset {
+			return 0, ErrChunkOffsetMismatch
+		}
+		// A non-composite object counts as a single component
+		if current.ComponentCount+1 > MaxObjectComponents {
+			return 0, ErrTooManyChunks
+		}
+		target = fmt.Sprintf("%s.part-%d", key, offset)
+	}
+
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := bucket.Object(target).NewWriter(writeCtx)
+	writer.ContentType = contentType
+	writer.Metadata = map[string]string{
+		"uploaded_by": userID,
+		"uploaded_at": time.Now().Format(time.RFC3339),
+	}
+
+	written, readErr := io.Copy(writer, r)
+	if written == 0 {
+		// Nothing arrived - abort the write instead of creating an empty object
+		cancel()
+		_ = writer.Close()
+		return 0, readErr
+	}
+
+	// Commit whatever arrived, even if the client went away mid-chunk
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("error closing writer: %v", err)
+	}
+
+	if offset == 0 {
+		return written, readErr
+	}
+
+	dst := bucket.Object(key).If(gcs.Conditions{GenerationMatch: current.Generation})
+	composer := dst.ComposerFrom(bucket.Object(key).Generation(current.Generation), bucket.Object(target))
+	composer.ContentType = contentType
+	composer.Metadata = writer.Metadata
+	if _, err := composer.Run(ctx); err != nil {
+		_ = bucket.Object(target).Delete(ctx)
+		return 0, fmt.Errorf("error composing upload chunk: %v", err)
+	}
+
+	// The chunk is already part of the upload, so a failed cleanup is not fatal
+	_ = bucket.Object(target).Delete(ctx)
+
+	return written, readErr
+}
+
+// DeleteObject deletes an object from storage by its key
+func (fs *FirebaseService) DeleteObject(ctx context.Context, key string) error {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	if err := bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("error deleting file: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteObjectsWithPrefix deletes every object whose key starts with prefix
+func (fs *FirebaseService) DeleteObjectsWithPrefix(ctx context.Context, prefix string) error {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	objects := bucket.Objects(ctx, &gcs.Query{Prefix: prefix})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error listing objects: %v", err)
+		}
+
+		if err := bucket.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+			return fmt.Errorf("error deleting file: %v", err)
+		}
+	}
+}
+
+// DownloadObject copies an object from storage into w
+func (fs *FirebaseService) DownloadObject(ctx context.Context, key string, w io.Writer) error {
+	bucket, err := fs.storage.Bucket(fs.bucket)
+	if err != nil {
+		return fmt.Errorf("error getting bucket: %v", err)
+	}
+
+	reader, err := bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("error opening object: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("error downloading object: %v", err)
+	}
+
+	return nil
+}
+
+// UploadAudioFile uploads an audio file to Firebase Storage and returns its
+// object key. format is the server-detected audio format; the client's Content-Type
+// header and filename extension are not trusted.
+func (fs *FirebaseService) UploadAudioFile(ctx context.Context, file *multipart.FileHeader, userID string, format *AudioFormat) (string, error) {
 	// Open the uploaded file
 	src, err := file.Open()
 	if err != nil {
@@ -95,8 +360,7 @@ func (fs *FirebaseService) UploadAudioFile(ctx context.Context, file *multipart.
 	defer src.Close()
 
 	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("memos/%s/%s%s", userID, uuid.New().String(), ext)
+	fileName := AudioObjectKey(userID, format.Extension)
 
 	// Get bucket
 	bucket, err := fs.storage.Bucket(fs.bucket)
@@ -107,7 +371,7 @@ func (fs *FirebaseService) UploadAudioFile(ctx context.Context, file *multipart.
 	// Create object writer
 	obj := bucket.Object(fileName)
 	writer := obj.NewWriter(ctx)
-	writer.ContentType = file.Header.Get("Content-Type")
+	writer.ContentType = format.MIMEType
 	writer.Metadata = map[string]string{
 		"uploaded_by": userID,
 		"uploaded_at": time.Now().Format(time.RFC3339),
@@ -124,37 +388,27 @@ func (fs *FirebaseService) UploadAudioFile(ctx context.Context, file *multipart.
 		return "", fmt.Errorf("error closing writer: %v", err)
 	}
 
-	// Make file publicly accessible
-	// if err := obj.ACL().Set(ctx, storage.AllUsers, storage.RoleReader); err != nil {
-	// 	return "", fmt.Errorf("error setting ACL: %v", err)
-	// }
-
-	// Generate public URL
-	url := fmt.Sprintf("https://storage.googleapis.com/%s/%s", fs.bucket, fileName)
-	return url, nil
+	// Objects stay private; they are read through signed URLs
+	return fileName, nil
 }
 
-// DeleteAudioFile deletes an audio file from Firebase Storage
-func (fs *FirebaseService) DeleteAudioFile(ctx context.Context, audioURL string) error {
-	// Extract file path from URL
-	// URL format: https://storage.googleapis.com/bucket-name/path/to/file
+// UploadObject writes r to storage under key
+func (fs *FirebaseService) UploadObject(ctx context.Context, key, contentType string, r io.Reader) error {
 	bucket, err := fs.storage.Bucket(fs.bucket)
 	if err != nil {
 		return fmt.Errorf("error getting bucket: %v", err)
 	}
 
-	// Parse file path from URL
-	// This is a simplified version - you might need more robust URL parsing
-	prefix := fmt.Sprintf("https://storage.googleapis.com/%s/", fs.bucket)
-	if len(audioURL) <= len(prefix) {
-		return fmt.Errorf("invalid audio URL")
+	writer := bucket.Object(key).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("error uploading file: %v", err)
 	}
-	filePath := audioURL[len(prefix):]
 
-	// Delete the file
-	obj := bucket.Object(filePath)
-	if err := obj.Delete(ctx); err != nil {
-		return fmt.Errorf("error deleting file: %v", err)
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error closing writer: %v", err)
 	}
 
 	return nil
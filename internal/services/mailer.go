@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailers selectable with MAILER
+const (
+	MailerSMTP   = "smtp"
+	MailerMemory = "memory"
+	MailerNone   = "none"
+)
+
+// smtpTimeout bounds a whole SMTP conversation when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// EmailMessage is an email with plain text and HTML bodies
+type EmailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	// Enabled reports whether email can be sent in this environment
+	Enabled() bool
+
+	// Send delivers a message
+	Send(ctx context.Context, msg *EmailMessage) error
+}
+
+// NewMailer creates the mailer named by engine. The SMTP mailer is disabled
+// when no host is configured.
+func NewMailer(engine, host string, port int, username, password, from string) (Mailer, error) {
+	switch strings.ToLower(engine) {
+	case MailerSMTP:
+		if host == "" {
+			return disabledMailer{}, nil
+		}
+		return NewSMTPMailer(host, port, username, password, from)
+	case MailerMemory:
+		return &MemoryMailer{}, nil
+	case MailerNone, "":
+		return disabledMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", engine)
+	}
+}
+
+// SMTPMailer sends email through an SMTP relay. It uses STARTTLS when the
+// relay offers it, or implicit TLS on port 465, and authenticates only when
+// a username is set, so a local relay such as Mailpit works unchanged.
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     *mail.Address
+}
+
+// NewSMTPMailer creates a new SMTP mailer sending from the from address
+func NewSMTPMailer(host string, port int, username, password, from string) (*SMTPMailer, error) {
+	fromAddress, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %v", from, err)
+	}
+
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     fromAddress,
+	}, nil
+}
+
+// Enabled implements Mailer
+func (m *SMTPMailer) Enabled() bool { return true }
+
+// Send implements Mailer
+func (m *SMTPMailer) Send(ctx context.Context, msg *EmailMessage) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %v", err)
+	}
+
+	body, err := buildEmail(m.from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if m.port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to SMTP relay: %v", err)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error starting SMTP session: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("error starting TLS: %v", err)
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("error authenticating with SMTP relay: %v", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+
+	return client.Quit()
+}
+
+// buildEmail encodes a message as multipart/alternative MIME
+func buildEmail(from, to *mail.Address, msg *EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error encoding email: %v", err)
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("error encoding email: %v", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("error encoding email: %v", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("error encoding email: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MemoryMailer keeps sent messages in memory instead of sending them, and logs
+// each one. MAILER=memory selects it, so email features can be tried without
+// a relay.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []EmailMessage
+}
+
+// Enabled implements Mailer
+func (m *MemoryMailer) Enabled() bool { return true }
+
+// Send implements Mailer
+func (m *MemoryMailer) Send(ctx context.Context, msg *EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, *msg)
+	log.Printf("Email to %s kept in memory: %s", msg.To, msg.Subject)
+	return nil
+}
+
+// Sent returns the messages sent so far
+func (m *MemoryMailer) Sent() []EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]EmailMessage(nil), m.sent...)
+}
+
+// disabledMailer is used when email is not configured
+type disabledMailer struct{}
+
+func (disabledMailer) Enabled() bool { return false }
+func (disabledMailer) Send(ctx context.Context, msg *EmailMessage) error {
+	return fmt.Errorf("email is not configured")
+}
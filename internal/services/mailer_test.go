@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryMailerKeepsRenderedDigest(t *testing.T) {
+	templates, err := NewEmailTemplates()
+	if err != nil {
+		t.Fatalf("NewEmailTemplates: %v", err)
+	}
+
+	data := DigestEmailData{
+		UserName: "Sam",
+		Summary:  "2 new memos in your parks, 1 high priority",
+		Period:   "Since Mon, Dec 2 at 6:00 AM",
+		Parks:    []string{"Lindley Park", "Peets Hill"},
+		Memos: []DigestEmailMemo{
+			{
+				Title:        "Downed tree <across> trail",
+				UserName:     "Alex",
+				ParkName:     "Peets Hill",
+				Excerpt:      "Large pine blocking the upper loop",
+				HighPriority: true,
+				CreatedAt:    time.Date(2024, 12, 2, 14, 30, 0, 0, time.UTC),
+			},
+		},
+		More: 1,
+	}
+	msg, err := templates.Render("digest", "sam@example.com", data.Summary, data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	mailer := &MemoryMailer{}
+	if !mailer.Enabled() {
+		t.Fatal("MemoryMailer should be enabled")
+	}
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d sent messages, want 1", len(sent))
+	}
+	got := sent[0]
+	if got.To != "sam@example.com" || got.Subject != data.Summary {
+		t.Errorf("got message to %q with subject %q", got.To, got.Subject)
+	}
+
+	for _, want := range []string{
+		"Hi Sam,",
+		"Since Mon, Dec 2 at 6:00 AM in Lindley Park, Peets Hill",
+		"[!] Downed tree <across> trail",
+		"Alex · Peets Hill · Dec 2, 2:30 PM",
+		"Large pine blocking the upper loop",
+		"And 1 more in the app.",
+	} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("text body is missing %q:\n%s", want, got.Text)
+		}
+	}
+
+	if !strings.Contains(got.HTML, "Downed tree &lt;across&gt; trail") {
+		t.Errorf("HTML body doesn't escape the memo title:\n%s", got.HTML)
+	}
+
+	// Sent returns a copy, so callers can't change what was recorded
+	sent[0].Subject = "changed"
+	if mailer.Sent()[0].Subject != data.Summary {
+		t.Error("Sent exposes the mailer's own slice")
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// mentionContextRunes is how much text around a mention is kept to show why
+// someone was mentioned
+const mentionContextRunes = 60
+
+// MentionCandidate is an @handle found in text. The word after it is kept so
+// that "@Dana Smith" can resolve to a full display name.
+type MentionCandidate struct {
+	Handle string // Without the @
+	Next   string // Following word, empty at the end of a line
+	Offset int    // Rune offset of the @
+}
+
+// ResolvedMention is a mention matched to a user
+type ResolvedMention struct {
+	UserID  string
+	Handle  string // As written, without the @
+	Context string // Text around the mention
+}
+
+// ParseMentions finds the @mentions in text. An @ only starts a mention at
+// the start of a word, so email addresses are ignored.
+func ParseMentions(text string) []MentionCandidate {
+	runes := []rune(text)
+	candidates := []MentionCandidate{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isMentionRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isMentionRune(runes[end]) {
+			end++
+		}
+		handle := strings.TrimRight(string(runes[i+1:end]), ".-_'")
+		if handle == "" {
+			continue
+		}
+
+		// The next word on the same line, for two-word names
+		next := ""
+		j := end
+		for j < len(runes) && (runes[j] == ' ' || runes[j] == '\t') {
+			j++
+		}
+		if j > end {
+			k := j
+			for k < len(runes) && isMentionRune(runes[k]) {
+				k++
+			}
+			next = strings.TrimRight(string(runes[j:k]), ".-_'")
+		}
+
+		candidates = append(candidates, MentionCandidate{Handle: handle, Next: next, Offset: i})
+		i = end - 1
+	}
+
+	return candidates
+}
+
+// isMentionRune reports whether r can be part of an @handle
+func isMentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '\''
+}
+
+// MentionKey normalizes a name for matching by keeping only its lower case
+// letters and digits, so "@dana.smith" and "@DanaSmith" both match
+// "Dana Smith"
+func MentionKey(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// Keys returns the name keys the candidate could match, longest first
+func (c MentionCandidate) Keys() []string {
+	keys := []string{}
+	if c.Next != "" {
+		keys = append(keys, MentionKey(c.Handle+c.Next))
+	}
+	return append(keys, MentionKey(c.Handle))
+}
+
+// ResolveMentions matches the mentions in text against users. A mention
+// matches a user's whole display name, "@Dana Smith" before "@Dana", or
+// failing that their first name when no one else shares it. Ambiguous and
+// unknown mentions are dropped, and each user is returned once.
+func ResolveMentions(text string, candidates []MentionCandidate, users []models.User) []ResolvedMention {
+	byName := map[string][]string{}
+	byFirstName := map[string][]string{}
+	for _, user := range users {
+		byName[MentionKey(user.DisplayName)] = append(byName[MentionKey(user.DisplayName)], user.UserID)
+		if fields := strings.Fields(user.DisplayName); len(fields) > 1 {
+			byFirstName[MentionKey(fields[0])] = append(byFirstName[MentionKey(fields[0])], user.UserID)
+		}
+	}
+
+	runes := []rune(text)
+	seen := map[string]bool{}
+	resolved := []ResolvedMention{}
+
+	for _, candidate := range candidates {
+		userID, handle := "", ""
+		if candidate.Next != "" {
+			if ids := byName[MentionKey(candidate.Handle+candidate.Next)]; len(ids) == 1 {
+				userID, handle = ids[0], candidate.Handle+" "+candidate.Next
+			}
+		}
+		if userID == "" {
+			key := MentionKey(candidate.Handle)
+			if ids := byName[key]; len(ids) == 1 {
+				userID, handle = ids[0], candidate.Handle
+			} else if ids := byFirstName[key]; len(ids) == 1 && len(byName[key]) == 0 {
+				userID, handle = ids[0], candidate.Handle
+			}
+		}
+		if userID == "" || seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+		resolved = append(resolved, ResolvedMention{
+			UserID:  userID,
+			Handle:  handle,
+			Context: mentionContext(runes, candidate.Offset),
+		})
+	}
+
+	return resolved
+}
+
+// mentionContext returns the text around offset on one line, with ellipses
+// where it was cut
+func mentionContext(runes []rune, offset int) string {
+	start := offset - mentionContextRunes
+	end := offset + mentionContextRunes
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(runes) {
+		end, suffix = len(runes), ""
+	}
+
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	return prefix + snippet + suffix
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// Push senders selectable with PUSH_SENDER
+const (
+	PushSenderLive   = "live"
+	PushSenderMemory = "memory"
+	PushSenderNone   = "none"
+)
+
+// ErrPushTokenInvalid is returned when the provider reports that a device
+// token is no longer valid, e.g. because the app was uninstalled. The device
+// should be removed.
+var ErrPushTokenInvalid = errors.New("push token is no longer valid")
+
+// PushMessage is a notification shown on a device. Type is the notification
+// type and, like Data, is passed to the app to decide what to open.
+type PushMessage struct {
+	Type  string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushSender delivers push notifications through APNs and FCM
+type PushSender interface {
+	// Supports reports whether devices registered with provider can be
+	// reached in this environment
+	Supports(provider string) bool
+
+	// Send delivers a message to one device token
+	Send(ctx context.Context, provider, token string, msg *PushMessage) error
+}
+
+// NewPushSender creates the push sender named by engine. The live sender
+// delivers FCM tokens through the Firebase app and APNs tokens directly to
+// Apple when apns has a key; without one, APNs devices are skipped.
+func NewPushSender(engine string, firebase *FirebaseService, apns APNsConfig) (PushSender, error) {
+	switch strings.ToLower(engine) {
+	case PushSenderLive:
+		fcm, err := firebase.Messaging(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		sender := &providerPushSender{fcm: &FCMSender{client: fcm}}
+		if apns.KeyPath != "" {
+			if sender.apns, err = NewAPNsSender(apns); err != nil {
+				return nil, err
+			}
+		}
+		return sender, nil
+	case PushSenderMemory:
+		return &MemoryPushSender{}, nil
+	case PushSenderNone, "":
+		return disabledPushSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown push sender %q", engine)
+	}
+}
+
+// providerPushSender routes each message to the device's provider
+type providerPushSender struct {
+	fcm  *FCMSender
+	apns *APNsSender
+}
+
+// Supports implements PushSender
+func (s *providerPushSender) Supports(provider string) bool {
+	switch provider {
+	case models.PushProviderFCM:
+		return true
+	case models.PushProviderAPNs:
+		return s.apns != nil
+	default:
+		return false
+	}
+}
+
+// Send implements PushSender
+func (s *providerPushSender) Send(ctx context.Context, provider, token string, msg *PushMessage) error {
+	switch {
+	case provider == models.PushProviderFCM:
+		return s.fcm.Send(ctx, token, msg)
+	case provider == models.PushProviderAPNs && s.apns != nil:
+		return s.apns.Send(ctx, token, msg)
+	default:
+		return fmt.Errorf("push provider %q is not configured", provider)
+	}
+}
+
+// FCMSender sends push notifications through Firebase Cloud Messaging, which
+// also reaches iOS apps that register FCM tokens
+type FCMSender struct {
+	client *messaging.Client
+}
+
+// Send delivers a message to one FCM registration token
+func (s *FCMSender) Send(ctx context.Context, token string, msg *PushMessage) error {
+	message := &messaging.Message{
+		Token: token,
+		Notification: &messaging.Notification{
+			Title: msg.Title,
+			Body:  msg.Body,
+		},
+		Data: pushData(msg),
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		APNS: &messaging.APNSConfig{
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{Sound: "default"},
+			},
+		},
+	}
+
+	if _, err := s.client.Send(ctx, message); err != nil {
+		if messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err) {
+			return ErrPushTokenInvalid
+		}
+		return fmt.Errorf("error sending FCM message: %v", err)
+	}
+
+	return nil
+}
+
+// pushData returns the custom data sent with a message, including its type
+func pushData(msg *PushMessage) map[string]string {
+	data := map[string]string{"type": msg.Type}
+	for key, value := range msg.Data {
+		data[key] = value
+	}
+	return data
+}
+
+// SentPush is a push notification kept by MemoryPushSender
+type SentPush struct {
+	Provider string
+	Token    string
+	Message  PushMessage
+}
+
+// MemoryPushSender keeps push notifications in memory instead of sending
+// them, and logs each one. PUSH_SENDER=memory selects it. Tokens added with
+// Invalidate are rejected with ErrPushTokenInvalid, like an uninstalled app.
+type MemoryPushSender struct {
+	mu      sync.Mutex
+	sent    []SentPush
+	invalid map[string]bool
+}
+
+// Supports implements PushSender
+func (s *MemoryPushSender) Supports(provider string) bool { return true }
+
+// Send implements PushSender
+func (s *MemoryPushSender) Send(ctx context.Context, provider, token string, msg *PushMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.invalid[token] {
+		return ErrPushTokenInvalid
+	}
+
+	s.sent = append(s.sent, SentPush{Provider: provider, Token: token, Message: *msg})
+	log.Printf("Push to %s device kept in memory: %s", provider, msg.Title)
+	return nil
+}
+
+// Invalidate makes later sends to token fail as if the app was uninstalled
+func (s *MemoryPushSender) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.invalid == nil {
+		s.invalid = map[string]bool{}
+	}
+	s.invalid[token] = true
+}
+
+// Sent returns the push notifications sent so far
+func (s *MemoryPushSender) Sent() []SentPush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SentPush(nil), s.sent...)
+}
+
+// disabledPushSender is used when push is turned off
+type disabledPushSender struct{}
+
+func (disabledPushSender) Supports(provider string) bool { return false }
+func (disabledPushSender) Send(ctx context.Context, provider, token string, msg *PushMessage) error {
+	return fmt.Errorf("push notifications are not configured")
+}
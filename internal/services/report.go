@@ -0,0 +1,458 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// Report page layout, in points
+const (
+	reportMargin       = 54.0
+	reportContentWidth = utils.PDFLetterWidth - 2*reportMargin
+	reportFooterHeight = 24.0
+	reportMapHeight    = 320.0
+)
+
+// reportAccent colors section rules and map markers
+var reportAccent = utils.PDFColor{R: 0.13, G: 0.42, B: 0.27}
+
+// Report date formats, in the report's time zone
+const (
+	reportDateFormat     = "Jan 2, 2006"
+	reportDateTimeFormat = "Jan 2, 2006 3:04 PM MST"
+)
+
+// ReportContent is everything shown in a PDF field report
+type ReportContent struct {
+	Title       string
+	Filter      models.MemoFilter
+	Location    *time.Location
+	PreparedBy  string
+	GeneratedAt time.Time
+	Summary     *models.StatsSummary
+	Parks       []models.StatsGroup
+	Categories  []models.StatsGroup
+	Memos       []models.MemoExportRow // Numbered in this order
+}
+
+// RenderReport lays out a field report as a printable PDF: a title page with
+// summary tables, a plot of memo locations, then every memo with its
+// metadata and transcript
+func RenderReport(content *ReportContent) ([]byte, error) {
+	doc := utils.NewPDFDocument(utils.PDFLetterWidth, utils.PDFLetterHeight, content.Title)
+	l := &reportLayout{doc: doc, loc: content.Location}
+	l.newPage()
+
+	// Title block
+	for _, line := range utils.PDFWrapText(content.Title, utils.PDFHelveticaBold, 20, reportContentWidth) {
+		l.line(line, utils.PDFHelveticaBold, 20, utils.PDFBlack, 0)
+	}
+	l.y += 4
+	l.paragraph(describeReportFilter(content.Filter, content.Location), utils.PDFHelvetica, 11, utils.PDFGray, 0)
+	prepared := "Generated " + content.GeneratedAt.In(content.Location).Format(reportDateTimeFormat)
+	if content.PreparedBy != "" {
+		prepared = "Prepared by " + content.PreparedBy + ". " + prepared
+	}
+	l.paragraph(prepared, utils.PDFHelvetica, 9, utils.PDFGray, 0)
+
+	// Summary tables
+	l.heading("Summary")
+	l.table([]string{"", ""}, []float64{200, 150}, []bool{false, true}, reportSummaryRows(content.Summary, content.Location), false)
+
+	if len(content.Parks) > 0 {
+		l.heading("By park")
+		l.table([]string{"Park", "Memos", "Audio minutes"}, []float64{280, 90, 110}, []bool{false, true, true}, reportGroupRows(content.Parks), true)
+	}
+	if len(content.Categories) > 0 {
+		l.heading("By category")
+		l.table([]string{"Category", "Memos", "Audio minutes"}, []float64{280, 90, 110}, []bool{false, true, true}, reportGroupRows(content.Categories), true)
+	}
+
+	// Map
+	l.heading("Locations")
+	l.memoMap(content.Memos)
+
+	// Memos
+	l.heading("Memos")
+	if len(content.Memos) == 0 {
+		l.paragraph("No memos match this report.", utils.PDFHelvetica, 10, utils.PDFGray, 0)
+	}
+	for i := range content.Memos {
+		l.memo(i+1, &content.Memos[i])
+	}
+
+	// Footers, now the page count is known
+	for i := 0; i < doc.PageCount(); i++ {
+		doc.SetPage(i)
+		y := utils.PDFLetterHeight - reportMargin + 6
+		doc.Line(reportMargin, y-14, reportMargin+reportContentWidth, y-14, 0.5, utils.PDFLightGray)
+		doc.Text(reportMargin, y, utils.PDFHelvetica, 8, utils.PDFGray, fitText(content.Title, utils.PDFHelvetica, 8, reportContentWidth-80))
+		pageLabel := fmt.Sprintf("Page %d of %d", i+1, doc.PageCount())
+		doc.Text(reportMargin+reportContentWidth-utils.PDFTextWidth(pageLabel, utils.PDFHelvetica, 8), y, utils.PDFHelvetica, 8, utils.PDFGray, pageLabel)
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("error writing report: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// reportLayout places blocks down the page, starting new pages as needed. y
+// is the top of the next block.
+type reportLayout struct {
+	doc *utils.PDFDocument
+	loc *time.Location
+	y   float64
+}
+
+func (l *reportLayout) newPage() {
+	l.doc.AddPage()
+	l.y = reportMargin
+}
+
+// ensure starts a new page unless height fits above the footer
+func (l *reportLayout) ensure(height float64) {
+	if l.y+height > utils.PDFLetterHeight-reportMargin-reportFooterHeight {
+		l.newPage()
+	}
+}
+
+// line writes one line of text
+func (l *reportLayout) line(text string, font utils.PDFFont, size float64, color utils.PDFColor, indent float64) {
+	height := size * 1.35
+	l.ensure(height)
+	l.doc.Text(reportMargin+indent, l.y+size, font, size, color, text)
+	l.y += height
+}
+
+// paragraph writes wrapped text
+func (l *reportLayout) paragraph(text string, font utils.PDFFont, size float64, color utils.PDFColor, indent float64) {
+	for _, line := range utils.PDFWrapText(text, font, size, reportContentWidth-indent) {
+		l.line(line, font, size, color, indent)
+	}
+}
+
+// heading starts a section, keeping it with some of its content
+func (l *reportLayout) heading(text string) {
+	l.y += 14
+	l.ensure(80)
+	l.doc.Text(reportMargin, l.y+13, utils.PDFHelveticaBold, 13, reportAccent, text)
+	l.y += 18
+	l.doc.Line(reportMargin, l.y, reportMargin+reportContentWidth, l.y, 1, reportAccent)
+	l.y += 8
+}
+
+// table draws rows under a shaded header row, repeating the header on new
+// pages. Cells that don't fit are cut short.
+func (l *reportLayout) table(headers []string, widths []float64, right []bool, rows [][]string, showHeader bool) {
+	const size, rowHeight, pad = 9.0, 15.0, 4.0
+
+	drawRow := func(cells []string, font utils.PDFFont) {
+		x := reportMargin
+		for i, cell := range cells {
+			text := fitText(cell, font, size, widths[i]-2*pad)
+			tx := x + pad
+			if right[i] {
+				tx = x + widths[i] - pad - utils.PDFTextWidth(text, font, size)
+			}
+			l.doc.Text(tx, l.y+rowHeight-4.5, font, size, utils.PDFBlack, text)
+			x += widths[i]
+		}
+		l.y += rowHeight
+	}
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	header := func() {
+		l.doc.FillRect(reportMargin, l.y, total, rowHeight, utils.PDFLightGray)
+		drawRow(headers, utils.PDFHelveticaBold)
+	}
+
+	if showHeader {
+		l.ensure(2 * rowHeight)
+		header()
+	}
+	for _, row := range rows {
+		if l.y+rowHeight > utils.PDFLetterHeight-reportMargin-reportFooterHeight {
+			l.newPage()
+			if showHeader {
+				header()
+			}
+		}
+		drawRow(row, utils.PDFHelvetica)
+		l.doc.Line(reportMargin, l.y, reportMargin+total, l.y, 0.5, utils.PDFLightGray)
+	}
+}
+
+// memoMap plots the memos with coordinates on a latitude/longitude grid,
+// numbered as in the memo list. North is up; longitude is scaled for the
+// latitude so distances look right.
+func (l *reportLayout) memoMap(memos []models.MemoExportRow) {
+	type point struct {
+		number   int
+		lat, lon float64
+	}
+	points := []point{}
+	for i, memo := range memos {
+		if memo.Latitude != nil && memo.Longitude != nil {
+			points = append(points, point{i + 1, *memo.Latitude, *memo.Longitude})
+		}
+	}
+	if len(points) == 0 {
+		l.paragraph("None of these memos have coordinates.", utils.PDFHelvetica, 10, utils.PDFGray, 0)
+		return
+	}
+
+	minLat, maxLat, minLon, maxLon := points[0].lat, points[0].lat, points[0].lon, points[0].lon
+	for _, p := range points {
+		minLat, maxLat = math.Min(minLat, p.lat), math.Max(maxLat, p.lat)
+		minLon, maxLon = math.Min(minLon, p.lon), math.Max(maxLon, p.lon)
+	}
+	midLat := (minLat + maxLat) / 2
+	kx := math.Cos(midLat * math.Pi / 180)
+
+	// Show at least about 200 m around a single memo
+	const minSpan = 0.002
+	spanY := math.Max(maxLat-minLat, minSpan)
+	spanX := math.Max((maxLon-minLon)*kx, minSpan)
+
+	l.ensure(reportMapHeight + 40)
+	const pad = 18.0
+	top := l.y
+	innerW, innerH := reportContentWidth-2*pad, reportMapHeight-2*pad
+	scale := math.Min(innerW/spanX, innerH/spanY)
+	centerLat, centerLon := (minLat+maxLat)/2, (minLon+maxLon)/2
+	centerX, centerY := reportMargin+reportContentWidth/2, top+reportMapHeight/2
+	toX := func(lon float64) float64 { return centerX + (lon-centerLon)*kx*scale }
+	toY := func(lat float64) float64 { return centerY - (lat-centerLat)*scale }
+	fromX := func(x float64) float64 { return centerLon + (x-centerX)/(kx*scale) }
+	fromY := func(y float64) float64 { return centerLat - (y-centerY)/scale }
+
+	l.doc.FillRect(reportMargin, top, reportContentWidth, reportMapHeight, utils.PDFColor{R: 0.97, G: 0.97, B: 0.95})
+
+	// Grid lines at quarters, labelled with their coordinate
+	for i := 1; i < 4; i++ {
+		x := reportMargin + reportContentWidth*float64(i)/4
+		l.doc.Line(x, top, x, top+reportMapHeight, 0.5, utils.PDFLightGray)
+		label := strconv.FormatFloat(fromX(x), 'f', 4, 64)
+		l.doc.Text(x+2, top+reportMapHeight-3, utils.PDFHelvetica, 6, utils.PDFGray, label)
+
+		y := top + reportMapHeight*float64(i)/4
+		l.doc.Line(reportMargin, y, reportMargin+reportContentWidth, y, 0.5, utils.PDFLightGray)
+		label = strconv.FormatFloat(fromY(y), 'f', 4, 64)
+		l.doc.Text(reportMargin+2, y-2, utils.PDFHelvetica, 6, utils.PDFGray, label)
+	}
+	l.doc.StrokeRect(reportMargin, top, reportContentWidth, reportMapHeight, 0.75, utils.PDFGray)
+
+	labelled := len(points) <= 200
+	for _, p := range points {
+		x, y := toX(p.lon), toY(p.lat)
+		l.doc.FillCircle(x, y, 3, reportAccent)
+		if labelled {
+			l.doc.Text(x+4, y+2.5, utils.PDFHelveticaBold, 6, utils.PDFBlack, strconv.Itoa(p.number))
+		}
+	}
+	l.y = top + reportMapHeight + 6
+
+	widthMeters := (fromX(reportMargin+reportContentWidth) - fromX(reportMargin)) * kx * 111320
+	caption := fmt.Sprintf("%d of %d memos have coordinates. North is up; the map is about %s across.",
+		len(points), len(memos), formatDistance(widthMeters))
+	if labelled {
+		caption += " Numbers match the memo list."
+	}
+	l.paragraph(caption, utils.PDFHelvetica, 8, utils.PDFGray, 0)
+}
+
+// memo writes one memo: a shaded title bar, metadata and the transcript
+func (l *reportLayout) memo(number int, memo *models.MemoExportRow) {
+	l.y += 6
+	l.ensure(70)
+
+	const barHeight = 18.0
+	l.doc.FillRect(reportMargin, l.y, reportContentWidth, barHeight, utils.PDFLightGray)
+	created := memo.CreatedAt.In(l.loc).Format(reportDateTimeFormat)
+	createdWidth := utils.PDFTextWidth(created, utils.PDFHelvetica, 9)
+	l.doc.Text(reportMargin+reportContentWidth-6-createdWidth, l.y+12.5, utils.PDFHelvetica, 9, utils.PDFBlack, created)
+	title := fmt.Sprintf("#%d  %s", number, reportMemoTitle(memo))
+	l.doc.Text(reportMargin+6, l.y+12.5, utils.PDFHelveticaBold, 10, utils.PDFBlack,
+		fitText(title, utils.PDFHelveticaBold, 10, reportContentWidth-createdWidth-24))
+	l.y += barHeight + 4
+
+	author := memo.UserName
+	if memo.Department != nil {
+		author += " (" + *memo.Department + ")"
+	}
+	meta := []string{"By " + author}
+	if memo.ParkName != nil {
+		meta = append(meta, "Park: "+*memo.ParkName)
+	}
+	if memo.Latitude != nil && memo.Longitude != nil {
+		location := fmt.Sprintf("Location: %.6f, %.6f", *memo.Latitude, *memo.Longitude)
+		if memo.LocationAccuracy != nil {
+			location += fmt.Sprintf(" (±%.0f m)", *memo.LocationAccuracy)
+		}
+		if memo.Address != nil {
+			location += ", " + *memo.Address
+		}
+		meta = append(meta, location)
+	}
+	details := []string{}
+	if memo.Category != nil {
+		details = append(details, "Category: "+*memo.Category)
+	}
+	if len(memo.Tags) > 0 {
+		details = append(details, "Tags: "+strings.Join(memo.Tags, ", "))
+	}
+	if memo.HasAudio {
+		audio := "Recording: " + formatDuration(memo.DurationSeconds)
+		if memo.TranscriptionStatus != nil && *memo.TranscriptionStatus != models.TranscriptionStatusCompleted {
+			audio += ", transcription " + *memo.TranscriptionStatus
+		}
+		details = append(details, audio)
+	}
+	if !memo.UpdatedAt.Equal(memo.CreatedAt) {
+		details = append(details, "Edited "+memo.UpdatedAt.In(l.loc).Format(reportDateTimeFormat))
+	}
+	if len(details) > 0 {
+		meta = append(meta, strings.Join(details, "  ·  "))
+	}
+	for _, line := range meta {
+		l.paragraph(line, utils.PDFHelvetica, 8.5, utils.PDFGray, 6)
+	}
+
+	l.y += 3
+	if strings.TrimSpace(memo.Text) == "" {
+		l.paragraph("No transcript.", utils.PDFHelvetica, 10, utils.PDFGray, 6)
+	} else {
+		l.paragraph(memo.Text, utils.PDFHelvetica, 10, utils.PDFBlack, 6)
+	}
+	l.y += 6
+}
+
+// reportSummaryRows lists the summary totals
+func reportSummaryRows(summary *models.StatsSummary, loc *time.Location) [][]string {
+	rows := [][]string{
+		{"Memos", strconv.Itoa(summary.TotalMemos)},
+		{"With a recording", strconv.Itoa(summary.AudioMemos)},
+		{"Minutes recorded", strconv.FormatFloat(summary.AudioMinutes, 'f', 1, 64)},
+		{"Contributors", strconv.Itoa(summary.Contributors)},
+		{"Parks", strconv.Itoa(summary.Parks)},
+	}
+	if summary.FirstMemoAt != nil && summary.LastMemoAt != nil {
+		rows = append(rows,
+			[]string{"First memo", summary.FirstMemoAt.In(loc).Format(reportDateTimeFormat)},
+			[]string{"Last memo", summary.LastMemoAt.In(loc).Format(reportDateTimeFormat)},
+		)
+	}
+	return rows
+}
+
+// reportGroupRows lists the memo count and audio minutes of each group
+func reportGroupRows(groups []models.StatsGroup) [][]string {
+	rows := [][]string{}
+	for _, group := range groups {
+		name := group.Key
+		if group.Label != nil {
+			name = *group.Label
+		}
+		rows = append(rows, []string{name, strconv.Itoa(group.Count), strconv.FormatFloat(group.AudioMinutes, 'f', 1, 64)})
+	}
+	return rows
+}
+
+// reportMemoTitle is a memo's title, or the start of its text without one
+func reportMemoTitle(memo *models.MemoExportRow) string {
+	if memo.Title != nil && strings.TrimSpace(*memo.Title) != "" {
+		return strings.TrimSpace(*memo.Title)
+	}
+	words := strings.Fields(memo.Text)
+	if len(words) == 0 {
+		return "Untitled memo"
+	}
+	if len(words) > 8 {
+		return strings.Join(words[:8], " ") + "…"
+	}
+	return strings.Join(words, " ")
+}
+
+// describeReportFilter summarizes what a report covers, e.g.
+// "Burke Park · Oct 1, 2024 to Dec 31, 2024"
+func describeReportFilter(f models.MemoFilter, loc *time.Location) string {
+	parts := []string{}
+	if len(f.ParkNames) > 0 {
+		parts = append(parts, strings.Join(f.ParkNames, ", "))
+	}
+	switch {
+	case f.StartDate != nil && f.EndDate != nil:
+		parts = append(parts, f.StartDate.In(loc).Format(reportDateFormat)+" to "+reportEndDate(*f.EndDate, loc))
+	case f.StartDate != nil:
+		parts = append(parts, "From "+f.StartDate.In(loc).Format(reportDateFormat))
+	case f.EndDate != nil:
+		parts = append(parts, "Until "+reportEndDate(*f.EndDate, loc))
+	}
+	if f.Category != "" {
+		parts = append(parts, "Category: "+f.Category)
+	}
+	if len(f.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(f.Tags, ", "))
+	}
+	if f.Language != "" {
+		parts = append(parts, "Language: "+f.Language)
+	}
+	if f.UserID != "" {
+		parts = append(parts, "One author")
+	}
+	if f.Query != "" {
+		parts = append(parts, fmt.Sprintf("Matching %q", f.Query))
+	}
+	if f.Near != nil {
+		parts = append(parts, fmt.Sprintf("Within %s of %.5f, %.5f", formatDistance(float64(f.Near.RadiusMeters)), f.Near.Latitude, f.Near.Longitude))
+	}
+	if f.BBox != nil {
+		parts = append(parts, fmt.Sprintf("Between %.5f, %.5f and %.5f, %.5f",
+			f.BBox.MinLatitude, f.BBox.MinLongitude, f.BBox.MaxLatitude, f.BBox.MaxLongitude))
+	}
+	if len(parts) == 0 {
+		return "All memos"
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// reportEndDate formats an exclusive end date as the last day it covers
+func reportEndDate(end time.Time, loc *time.Location) string {
+	return end.Add(-time.Nanosecond).In(loc).Format(reportDateFormat)
+}
+
+// fitText cuts text short with an ellipsis to fit width
+func fitText(text string, font utils.PDFFont, size, width float64) string {
+	if utils.PDFTextWidth(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && utils.PDFTextWidth(string(runes)+"…", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// formatDuration formats seconds as m:ss
+func formatDuration(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// formatDistance formats meters as m or km
+func formatDistance(meters float64) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%.0f m", meters)
+	}
+	return fmt.Sprintf("%.1f km", meters/1000)
+}
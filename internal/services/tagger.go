@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tom-fitz/trailmemo-api/internal/utils"
+)
+
+// TagRule maps the phrases that indicate a tag to the tag and its category
+type TagRule struct {
+	Tag      string   `json:"tag"`
+	Category string   `json:"category"`
+	Phrases  []string `json:"phrases"`
+}
+
+// TagVocabulary is the list of rules used to suggest tags. Earlier rules win
+// category ties.
+type TagVocabulary struct {
+	Tags []TagRule `json:"tags"`
+}
+
+// DefaultTagVocabulary covers common trail and park reports
+var DefaultTagVocabulary = TagVocabulary{
+	Tags: []TagRule{
+		{Tag: "downed-tree", Category: "hazard", Phrases: []string{"downed tree", "fallen tree", "tree down", "tree across", "blowdown", "deadfall"}},
+		{Tag: "washout", Category: "hazard", Phrases: []string{"washout", "washed out", "erosion", "eroded", "gully"}},
+		{Tag: "rockfall", Category: "hazard", Phrases: []string{"rockfall", "rock slide", "landslide", "fallen rock", "loose rock"}},
+		{Tag: "flooding", Category: "hazard", Phrases: []string{"flooding", "flooded", "standing water", "high water"}},
+		{Tag: "ice", Category: "hazard", Phrases: []string{"icy", "ice", "black ice"}},
+		{Tag: "wildlife", Category: "wildlife", Phrases: []string{"bear", "moose", "mountain lion", "cougar", "rattlesnake", "snake", "elk", "wolf"}},
+		{Tag: "graffiti", Category: "vandalism", Phrases: []string{"graffiti", "spray paint", "tagging", "tagged"}},
+		{Tag: "vandalism", Category: "vandalism", Phrases: []string{"vandalism", "vandalized", "smashed", "broken into"}},
+		{Tag: "litter", Category: "sanitation", Phrases: []string{"litter", "trash", "garbage", "dumping", "dumped"}},
+		{Tag: "restroom", Category: "facilities", Phrases: []string{"restroom", "toilet", "outhouse", "bathroom"}},
+		{Tag: "signage", Category: "maintenance", Phrases: []string{"sign missing", "missing sign", "damaged sign", "sign down", "trail marker", "blaze"}},
+		{Tag: "bridge", Category: "maintenance", Phrases: []string{"bridge", "boardwalk", "footbridge"}},
+		{Tag: "drainage", Category: "maintenance", Phrases: []string{"culvert", "water bar", "drainage", "clogged drain"}},
+		{Tag: "overgrowth", Category: "maintenance", Phrases: []string{"overgrown", "overgrowth", "brush", "needs trimming"}},
+	},
+}
+
+// TagSuggestionResult is the output of the tagger for one text
+type TagSuggestionResult struct {
+	Tags     []string
+	Category string // Empty if no tag matched
+	Keywords []string
+}
+
+type tagMatcher struct {
+	rule     TagRule
+	patterns []*regexp.Regexp
+}
+
+// Tagger suggests tags and a category using dictionary matching on a vocabulary
+type Tagger struct {
+	matchers []tagMatcher
+}
+
+// NewTagger creates a tagger from the vocabulary file at path (JSON, see
+// TagVocabulary), or from DefaultTagVocabulary if path is empty
+func NewTagger(path string) (*Tagger, error) {
+	vocabulary := DefaultTagVocabulary
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading tag vocabulary: %v", err)
+		}
+		vocabulary = TagVocabulary{}
+		if err := json.Unmarshal(data, &vocabulary); err != nil {
+			return nil, fmt.Errorf("error parsing tag vocabulary: %v", err)
+		}
+	}
+
+	tagger := &Tagger{}
+	for _, rule := range vocabulary.Tags {
+		if rule.Tag == "" {
+			return nil, fmt.Errorf("tag vocabulary rule without a tag")
+		}
+		matcher := tagMatcher{rule: rule}
+		for _, phrase := range rule.Phrases {
+			words := strings.Fields(strings.ToLower(phrase))
+			if len(words) == 0 {
+				continue
+			}
+			for i, word := range words {
+				words[i] = regexp.QuoteMeta(word)
+			}
+			// Words may be separated by spaces or hyphens, and the last one
+			// may be plural: "fallen trees", "washed-out"
+			pattern := `(?i)\b` + strings.Join(words, `[\s-]+`) + `(?:s|es)?\b`
+			matcher.patterns = append(matcher.patterns, regexp.MustCompile(pattern))
+		}
+		tagger.matchers = append(tagger.matchers, matcher)
+	}
+
+	return tagger, nil
+}
+
+// Suggest returns the tags whose phrases appear in text. The category is the
+// one with the most matching tags.
+func (t *Tagger) Suggest(text string) *TagSuggestionResult {
+	result := &TagSuggestionResult{Tags: []string{}, Keywords: []string{}}
+	categoryCounts := map[string]int{}
+	categoryOrder := []string{}
+
+	for _, matcher := range t.matchers {
+		matched := false
+		for _, pattern := range matcher.patterns {
+			if found := pattern.FindString(text); found != "" {
+				result.Keywords = appendUnique(result.Keywords, strings.ToLower(found))
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		result.Tags = appendUnique(result.Tags, matcher.rule.Tag)
+		if category := matcher.rule.Category; category != "" {
+			if categoryCounts[category] == 0 {
+				categoryOrder = append(categoryOrder, category)
+			}
+			categoryCounts[category]++
+		}
+	}
+
+	for _, category := range categoryOrder {
+		if categoryCounts[category] > categoryCounts[result.Category] {
+			result.Category = category
+		}
+	}
+
+	return result
+}
+
+func appendUnique(list []string, value string) []string {
+	if utils.ContainsString(list, value) {
+		return list
+	}
+	return append(list, value)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loudnessFilter normalizes speech to -16 LUFS (EBU R128 single pass)
+const loudnessFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// TranscodeTarget describes one output rendition
+type TranscodeTarget struct {
+	Format      string // Variant name stored on the memo, e.g. "opus"
+	Extension   string
+	ContentType string
+	Codec       string // ffmpeg encoder
+	BitrateKbps int
+	ExtraArgs   []string
+}
+
+// TranscodedFile is a rendition written to disk by the transcoder
+type TranscodedFile struct {
+	Target TranscodeTarget
+	Path   string
+	Size   int64
+}
+
+// Transcoder converts recordings into compact, loudness-normalized variants using ffmpeg
+type Transcoder struct {
+	ffmpegPath string
+	targets    []TranscodeTarget
+}
+
+// NewTranscoder creates a new transcoder producing a compact Opus variant and
+// a web-friendly MP3 fallback. Transcoding is disabled if ffmpeg cannot be found.
+func NewTranscoder(ffmpegPath string, opusBitrateKbps, fallbackBitrateKbps int) *Transcoder {
+	path, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		log.Printf("⚠️  ffmpeg not found (%s); audio transcoding disabled", ffmpegPath)
+		path = ""
+	}
+
+	return &Transcoder{
+		ffmpegPath: path,
+		targets: []TranscodeTarget{
+			{
+				Format:      "opus",
+				Extension:   ".opus",
+				ContentType: "audio/ogg",
+				Codec:       "libopus",
+				BitrateKbps: opusBitrateKbps,
+				ExtraArgs:   []string{"-application", "voip"},
+			},
+			{
+				Format:      "mp3",
+				Extension:   ".mp3",
+				ContentType: "audio/mpeg",
+				Codec:       "libmp3lame",
+				BitrateKbps: fallbackBitrateKbps,
+			},
+		},
+	}
+}
+
+// Enabled reports whether ffmpeg is available
+func (t *Transcoder) Enabled() bool {
+	return t.ffmpegPath != ""
+}
+
+// Transcode renders every target variant of inputPath into outputDir
+func (t *Transcoder) Transcode(ctx context.Context, inputPath, outputDir string) ([]TranscodedFile, error) {
+	if !t.Enabled() {
+		return nil, fmt.Errorf("ffmpeg is not available")
+	}
+
+	files := make([]TranscodedFile, 0, len(t.targets))
+	for _, target := range t.targets {
+		outputPath := filepath.Join(outputDir, target.Format+target.Extension)
+
+		args := []string{
+			"-hide_banner", "-loglevel", "error", "-y",
+			"-i", inputPath,
+			"-vn",
+			"-af", loudnessFilter,
+			"-ac", "1",
+			"-c:a", target.Codec,
+			"-b:a", strconv.Itoa(target.BitrateKbps) + "k",
+		}
+		args = append(args, target.ExtraArgs...)
+		args = append(args, outputPath)
+
+		cmd := exec.CommandContext(ctx, t.ffmpegPath, args...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("error transcoding to %s: %v: %s", target.Format, err, strings.TrimSpace(string(output)))
+		}
+
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s output: %v", target.Format, err)
+		}
+
+		files = append(files, TranscodedFile{
+			Target: target,
+			Path:   outputPath,
+			Size:   info.Size(),
+		})
+	}
+
+	return files, nil
+}
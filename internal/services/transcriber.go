@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Transcription engines selectable with TRANSCRIBER_ENGINE
+const (
+	TranscriberEngineWhisper = "whisper"
+	TranscriberEngineStub    = "stub"
+	TranscriberEngineNone    = "none"
+)
+
+// TranscribedWord is a recognized word with its position in the recording
+type TranscribedWord struct {
+	Text       string
+	Start      float64 // Seconds
+	End        float64
+	Confidence float64 // 0-1
+}
+
+// Transcription is the output of a transcription engine
+type Transcription struct {
+	Text     string
+	Language string
+	Words    []TranscribedWord
+}
+
+// Transcriber converts a recording into text
+type Transcriber interface {
+	// Name identifies the engine in stored transcripts
+	Name() string
+
+	// Enabled reports whether the engine can run in this environment
+	Enabled() bool
+
+	// Transcribe transcribes the audio file at audioPath. language is an
+	// ISO 639-1 code, or "auto" or empty to let the engine detect it.
+	Transcribe(ctx context.Context, audioPath, language string) (*Transcription, error)
+}
+
+// NewTranscriber creates the transcription engine named by engine.
+// Engines that process audio stop after maxDuration of it.
+func NewTranscriber(engine, whisperPath, whisperModelPath, ffmpegPath string, maxDuration time.Duration) (Transcriber, error) {
+	switch strings.ToLower(engine) {
+	case TranscriberEngineWhisper:
+		return NewWhisperTranscriber(whisperPath, whisperModelPath, ffmpegPath, maxDuration), nil
+	case TranscriberEngineStub:
+		return &StubTranscriber{}, nil
+	case TranscriberEngineNone, "":
+		return disabledTranscriber{}, nil
+	default:
+		return nil, fmt.Errorf("unknown transcriber engine %q", engine)
+	}
+}
+
+// StubTranscriber returns a fixed transcript without looking at the audio, so
+// the transcription pipeline can run where whisper.cpp isn't installed.
+type StubTranscriber struct {
+	// Text is the transcript to return; a default sentence is used if empty
+	Text string
+}
+
+// Name implements Transcriber
+func (t *StubTranscriber) Name() string { return TranscriberEngineStub }
+
+// Enabled implements Transcriber
+func (t *StubTranscriber) Enabled() bool { return true }
+
+// Transcribe implements Transcriber. Words are spaced half a second apart.
+func (t *StubTranscriber) Transcribe(ctx context.Context, audioPath, language string) (*Transcription, error) {
+	text := t.Text
+	if text == "" {
+		text = "This is a stub transcript"
+	}
+	if language == "" || language == "auto" {
+		language = "en"
+	}
+
+	fields := strings.Fields(text)
+	words := make([]TranscribedWord, len(fields))
+	for i, field := range fields {
+		words[i] = TranscribedWord{
+			Text:       field,
+			Start:      float64(i) * 0.5,
+			End:        float64(i)*0.5 + 0.4,
+			Confidence: 1,
+		}
+	}
+
+	return &Transcription{
+		Text:     strings.Join(fields, " "),
+		Language: language,
+		Words:    words,
+	}, nil
+}
+
+// disabledTranscriber is used when transcription is turned off
+type disabledTranscriber struct{}
+
+func (disabledTranscriber) Name() string  { return TranscriberEngineNone }
+func (disabledTranscriber) Enabled() bool { return false }
+func (disabledTranscriber) Transcribe(ctx context.Context, audioPath, language string) (*Transcription, error) {
+	return nil, fmt.Errorf("transcription is disabled")
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os/exec"
+)
+
+const (
+	// waveformSampleRate is the rate audio is decoded at for peak detection
+	waveformSampleRate = 8000
+
+	// defaultPeaksPerSecond and maxPeaks bound the waveform resolution;
+	// long recordings get fewer peaks per second to stay under maxPeaks
+	defaultPeaksPerSecond = 10
+	maxPeaks              = 20000
+)
+
+// WaveformData is the result of analysing a recording
+type WaveformData struct {
+	Peaks           []float64
+	PeaksPerSecond  float64
+	SampleRate      int
+	DurationSeconds float64
+}
+
+// WaveformGenerator computes waveform peaks by decoding audio with ffmpeg
+type WaveformGenerator struct {
+	ffmpegPath string
+}
+
+// NewWaveformGenerator creates a new waveform generator.
+// Generation is disabled if ffmpeg cannot be found.
+func NewWaveformGenerator(ffmpegPath string) *WaveformGenerator {
+	path, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		log.Printf("⚠️  ffmpeg not found (%s); waveform generation disabled", ffmpegPath)
+		path = ""
+	}
+	return &WaveformGenerator{ffmpegPath: path}
+}
+
+// Enabled reports whether ffmpeg is available
+func (g *WaveformGenerator) Enabled() bool {
+	return g.ffmpegPath != ""
+}
+
+// Generate decodes the audio file at inputPath to mono PCM and returns its
+// peaks. expectedDuration (seconds) is used to pick the resolution.
+func (g *WaveformGenerator) Generate(ctx context.Context, inputPath string, expectedDuration int) (*WaveformData, error) {
+	if !g.Enabled() {
+		return nil, fmt.Errorf("ffmpeg is not available")
+	}
+
+	peaksPerSecond := float64(defaultPeaksPerSecond)
+	if expectedDuration > 0 && float64(expectedDuration)*peaksPerSecond > maxPeaks {
+		peaksPerSecond = float64(maxPeaks) / float64(expectedDuration)
+	}
+	samplesPerPeak := int(math.Max(1, math.Round(waveformSampleRate/peaksPerSecond)))
+
+	cmd := exec.CommandContext(ctx, g.ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"pipe:1",
+	)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating ffmpeg pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting ffmpeg: %v", err)
+	}
+
+	peaks, samples, readErr := readPeaks(bufio.NewReader(stdout), samplesPerPeak)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("error decoding audio: %v", err)
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("error reading decoded audio: %v", readErr)
+	}
+
+	return &WaveformData{
+		Peaks:           peaks,
+		PeaksPerSecond:  float64(waveformSampleRate) / float64(samplesPerPeak),
+		SampleRate:      waveformSampleRate,
+		DurationSeconds: float64(samples) / waveformSampleRate,
+	}, nil
+}
+
+// readPeaks reads little-endian 16-bit samples and returns the maximum
+// absolute amplitude of every window of samplesPerPeak samples
+func readPeaks(r io.Reader, samplesPerPeak int) ([]float64, int, error) {
+	peaks := []float64{}
+	buf := make([]byte, 2)
+	samples := 0
+	peak := 0.0
+
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, 0, err
+		}
+
+		amplitude := math.Abs(float64(int16(binary.LittleEndian.Uint16(buf)))) / 32768
+		if amplitude > peak {
+			peak = amplitude
+		}
+		samples++
+
+		if samples%samplesPerPeak == 0 {
+			peaks = append(peaks, roundPeak(peak))
+			peak = 0
+		}
+	}
+
+	if samples%samplesPerPeak != 0 {
+		peaks = append(peaks, roundPeak(peak))
+	}
+
+	return peaks, samples, nil
+}
+
+// roundPeak keeps three decimals, plenty for drawing and much smaller to store
+func roundPeak(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// whisperSampleRate is the input sample rate whisper.cpp expects
+const whisperSampleRate = 16000
+
+// WhisperTranscriber runs the whisper.cpp command line tool locally.
+// Audio is first converted to 16 kHz mono WAV with ffmpeg.
+type WhisperTranscriber struct {
+	whisperPath string
+	modelPath   string
+	ffmpegPath  string
+	maxDuration time.Duration
+}
+
+// NewWhisperTranscriber creates a new whisper.cpp adapter. Only the first
+// maxDuration of a recording is transcribed (no limit if zero). Transcription
+// is disabled if the binary, the model or ffmpeg cannot be found.
+func NewWhisperTranscriber(whisperPath, modelPath, ffmpegPath string, maxDuration time.Duration) *WhisperTranscriber {
+	t := &WhisperTranscriber{maxDuration: maxDuration}
+
+	path, err := exec.LookPath(whisperPath)
+	if err != nil {
+		log.Printf("⚠️  whisper not found (%s); transcription disabled", whisperPath)
+		return t
+	}
+	if _, err := os.Stat(modelPath); modelPath == "" || err != nil {
+		log.Printf("⚠️  whisper model not found (%s); transcription disabled", modelPath)
+		return t
+	}
+	ffmpeg, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		log.Printf("⚠️  ffmpeg not found (%s); transcription disabled", ffmpegPath)
+		return t
+	}
+
+	t.whisperPath = path
+	t.modelPath = modelPath
+	t.ffmpegPath = ffmpeg
+	return t
+}
+
+// Name implements Transcriber
+func (t *WhisperTranscriber) Name() string { return TranscriberEngineWhisper }
+
+// Enabled implements Transcriber
+func (t *WhisperTranscriber) Enabled() bool {
+	return t.whisperPath != ""
+}
+
+// whisperOutput is the subset of whisper.cpp's --output-json-full format we use
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Text    string `json:"text"`
+		Offsets struct {
+			From int64 `json:"from"` // Milliseconds
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Tokens []struct {
+			Text string  `json:"text"`
+			P    float64 `json:"p"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// Transcribe implements Transcriber
+func (t *WhisperTranscriber) Transcribe(ctx context.Context, audioPath, language string) (*Transcription, error) {
+	if !t.Enabled() {
+		return nil, fmt.Errorf("whisper is not available")
+	}
+
+	workDir, err := os.MkdirTemp("", "trailmemo-whisper-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating work directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	wavPath := filepath.Join(workDir, "input.wav")
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", audioPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(whisperSampleRate),
+		"-c:a", "pcm_s16le",
+	}
+	// Bound the work regardless of the duration the client reported
+	if t.maxDuration > 0 {
+		args = append(args, "-t", fmt.Sprint(int(t.maxDuration.Seconds())))
+	}
+	convert := exec.CommandContext(ctx, t.ffmpegPath, append(args, wavPath)...)
+	if output, err := convert.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("error converting audio: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	if language == "" {
+		language = "auto"
+	}
+
+	// One word per segment (-ml 1 -sow) gives word-level timestamps
+	outputBase := filepath.Join(workDir, "transcript")
+	cmd := exec.CommandContext(ctx, t.whisperPath,
+		"-m", t.modelPath,
+		"-f", wavPath,
+		"-l", language,
+		"-ml", "1",
+		"-sow",
+		"-ojf",
+		"-of", outputBase,
+		"-np",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("error running whisper: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	data, err := os.ReadFile(outputBase + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("error reading whisper output: %v", err)
+	}
+
+	var out whisperOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("error parsing whisper output: %v", err)
+	}
+
+	transcription := &Transcription{Language: out.Result.Language}
+	texts := []string{}
+	for _, segment := range out.Transcription {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+
+		// Average the probability of the segment's text tokens, skipping
+		// special tokens such as [_BEG_]
+		confidence, n := 0.0, 0
+		for _, token := range segment.Tokens {
+			if strings.HasPrefix(token.Text, "[_") {
+				continue
+			}
+			confidence += token.P
+			n++
+		}
+		if n > 0 {
+			confidence /= float64(n)
+		}
+
+		texts = append(texts, text)
+		transcription.Words = append(transcription.Words, TranscribedWord{
+			Text:       text,
+			Start:      float64(segment.Offsets.From) / 1000,
+			End:        float64(segment.Offsets.To) / 1000,
+			Confidence: confidence,
+		})
+	}
+	transcription.Text = strings.Join(texts, " ")
+
+	return transcription, nil
+}
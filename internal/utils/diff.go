@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// DiffWords computes a word-level diff between two texts.
+// Consecutive words with the same operation are merged into a single segment,
+// so a corrected transcript reads as a handful of insert/delete spans.
+func DiffWords(from, to string) []models.DiffOp {
+	a := strings.Fields(from)
+	b := strings.Fields(to)
+
+	// Longest common subsequence table (suffix lengths)
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := []models.DiffOp{}
+	appendOp := func(op, word string) {
+		if n := len(ops); n > 0 && ops[n-1].Op == op {
+			ops[n-1].Text += " " + word
+			return
+		}
+		ops = append(ops, models.DiffOp{Op: op, Text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			appendOp("equal", a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			appendOp("delete", a[i])
+			i++
+		default:
+			appendOp("insert", b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		appendOp("delete", a[i])
+	}
+	for ; j < len(b); j++ {
+		appendOp("insert", b[j])
+	}
+
+	return ops
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageEvidence is the number of stopwords needed before a language is
+// reported; shorter texts are too ambiguous
+const minLanguageEvidence = 2
+
+// languageStopwords are frequent function words of each supported language.
+// Words shared by several languages count for each of them.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "on", "at", "with", "there", "this", "that", "it", "near", "from", "by"},
+	"es": {"el", "la", "los", "las", "y", "es", "está", "están", "hay", "de", "del", "en", "con", "por", "para", "que", "un", "una", "cerca", "muy"},
+	"fr": {"le", "la", "les", "et", "est", "sont", "il", "y", "a", "de", "du", "des", "dans", "sur", "avec", "pour", "que", "une", "près"},
+	"de": {"der", "die", "das", "und", "ist", "sind", "es", "gibt", "von", "mit", "auf", "im", "zu", "den", "dem", "ein", "eine", "nicht"},
+	"it": {"il", "lo", "la", "gli", "le", "e", "è", "sono", "di", "del", "della", "in", "con", "per", "che", "un", "una", "vicino"},
+	"pt": {"o", "a", "os", "as", "e", "é", "está", "estão", "há", "de", "do", "da", "em", "no", "na", "com", "para", "que", "um", "uma"},
+}
+
+// languageIndex maps each stopword to the languages it belongs to
+var languageIndex = buildLanguageIndex()
+
+func buildLanguageIndex() map[string][]string {
+	index := map[string][]string{}
+	for lang, words := range languageStopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}
+
+// DetectLanguage guesses the language of text from its stopwords. It returns
+// false when there is too little evidence or two languages tie.
+func DetectLanguage(text string) (string, bool) {
+	counts := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		for _, lang := range languageIndex[word] {
+			counts[lang]++
+		}
+	}
+
+	best, bestCount, tied := "", 0, false
+	for lang, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = lang, count, false
+		case count == bestCount:
+			tied = true
+		}
+	}
+
+	if bestCount < minLanguageEvidence || tied {
+		return "", false
+	}
+	return best, true
+}
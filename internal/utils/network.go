@@ -0,0 +1,17 @@
+package utils
+
+import "net"
+
+// IsPublicIP reports whether ip can be reached from the internet, i.e. it is
+// not a loopback, private, link-local, multicast or unspecified address.
+// Requests made on a user's behalf are refused for anything else, so that a
+// URL can't be used to reach the server itself or the network it runs in.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
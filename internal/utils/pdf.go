@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// US Letter page size in points
+const (
+	PDFLetterWidth  = 612.0
+	PDFLetterHeight = 792.0
+)
+
+// PDFFont is one of the fonts every PDF viewer has built in
+type PDFFont int
+
+// Built-in fonts
+const (
+	PDFHelvetica PDFFont = iota
+	PDFHelveticaBold
+)
+
+// PDFColor is an RGB color with components from 0 to 1
+type PDFColor struct {
+	R, G, B float64
+}
+
+// Common colors
+var (
+	PDFBlack     = PDFColor{0, 0, 0}
+	PDFGray      = PDFColor{0.45, 0.45, 0.45}
+	PDFLightGray = PDFColor{0.88, 0.88, 0.88}
+	PDFWhite     = PDFColor{1, 1, 1}
+)
+
+// PDFDocument builds a PDF of vector text and shapes. Positions are in points
+// from the top left corner of the page, and text is placed by its baseline.
+// Text is limited to Windows-1252 characters; others are shown as "?".
+type PDFDocument struct {
+	width, height float64
+	title         string
+	pages         []*bytes.Buffer
+	page          *bytes.Buffer
+}
+
+// NewPDFDocument creates an empty document with pages of the given size
+func NewPDFDocument(width, height float64, title string) *PDFDocument {
+	return &PDFDocument{width: width, height: height, title: title}
+}
+
+// AddPage starts a new page and makes it current
+func (d *PDFDocument) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// PageCount returns the number of pages
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage makes the zero-based page i current, e.g. to add footers once the
+// page count is known
+func (d *PDFDocument) SetPage(i int) {
+	d.page = d.pages[i]
+}
+
+// Text draws s with its baseline at y
+func (d *PDFDocument) Text(x, y float64, font PDFFont, size float64, color PDFColor, s string) {
+	fmt.Fprintf(d.page, "BT %s rg /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		pdfColor(color), int(font)+1, pdfNum(size), pdfNum(x), pdfNum(d.height-y), pdfEscape(s))
+}
+
+// FillRect fills a rectangle whose top left corner is at x, y
+func (d *PDFDocument) FillRect(x, y, w, h float64, color PDFColor) {
+	fmt.Fprintf(d.page, "%s rg %s %s %s %s re f\n",
+		pdfColor(color), pdfNum(x), pdfNum(d.height-y-h), pdfNum(w), pdfNum(h))
+}
+
+// StrokeRect outlines a rectangle whose top left corner is at x, y
+func (d *PDFDocument) StrokeRect(x, y, w, h, lineWidth float64, color PDFColor) {
+	fmt.Fprintf(d.page, "%s RG %s w %s %s %s %s re S\n",
+		pdfColor(color), pdfNum(lineWidth), pdfNum(x), pdfNum(d.height-y-h), pdfNum(w), pdfNum(h))
+}
+
+// Line draws a straight line
+func (d *PDFDocument) Line(x1, y1, x2, y2, lineWidth float64, color PDFColor) {
+	fmt.Fprintf(d.page, "%s RG %s w %s %s m %s %s l S\n",
+		pdfColor(color), pdfNum(lineWidth), pdfNum(x1), pdfNum(d.height-y1), pdfNum(x2), pdfNum(d.height-y2))
+}
+
+// FillCircle fills a circle centred at x, y
+func (d *PDFDocument) FillCircle(x, y, r float64, color PDFColor) {
+	// Four Bézier curves approximate the circle
+	const k = 0.5523
+	cy := d.height - y
+	fmt.Fprintf(d.page, "%s rg %s %s m ", pdfColor(color), pdfNum(x+r), pdfNum(cy))
+	fmt.Fprintf(d.page, "%s %s %s %s %s %s c ", pdfNum(x+r), pdfNum(cy+k*r), pdfNum(x+k*r), pdfNum(cy+r), pdfNum(x), pdfNum(cy+r))
+	fmt.Fprintf(d.page, "%s %s %s %s %s %s c ", pdfNum(x-k*r), pdfNum(cy+r), pdfNum(x-r), pdfNum(cy+k*r), pdfNum(x-r), pdfNum(cy))
+	fmt.Fprintf(d.page, "%s %s %s %s %s %s c ", pdfNum(x-r), pdfNum(cy-k*r), pdfNum(x-k*r), pdfNum(cy-r), pdfNum(x), pdfNum(cy-r))
+	fmt.Fprintf(d.page, "%s %s %s %s %s %s c f\n", pdfNum(x+k*r), pdfNum(cy-r), pdfNum(x+r), pdfNum(cy-k*r), pdfNum(x+r), pdfNum(cy))
+}
+
+// WriteTo writes the document as a PDF file
+func (d *PDFDocument) WriteTo(w io.Writer) (int64, error) {
+	out := &countingWriter{w: w}
+	offsets := []int64{}
+	object := func(body string) {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	fmt.Fprint(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are the catalog, page tree, fonts and document info;
+	// each page then takes a page object and a content stream
+	pageIDs := []string{}
+	for i := range d.pages {
+		pageIDs = append(pageIDs, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(pageIDs, " "), len(d.pages), pdfNum(d.width), pdfNum(d.height)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (TrailMemo) /CreationDate (D:%s) >>",
+		pdfEscape(d.title), time.Now().UTC().Format("20060102150405Z")))
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", 7+2*i))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return out.n, err
+		}
+		if err := zw.Close(); err != nil {
+			return out.n, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.n, out.err
+}
+
+// PDFTextWidth returns the width of s in points
+func PDFTextWidth(s string, font PDFFont, size float64) float64 {
+	widths := &helveticaWidths
+	if font == PDFHelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, b := range winAnsi(s) {
+		switch {
+		case b >= 32 && b <= 126:
+			total += widths[b-32]
+		case b == 0x91 || b == 0x92:
+			total += 278
+		case b == 0x85 || b == 0x97:
+			total += 1000
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// PDFWrapText breaks s into lines no wider than width, at spaces where
+// possible. Line breaks in s are kept.
+func PDFWrapText(s string, font PDFFont, size, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if PDFTextWidth(candidate, font, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Split words that don't fit on a line of their own, like URLs
+			for PDFTextWidth(word, font, size) > width {
+				runes := []rune(word)
+				cut := len(runes) - 1
+				for cut > 1 && PDFTextWidth(string(runes[:cut]), font, size) > width {
+					cut--
+				}
+				lines = append(lines, string(runes[:cut]))
+				word = string(runes[cut:])
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// countingWriter counts the bytes written, for the cross-reference table.
+// After an error it discards further writes and keeps the error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return len(p), nil
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return len(p), nil
+}
+
+// pdfNum formats a number compactly
+func pdfNum(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+func pdfColor(c PDFColor) string {
+	return pdfNum(c.R) + " " + pdfNum(c.G) + " " + pdfNum(c.B)
+}
+
+// pdfEscape encodes s as the body of a PDF string literal
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, c := range winAnsi(s) {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// winAnsiExtras maps the characters Windows-1252 adds to Latin-1
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// winAnsi converts s to Windows-1252, replacing control characters with
+// spaces and characters it can't represent with "?"
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 32 || r == 127:
+			out = append(out, ' ')
+		case r < 127 || r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiExtras[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// Advance widths of the printable ASCII characters, in thousandths of the
+// font size, from the Adobe font metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
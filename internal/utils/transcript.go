@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tom-fitz/trailmemo-api/internal/models"
+)
+
+// segmentPause is the silence (seconds) that starts a new segment even
+// without sentence punctuation
+const segmentPause = 1.0
+
+// SegmentWords groups timed words into sentence-like segments. A segment ends
+// at sentence punctuation or at a pause longer than segmentPause.
+func SegmentWords(words models.TranscriptWords) models.TranscriptSegments {
+	segments := models.TranscriptSegments{}
+
+	var current []string
+	var start, end float64
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, models.TranscriptSegment{
+				Text:  strings.Join(current, " "),
+				Start: start,
+				End:   end,
+			})
+			current = nil
+		}
+	}
+
+	for _, word := range words {
+		if len(current) > 0 && word.Start-end > segmentPause {
+			flush()
+		}
+		if len(current) == 0 {
+			start = word.Start
+		}
+		current = append(current, word.Word)
+		end = word.End
+
+		if strings.HasSuffix(word.Word, ".") || strings.HasSuffix(word.Word, "?") || strings.HasSuffix(word.Word, "!") {
+			flush()
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// FindTranscriptMatch locates query in a transcript's words. It prefers the
+// first place the whole phrase is spoken and falls back to the first word
+// matching any query term. It returns nil if nothing matches.
+func FindTranscriptMatch(words models.TranscriptWords, query string) *models.TranscriptMatch {
+	terms := normalizedTerms(query)
+	if len(terms) == 0 || len(words) == 0 {
+		return nil
+	}
+
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = normalizeWord(word.Word)
+	}
+
+	// Whole phrase
+	for i := 0; i+len(terms) <= len(words); i++ {
+		matched := true
+		for j, term := range terms {
+			if !termMatches(normalized[i+j], term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return newTranscriptMatch(words[i : i+len(terms)])
+		}
+	}
+
+	// Any single term
+	for i, word := range normalized {
+		for _, term := range terms {
+			if termMatches(word, term) {
+				return newTranscriptMatch(words[i : i+1])
+			}
+		}
+	}
+
+	return nil
+}
+
+func newTranscriptMatch(words models.TranscriptWords) *models.TranscriptMatch {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.Word
+	}
+	return &models.TranscriptMatch{
+		Text:  strings.Join(texts, " "),
+		Start: words[0].Start,
+		End:   words[len(words)-1].End,
+	}
+}
+
+// normalizedTerms splits a search query into lowercase terms without
+// punctuation, dropping negated terms and the OR operator
+func normalizedTerms(query string) []string {
+	terms := []string{}
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") || strings.EqualFold(field, "or") {
+			continue
+		}
+		if term := normalizeWord(field); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
+
+// termMatches approximates full-text stemming: "trees" matches "tree" and
+// "blocking" matches "block"
+func termMatches(word, term string) bool {
+	if word == term {
+		return true
+	}
+	if len(word) < 3 || len(term) < 3 {
+		return false
+	}
+	return strings.HasPrefix(word, term) || strings.HasPrefix(term, word)
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// XLSXMaxRows is the number of rows a worksheet can hold, header included
+const XLSXMaxRows = 1048576
+
+// xlsxMaxCellText is the most characters Excel shows in a cell
+const xlsxMaxCellText = 32767
+
+// xlsxMaxFormulaText is the longest string literal allowed in a formula
+const xlsxMaxFormulaText = 255
+
+// Cell styles defined in xlsxStyles
+const (
+	xlsxStyleDate   = 1
+	xlsxStyleHeader = 2
+)
+
+// excelEpoch is day zero of Excel's date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSXLink is a cell that links to URL
+type XLSXLink struct {
+	URL  string
+	Text string
+}
+
+// XLSXWriter writes a single-sheet workbook row by row, so large exports are
+// streamed instead of built in memory. Cells can be strings, numbers,
+// time.Time (written as its wall-clock time), XLSXLink or nil for an empty
+// cell. Call Close to finish the file.
+type XLSXWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+// NewXLSXWriter starts a workbook on w with one sheet named sheetName
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("error writing workbook: %v", err)
+		}
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, fmt.Errorf("error writing workbook: %v", err)
+		}
+	}
+
+	// The sheet is written last, as it stays open while rows are added
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("error writing workbook: %v", err)
+	}
+	sheet := bufio.NewWriter(sw)
+	if _, err := sheet.WriteString(xlsxSheetHeader); err != nil {
+		return nil, fmt.Errorf("error writing workbook: %v", err)
+	}
+
+	return &XLSXWriter{zip: zw, sheet: sheet}, nil
+}
+
+// WriteHeader writes a bold header row. The sheet scrolls below it.
+func (x *XLSXWriter) WriteHeader(titles []string) error {
+	cells := make([]interface{}, len(titles))
+	for i, title := range titles {
+		cells[i] = title
+	}
+	return x.writeRow(cells, xlsxStyleHeader)
+}
+
+// WriteRow appends a row
+func (x *XLSXWriter) WriteRow(cells []interface{}) error {
+	return x.writeRow(cells, 0)
+}
+
+// Flush writes buffered rows to the underlying writer
+func (x *XLSXWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Flush()
+}
+
+// Close finishes the sheet and the file. It does not close the underlying
+// writer.
+func (x *XLSXWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return fmt.Errorf("error writing workbook: %v", err)
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return fmt.Errorf("error writing workbook: %v", err)
+	}
+	return x.zip.Close()
+}
+
+func (x *XLSXWriter) writeRow(cells []interface{}, style int) error {
+	if x.rows >= XLSXMaxRows {
+		return fmt.Errorf("worksheet is full")
+	}
+	x.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.rows)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(x.rows)
+		styleAttr := ""
+		if style != 0 {
+			styleAttr = fmt.Sprintf(` s="%d"`, style)
+		}
+
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case string:
+			fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlEscape(truncateCellText(v)))
+		case int:
+			fmt.Fprintf(&b, `<c r="%s"%s><v>%d</v></c>`, ref, styleAttr, v)
+		case int64:
+			fmt.Fprintf(&b, `<c r="%s"%s><v>%d</v></c>`, ref, styleAttr, v)
+		case float64:
+			fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, strconv.FormatFloat(v, 'f', -1, 64))
+		case time.Time:
+			// Excel has no time zones; write the wall-clock time
+			wall := time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+			serial := wall.Sub(excelEpoch).Hours() / 24
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(serial, 'f', -1, 64))
+		case XLSXLink:
+			// HYPERLINK keeps the sheet streamable, as a hyperlink part would
+			// need every URL up front; Excel rejects longer literals
+			if len(v.URL) > xlsxMaxFormulaText || len(v.Text) > xlsxMaxFormulaText {
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t>%s</t></is></c>`, ref, styleAttr, xmlEscape(v.URL))
+				continue
+			}
+			formula := fmt.Sprintf(`HYPERLINK("%s","%s")`, strings.ReplaceAll(v.URL, `"`, `""`), strings.ReplaceAll(v.Text, `"`, `""`))
+			fmt.Fprintf(&b, `<c r="%s"%s t="str"><f>%s</f><v>%s</v></c>`, ref, styleAttr, xmlEscape(formula), xmlEscape(v.Text))
+		default:
+			fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlEscape(truncateCellText(fmt.Sprint(v))))
+		}
+	}
+	b.WriteString("</row>")
+
+	_, err := x.sheet.WriteString(b.String())
+	return err
+}
+
+// xlsxColumn returns the letters of the zero-based column i: A, B, ... AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// truncateCellText cuts text to what a cell can hold
+func truncateCellText(s string) string {
+	if utf8.RuneCountInString(s) <= xlsxMaxCellText {
+		return s
+	}
+	return string([]rune(s)[:xlsxMaxCellText])
+}
+
+// xmlEscape escapes text for XML, dropping characters XML cannot contain
+func xmlEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r != 0xFFFE && r != 0xFFFF:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the default style, a date-time style and a bold header
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+const xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+	`<sheetData>`
+
+const xlsxSheetFooter = `</sheetData></worksheet>`
@@ -0,0 +1,18 @@
+-- Memo revisions table
+-- Each row is a full snapshot of a memo's editable fields. Revision 1 is the
+-- original field report; every update appends the next revision.
+CREATE TABLE IF NOT EXISTS memo_revisions (
+    revision_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    memo_id UUID NOT NULL REFERENCES memos(memo_id) ON DELETE CASCADE,
+    revision_number INTEGER NOT NULL,
+    title VARCHAR(255),
+    text TEXT NOT NULL,
+    park_name VARCHAR(255),
+    latitude DECIMAL(10, 8),
+    longitude DECIMAL(11, 8),
+    edited_by VARCHAR(128) REFERENCES users(user_id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (memo_id, revision_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_memo_revisions_memo ON memo_revisions(memo_id, revision_number DESC);
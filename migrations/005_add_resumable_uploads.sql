@@ -0,0 +1,26 @@
+-- Resumable uploads table
+-- Tracks tus-style chunked uploads. Received bytes are stored in
+-- object_key as they arrive; upload_offset is the number of bytes committed.
+CREATE TABLE IF NOT EXISTS uploads (
+    upload_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user ON uploads(user_id, created_at DESC);
+
+-- Trigger to auto-update updated_at
+DROP TRIGGER IF EXISTS update_uploads_updated_at ON uploads;
+CREATE TRIGGER update_uploads_updated_at
+    BEFORE UPDATE ON uploads
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
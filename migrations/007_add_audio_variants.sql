@@ -0,0 +1,16 @@
+-- Transcoding status on memos: NULL (no audio), pending, completed, failed or skipped
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS transcode_status VARCHAR(20);
+
+-- Transcoded audio variants of a memo's original recording
+CREATE TABLE IF NOT EXISTS memo_audio_variants (
+    variant_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    memo_id UUID NOT NULL REFERENCES memos(memo_id) ON DELETE CASCADE,
+    format VARCHAR(20) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    audio_url TEXT NOT NULL,
+    bitrate_kbps INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    loudness_normalized BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (memo_id, format)
+);
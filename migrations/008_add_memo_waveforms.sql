@@ -0,0 +1,9 @@
+-- Waveform peak data for drawing a scrubbable waveform without downloading the audio
+CREATE TABLE IF NOT EXISTS memo_waveforms (
+    memo_id UUID PRIMARY KEY REFERENCES memos(memo_id) ON DELETE CASCADE,
+    peaks REAL[] NOT NULL,
+    peaks_per_second DOUBLE PRECISION NOT NULL,
+    sample_rate INTEGER NOT NULL,
+    duration_seconds DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
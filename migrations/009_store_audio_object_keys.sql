@@ -0,0 +1,21 @@
+-- Store storage object keys instead of public URLs. Audio is served through
+-- short-lived signed URLs generated when a memo is returned.
+ALTER TABLE memos RENAME COLUMN audio_url TO audio_key;
+ALTER TABLE memos ALTER COLUMN audio_key DROP NOT NULL;
+
+UPDATE memos
+SET audio_key = regexp_replace(audio_key, '^https://storage\.googleapis\.com/[^/]+/', '')
+WHERE audio_key LIKE 'https://storage.googleapis.com/%';
+
+-- Memos created without a recording used a placeholder URL
+UPDATE memos
+SET audio_key = NULL
+WHERE audio_key LIKE 'http://%' OR audio_key LIKE 'https://%';
+
+ALTER TABLE memo_audio_variants RENAME COLUMN audio_url TO audio_key;
+
+UPDATE memo_audio_variants
+SET audio_key = regexp_replace(audio_key, '^https://storage\.googleapis\.com/[^/]+/', '')
+WHERE audio_key LIKE 'https://storage.googleapis.com/%';
+
+CREATE INDEX IF NOT EXISTS idx_memos_audio_key ON memos(audio_key);
@@ -0,0 +1,14 @@
+-- Server-side transcription status on memos: NULL (not requested), pending,
+-- completed, failed or skipped
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS transcription_status VARCHAR(20);
+
+-- Server-generated transcripts with per-word timings and confidence
+CREATE TABLE IF NOT EXISTS memo_transcripts (
+    memo_id UUID PRIMARY KEY REFERENCES memos(memo_id) ON DELETE CASCADE,
+    engine VARCHAR(50) NOT NULL,
+    language VARCHAR(10),
+    text TEXT NOT NULL,
+    words JSONB NOT NULL DEFAULT '[]',
+    confidence REAL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Sentence-level segments of a transcript, derived from its word timings
+ALTER TABLE memo_transcripts ADD COLUMN IF NOT EXISTS segments JSONB NOT NULL DEFAULT '[]';
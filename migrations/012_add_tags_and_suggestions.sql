@@ -0,0 +1,16 @@
+-- Tags and category on memos
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_memos_tags ON memos USING gin(tags);
+CREATE INDEX IF NOT EXISTS idx_memos_category ON memos(category);
+
+-- Tags and category suggested from a memo's text by the keyword vocabulary
+CREATE TABLE IF NOT EXISTS memo_tag_suggestions (
+    memo_id UUID PRIMARY KEY REFERENCES memos(memo_id) ON DELETE CASCADE,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(50),
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP
+);
@@ -0,0 +1,21 @@
+-- array_to_string is only STABLE, which generated columns don't allow. Joining
+-- a text[] with a fixed separator is immutable in practice.
+CREATE OR REPLACE FUNCTION immutable_array_to_string(arr TEXT[], sep TEXT)
+RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT array_to_string(arr, sep) $$;
+
+-- Weighted full-text search vector: title and tags rank highest, then park
+-- name, then the memo text
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', immutable_array_to_string(tags, ' ')), 'A') ||
+        setweight(to_tsvector('english', coalesce(park_name, '')), 'B') ||
+        setweight(to_tsvector('english', text), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_memos_search_vector ON memos USING gin(search_vector);
+
+-- Replaced by the search vector index
+DROP INDEX IF EXISTS idx_memos_text_search;
@@ -0,0 +1,8 @@
+-- Trigram matching for typo-tolerant search and autocomplete. Transcripts
+-- contain recognition errors ("Sour dough trail") that stemming can't fix.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Support the <% word-similarity operator and ILIKE prefix lookups
+CREATE INDEX IF NOT EXISTS idx_memos_text_trgm ON memos USING gin(text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_memos_title_trgm ON memos USING gin(title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_memos_park_name_trgm ON memos USING gin(park_name gin_trgm_ops);
@@ -0,0 +1,39 @@
+-- Saved searches notify their owner when a new memo matches
+CREATE TABLE IF NOT EXISTS saved_searches (
+    search_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    notify_in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    notify_email BOOLEAN NOT NULL DEFAULT FALSE,
+    webhook_url TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user ON saved_searches(user_id);
+
+-- Memos a saved search has already notified about, so edits and late
+-- transcripts don't notify twice
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    search_id UUID NOT NULL REFERENCES saved_searches(search_id) ON DELETE CASCADE,
+    memo_id UUID NOT NULL REFERENCES memos(memo_id) ON DELETE CASCADE,
+    matched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (search_id, memo_id)
+);
+
+-- In-app notifications
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    memo_id UUID REFERENCES memos(memo_id) ON DELETE CASCADE,
+    search_id UUID REFERENCES saved_searches(search_id) ON DELETE SET NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
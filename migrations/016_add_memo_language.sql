@@ -0,0 +1,81 @@
+-- Text search configuration for a memo language. Keep in sync with
+-- models.Languages; unknown codes fall back to English.
+CREATE OR REPLACE FUNCTION memo_search_config(lang TEXT)
+RETURNS regconfig
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$
+    SELECT (CASE lang
+        WHEN 'es' THEN 'spanish'
+        WHEN 'fr' THEN 'french'
+        WHEN 'de' THEN 'german'
+        WHEN 'it' THEN 'italian'
+        WHEN 'pt' THEN 'portuguese'
+        ELSE 'english'
+    END)::regconfig
+$$;
+
+-- Add the language column and guess the language of existing memos. This
+-- runs once: on later runs the column exists and languages set since (by
+-- users or detection) are left alone.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'memos' AND column_name = 'language'
+    ) THEN
+        RETURN;
+    END IF;
+
+    -- Language of each memo as an ISO 639-1 code, used to pick its text
+    -- search configuration
+    ALTER TABLE memos ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT 'en';
+
+    -- Existing memos were all indexed as English. Mark those that use more
+    -- common Spanish words than English ones as Spanish so their crews can
+    -- search them.
+    UPDATE memos m
+    SET language = 'es'
+    FROM (
+        SELECT
+            memo_id,
+            COUNT(*) FILTER (WHERE w IN ('el', 'la', 'los', 'las', 'y', 'es', 'está', 'están', 'hay', 'del', 'con', 'por', 'para', 'que', 'un', 'una', 'cerca', 'muy')) AS es_words,
+            COUNT(*) FILTER (WHERE w IN ('the', 'and', 'is', 'are', 'was', 'of', 'to', 'in', 'on', 'at', 'with', 'there', 'this', 'that', 'it', 'near', 'from', 'by')) AS en_words
+        FROM memos, regexp_split_to_table(lower(text), '[^[:alpha:]]+') AS w
+        GROUP BY memo_id
+    ) counts
+    WHERE m.memo_id = counts.memo_id
+      AND counts.es_words >= 2
+      AND counts.es_words > counts.en_words;
+END
+$$;
+
+-- Rebuild the weighted search vector with each memo's own configuration,
+-- unless it already uses it
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM pg_attrdef d
+        JOIN pg_attribute a ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+        WHERE d.adrelid = 'memos'::regclass
+          AND a.attname = 'search_vector'
+          AND pg_get_expr(d.adbin, d.adrelid) LIKE '%memo_search_config%'
+    ) THEN
+        RETURN;
+    END IF;
+
+    DROP INDEX IF EXISTS idx_memos_search_vector;
+    ALTER TABLE memos DROP COLUMN IF EXISTS search_vector;
+
+    ALTER TABLE memos ADD COLUMN search_vector tsvector
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector(memo_search_config(language), coalesce(title, '')), 'A') ||
+            setweight(to_tsvector(memo_search_config(language), immutable_array_to_string(tags, ' ')), 'A') ||
+            setweight(to_tsvector(memo_search_config(language), coalesce(park_name, '')), 'B') ||
+            setweight(to_tsvector(memo_search_config(language), text), 'C')
+        ) STORED;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_memos_search_vector ON memos USING gin(search_vector);
+CREATE INDEX IF NOT EXISTS idx_memos_language ON memos(language);
@@ -0,0 +1,61 @@
+-- Change feed of memos for real-time clients. Rows are written by triggers so
+-- every write path is covered, and each one is announced with NOTIFY so all
+-- API instances can push it to their connected clients. Old rows are pruned
+-- by the API; they only need to live long enough for clients to resume.
+CREATE TABLE IF NOT EXISTS memo_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(30) NOT NULL,
+    memo_id UUID NOT NULL,
+    -- Snapshot of the fields clients filter on, kept for deleted memos
+    user_id VARCHAR(128),
+    park_name VARCHAR(255),
+    latitude DECIMAL(10, 8),
+    longitude DECIMAL(11, 8),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_memo_events_created ON memo_events(created_at);
+
+CREATE OR REPLACE FUNCTION record_memo_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    memo memos%ROWTYPE;
+    new_event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        memo := OLD;
+    ELSE
+        memo := NEW;
+    END IF;
+
+    INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude)
+    VALUES (
+        CASE TG_OP
+            WHEN 'INSERT' THEN 'memo.created'
+            WHEN 'UPDATE' THEN 'memo.updated'
+            ELSE 'memo.deleted'
+        END,
+        memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude
+    )
+    RETURNING event_id INTO new_event_id;
+
+    PERFORM pg_notify('memo_events', new_event_id::text);
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS memos_record_event ON memos;
+CREATE TRIGGER memos_record_event
+    AFTER INSERT OR DELETE ON memos
+    FOR EACH ROW EXECUTE FUNCTION record_memo_event();
+
+-- Skip updates that change nothing but updated_at, such as re-setting a
+-- status to its current value
+DROP TRIGGER IF EXISTS memos_record_update_event ON memos;
+CREATE TRIGGER memos_record_update_event
+    AFTER UPDATE ON memos
+    FOR EACH ROW
+    WHEN (to_jsonb(OLD) - 'updated_at' IS DISTINCT FROM to_jsonb(NEW) - 'updated_at')
+    EXECUTE FUNCTION record_memo_event();
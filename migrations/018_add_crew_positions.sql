@@ -0,0 +1,60 @@
+-- Latest shared position of each crew member. Name, color and department are
+-- copied from the user so position changes can be routed and drawn without a
+-- lookup, the same way memos keep user_name and user_color.
+CREATE TABLE IF NOT EXISTS crew_positions (
+    user_id VARCHAR(128) PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    user_name VARCHAR(255) NOT NULL,
+    user_color VARCHAR(7) NOT NULL,
+    department VARCHAR(100),
+    park_name VARCHAR(255),
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    accuracy FLOAT,
+    heading FLOAT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_crew_positions_department ON crew_positions(department);
+CREATE INDEX IF NOT EXISTS idx_crew_positions_park ON crew_positions(park_name);
+
+-- JSON for a position row. updated_at is given a zone, read as UTC like the
+-- driver does, so it decodes as an RFC 3339 timestamp.
+CREATE OR REPLACE FUNCTION crew_position_json(p crew_positions)
+RETURNS json
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT json_build_object(
+        'user_id', p.user_id,
+        'user_name', p.user_name,
+        'user_color', p.user_color,
+        'department', p.department,
+        'park_name', p.park_name,
+        'latitude', p.latitude,
+        'longitude', p.longitude,
+        'accuracy', p.accuracy,
+        'heading', p.heading,
+        'updated_at', p.updated_at AT TIME ZONE 'UTC'
+    )
+$$;
+
+-- Announce every change so all API instances can push it to their connected
+-- clients. The previous row is included so clients that can no longer see a
+-- member, after a park change or a deletion, are told they left.
+CREATE OR REPLACE FUNCTION notify_crew_position()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    PERFORM pg_notify('crew_positions', json_build_object(
+        'position', CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE crew_position_json(NEW) END,
+        'previous', CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE crew_position_json(OLD) END
+    )::text);
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS crew_positions_notify ON crew_positions;
+CREATE TRIGGER crew_positions_notify
+    AFTER INSERT OR UPDATE OR DELETE ON crew_positions
+    FOR EACH ROW EXECUTE FUNCTION notify_crew_position();
@@ -0,0 +1,146 @@
+-- Outbound webhooks: subscribers receive signed memo events over HTTP
+
+-- Status changes are recorded alongside memo.updated with the old and new
+-- values, for subscribers that only care about processing finishing
+ALTER TABLE memo_events ADD COLUMN IF NOT EXISTS changes JSONB;
+
+CREATE OR REPLACE FUNCTION record_memo_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    memo memos%ROWTYPE;
+    new_event_id BIGINT;
+    status_changes JSONB := '{}';
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        memo := OLD;
+    ELSE
+        memo := NEW;
+    END IF;
+
+    INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude)
+    VALUES (
+        CASE TG_OP
+            WHEN 'INSERT' THEN 'memo.created'
+            WHEN 'UPDATE' THEN 'memo.updated'
+            ELSE 'memo.deleted'
+        END,
+        memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude
+    )
+    RETURNING event_id INTO new_event_id;
+
+    PERFORM pg_notify('memo_events', new_event_id::text);
+
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.transcode_status IS DISTINCT FROM NEW.transcode_status THEN
+            status_changes := status_changes || jsonb_build_object('transcode_status',
+                jsonb_build_object('from', OLD.transcode_status, 'to', NEW.transcode_status));
+        END IF;
+        IF OLD.transcription_status IS DISTINCT FROM NEW.transcription_status THEN
+            status_changes := status_changes || jsonb_build_object('transcription_status',
+                jsonb_build_object('from', OLD.transcription_status, 'to', NEW.transcription_status));
+        END IF;
+
+        IF status_changes <> '{}' THEN
+            INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude, changes)
+            VALUES ('status.changed', memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude, status_changes)
+            RETURNING event_id INTO new_event_id;
+
+            PERFORM pg_notify('memo_events', new_event_id::text);
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description VARCHAR(200),
+    event_types TEXT[] NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user ON webhook_subscriptions(user_id);
+
+-- One event to deliver to one subscription. The event is copied so it can
+-- be delivered after memo_events has been pruned; the body is built on the
+-- first attempt and reused so retries are identical.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    memo_id UUID NOT NULL,
+    changes JSONB,
+    occurred_at TIMESTAMP NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+
+-- Every request made for a delivery, for the delivery log
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    attempt_id BIGSERIAL PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(delivery_id) ON DELETE CASCADE,
+    status_code INTEGER,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id);
+
+DROP TRIGGER IF EXISTS update_webhook_subscriptions_updated_at ON webhook_subscriptions;
+CREATE TRIGGER update_webhook_subscriptions_updated_at
+    BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_webhook_deliveries_updated_at ON webhook_deliveries;
+CREATE TRIGGER update_webhook_deliveries_updated_at
+    BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Fan each memo event out to the subscriptions that want it, in the same
+-- transaction as the change, and queue a delivery job for each. The attempt
+-- limit matches models.WebhookMaxAttempts.
+CREATE OR REPLACE FUNCTION enqueue_webhook_deliveries()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    WITH deliveries AS (
+        INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, memo_id, changes, occurred_at)
+        SELECT subscription_id, NEW.event_id, NEW.event_type, NEW.memo_id, NEW.changes, NEW.created_at
+        FROM webhook_subscriptions
+        WHERE active AND NEW.event_type = ANY(event_types)
+        RETURNING delivery_id
+    )
+    INSERT INTO jobs (job_type, payload, max_attempts)
+    SELECT 'webhook.deliver', jsonb_build_object('delivery_id', delivery_id), 10
+    FROM deliveries;
+
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS memo_events_enqueue_webhooks ON memo_events;
+CREATE TRIGGER memo_events_enqueue_webhooks
+    AFTER INSERT ON memo_events
+    FOR EACH ROW EXECUTE FUNCTION enqueue_webhook_deliveries();
@@ -0,0 +1,24 @@
+-- Per-user email settings. Users without a row get the defaults.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id VARCHAR(128) PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    email_immediate BOOLEAN NOT NULL DEFAULT TRUE,
+    digest_frequency VARCHAR(10) NOT NULL DEFAULT 'off' CHECK (digest_frequency IN ('off', 'daily', 'weekly')),
+    digest_parks TEXT[] NOT NULL DEFAULT '{}',
+    digest_hour INTEGER NOT NULL DEFAULT 7 CHECK (digest_hour BETWEEN 0 AND 23),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    -- When the next digest is due (UTC) and the end of the last one sent
+    next_digest_at TIMESTAMP,
+    last_digest_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_due
+    ON notification_preferences(next_digest_at)
+    WHERE digest_frequency <> 'off';
+
+DROP TRIGGER IF EXISTS update_notification_preferences_updated_at ON notification_preferences;
+CREATE TRIGGER update_notification_preferences_updated_at
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
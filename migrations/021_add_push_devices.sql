@@ -0,0 +1,29 @@
+-- Devices registered for push notifications. A token belongs to one user at
+-- a time; registering it again (e.g. after signing in as someone else on a
+-- shared crew phone) moves it to the new user.
+CREATE TABLE IF NOT EXISTS push_devices (
+    device_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    platform VARCHAR(10) NOT NULL CHECK (platform IN ('ios', 'android')),
+    provider VARCHAR(10) NOT NULL CHECK (provider IN ('apns', 'fcm')),
+    token TEXT NOT NULL,
+    app_version VARCHAR(50),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_user ON push_devices(user_id);
+
+DROP TRIGGER IF EXISTS update_push_devices_updated_at ON push_devices;
+CREATE TRIGGER update_push_devices_updated_at
+    BEFORE UPDATE ON push_devices
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Push is a channel of its own on saved searches, e.g. to be alerted on the
+-- trail when a memo is recorded near a saved location
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS notify_push BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Users can silence push on all their devices at once
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS push_enabled BOOLEAN NOT NULL DEFAULT TRUE;
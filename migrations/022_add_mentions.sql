@@ -0,0 +1,23 @@
+-- Users @mentioned in a memo's title or text. Each user is mentioned at most
+-- once per memo, so editing a memo does not notify them again. Mentions an
+-- edit removes are kept (see 032_keep_removed_mentions), so adding one back
+-- doesn't notify either.
+CREATE TABLE IF NOT EXISTS mentions (
+    mention_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    memo_id UUID NOT NULL REFERENCES memos(memo_id) ON DELETE CASCADE,
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    mentioned_by VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    handle VARCHAR(200) NOT NULL,
+    context TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (memo_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_created ON mentions(user_id, created_at DESC);
+
+-- Mentions are resolved against display names with everything but letters
+-- and digits removed
+CREATE INDEX IF NOT EXISTS idx_users_mention_key
+    ON users (lower(regexp_replace(display_name, '[^[:alnum:]]', '', 'g')));
+CREATE INDEX IF NOT EXISTS idx_users_mention_first_name
+    ON users (lower(regexp_replace(split_part(trim(display_name), ' ', 1), '[^[:alnum:]]', '', 'g')));
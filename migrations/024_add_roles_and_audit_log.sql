@@ -0,0 +1,50 @@
+-- Roles: members record memos, supervisors oversee crews, admins manage
+-- roles and read the audit log. Promote the first admin by hand:
+--   UPDATE users SET role = 'admin' WHERE email = 'someone@example.org';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'member'
+    CHECK (role IN ('member', 'supervisor', 'admin'));
+
+-- Append-only log of every mutating action. Actors and targets are plain
+-- IDs rather than foreign keys, so entries outlive the users and memos they
+-- describe.
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_id VARCHAR(128),
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(30) NOT NULL,
+    target_id VARCHAR(128),
+    before JSONB,
+    after JSONB,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR(100),
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    method VARCHAR(10),
+    path TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred ON audit_log(occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, occurred_at DESC);
+
+-- Entries can't be changed or removed, even by the application
+CREATE OR REPLACE FUNCTION prevent_audit_log_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_audit_log_changes();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION prevent_audit_log_changes();
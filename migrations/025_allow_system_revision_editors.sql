@@ -0,0 +1,5 @@
+-- Revisions made by the server (e.g. applying a transcript) are attributed
+-- to a system editor such as 'system:transcription' rather than a user, so
+-- edited_by can no longer reference users. Revisions by deleted users keep
+-- their ID; the editor's name is simply no longer found.
+ALTER TABLE memo_revisions DROP CONSTRAINT IF EXISTS memo_revisions_edited_by_fkey;
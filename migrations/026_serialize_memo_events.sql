@@ -0,0 +1,59 @@
+-- Event IDs come from a sequence when the row is inserted, not when the
+-- transaction commits, so two concurrent memo writes could commit their
+-- events out of order and a reader that had already seen the higher ID
+-- would skip the lower one. Taking a transaction-scoped advisory lock before
+-- inserting serializes event writers until commit, so IDs become visible in
+-- the order they were assigned and "everything after N" is never missing
+-- an event.
+CREATE OR REPLACE FUNCTION record_memo_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    memo memos%ROWTYPE;
+    new_event_id BIGINT;
+    status_changes JSONB := '{}';
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('memo_events'));
+
+    IF TG_OP = 'DELETE' THEN
+        memo := OLD;
+    ELSE
+        memo := NEW;
+    END IF;
+
+    INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude)
+    VALUES (
+        CASE TG_OP
+            WHEN 'INSERT' THEN 'memo.created'
+            WHEN 'UPDATE' THEN 'memo.updated'
+            ELSE 'memo.deleted'
+        END,
+        memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude
+    )
+    RETURNING event_id INTO new_event_id;
+
+    PERFORM pg_notify('memo_events', new_event_id::text);
+
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.transcode_status IS DISTINCT FROM NEW.transcode_status THEN
+            status_changes := status_changes || jsonb_build_object('transcode_status',
+                jsonb_build_object('from', OLD.transcode_status, 'to', NEW.transcode_status));
+        END IF;
+        IF OLD.transcription_status IS DISTINCT FROM NEW.transcription_status THEN
+            status_changes := status_changes || jsonb_build_object('transcription_status',
+                jsonb_build_object('from', OLD.transcription_status, 'to', NEW.transcription_status));
+        END IF;
+
+        IF status_changes <> '{}' THEN
+            INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude, changes)
+            VALUES ('status.changed', memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude, status_changes)
+            RETURNING event_id INTO new_event_id;
+
+            PERFORM pg_notify('memo_events', new_event_id::text);
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
@@ -0,0 +1,4 @@
+-- Receivers' response bodies are no longer kept in the delivery log: a
+-- webhook URL pointing at an internal service would otherwise let its owner
+-- read that service's responses through the API. Only status codes remain.
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS response_body;
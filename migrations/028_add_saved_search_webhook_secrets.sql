@@ -0,0 +1,9 @@
+-- Saved search webhooks are signed like memo event webhooks, so each search
+-- with a webhook needs a secret. Existing webhooks are given one; owners can
+-- read it from the saved search.
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_secret VARCHAR(100);
+
+UPDATE saved_searches
+SET webhook_secret = 'whsec_' || replace(gen_random_uuid()::text, '-', '') || replace(gen_random_uuid()::text, '-', '')
+WHERE webhook_url IS NOT NULL
+AND webhook_secret IS NULL;
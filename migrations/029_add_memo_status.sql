@@ -0,0 +1,8 @@
+-- Workflow status of the issue a memo reports: open when recorded, in
+-- progress once a crew picks it up, resolved when fixed. resolved_at is set
+-- when a memo becomes resolved and cleared if it is reopened.
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'open'
+    CHECK (status IN ('open', 'in_progress', 'resolved'));
+ALTER TABLE memos ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_memos_status ON memos(status, created_at DESC);
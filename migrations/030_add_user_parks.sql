@@ -0,0 +1,9 @@
+-- Parks a member is assigned to besides their department. Crew presence
+-- lets members follow their department and these parks only; supervisors
+-- and admins can follow any park.
+CREATE TABLE IF NOT EXISTS user_parks (
+    user_id VARCHAR(128) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    park_name VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, park_name)
+);
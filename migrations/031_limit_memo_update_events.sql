@@ -0,0 +1,73 @@
+-- memo.updated was recorded for every change to a memo row, so background
+-- jobs writing transcode and transcription progress, durations or audio keys
+-- looked like edits to subscribers. It is now only recorded when a field
+-- users edit changes. Processing statuses and the workflow status are
+-- reported by status.changed alone; other background writes record no event.
+CREATE OR REPLACE FUNCTION record_memo_event()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    memo memos%ROWTYPE;
+    new_event_id BIGINT;
+    status_changes JSONB := '{}';
+BEGIN
+    -- Serialize event writers until commit (see 026_serialize_memo_events)
+    PERFORM pg_advisory_xact_lock(hashtext('memo_events'));
+
+    IF TG_OP = 'DELETE' THEN
+        memo := OLD;
+    ELSE
+        memo := NEW;
+    END IF;
+
+    IF TG_OP <> 'UPDATE' OR
+        OLD.title IS DISTINCT FROM NEW.title OR
+        OLD.text IS DISTINCT FROM NEW.text OR
+        OLD.park_name IS DISTINCT FROM NEW.park_name OR
+        OLD.category IS DISTINCT FROM NEW.category OR
+        OLD.tags IS DISTINCT FROM NEW.tags OR
+        OLD.language IS DISTINCT FROM NEW.language OR
+        OLD.latitude IS DISTINCT FROM NEW.latitude OR
+        OLD.longitude IS DISTINCT FROM NEW.longitude
+    THEN
+        INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude)
+        VALUES (
+            CASE TG_OP
+                WHEN 'INSERT' THEN 'memo.created'
+                WHEN 'UPDATE' THEN 'memo.updated'
+                ELSE 'memo.deleted'
+            END,
+            memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude
+        )
+        RETURNING event_id INTO new_event_id;
+
+        PERFORM pg_notify('memo_events', new_event_id::text);
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.status IS DISTINCT FROM NEW.status THEN
+            status_changes := status_changes || jsonb_build_object('status',
+                jsonb_build_object('from', OLD.status, 'to', NEW.status));
+        END IF;
+        IF OLD.transcode_status IS DISTINCT FROM NEW.transcode_status THEN
+            status_changes := status_changes || jsonb_build_object('transcode_status',
+                jsonb_build_object('from', OLD.transcode_status, 'to', NEW.transcode_status));
+        END IF;
+        IF OLD.transcription_status IS DISTINCT FROM NEW.transcription_status THEN
+            status_changes := status_changes || jsonb_build_object('transcription_status',
+                jsonb_build_object('from', OLD.transcription_status, 'to', NEW.transcription_status));
+        END IF;
+
+        IF status_changes <> '{}' THEN
+            INSERT INTO memo_events (event_type, memo_id, user_id, park_name, latitude, longitude, changes)
+            VALUES ('status.changed', memo.memo_id, memo.user_id, memo.park_name, memo.latitude, memo.longitude, status_changes)
+            RETURNING event_id INTO new_event_id;
+
+            PERFORM pg_notify('memo_events', new_event_id::text);
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
@@ -0,0 +1,8 @@
+-- Mentions an edit removes are kept with removed_at set instead of being
+-- deleted, so adding the mention back restores it without notifying the
+-- user a second time.
+ALTER TABLE mentions ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_mentions_user_created;
+CREATE INDEX IF NOT EXISTS idx_mentions_user_active
+    ON mentions(user_id, created_at DESC) WHERE removed_at IS NULL;